@@ -3,6 +3,7 @@ module helm-charts-migrator
 go 1.25
 
 require (
+	filippo.io/age v1.2.1
 	github.com/elioetibr/golang-yaml-advanced v1.2.0
 	github.com/getsops/sops/v3 v3.10.2
 	github.com/spf13/cobra v1.10.1
@@ -28,7 +29,6 @@ require (
 	cloud.google.com/go/monitoring v1.24.2 // indirect
 	cloud.google.com/go/storage v1.57.0 // indirect
 	dario.cat/mergo v1.0.2 // indirect
-	filippo.io/age v1.2.1 // indirect
 	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/Azure/azure-sdk-for-go/sdk/azcore v1.19.1 // indirect
 	github.com/Azure/azure-sdk-for-go/sdk/azidentity v1.12.0 // indirect
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// configCmd groups the commands working on the migrator configuration
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Work with the migrator configuration",
+	Long: `Work with the migrator configuration file.
+
+Examples:
+  # Check config.yaml for misspelt keys and invalid values
+  helm-charts-migrator config validate`,
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+}
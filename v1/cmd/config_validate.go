@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/errkind"
+)
+
+// configValidateCmd checks a configuration file without running anything
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "Check a configuration file or directory for unknown keys and invalid values",
+	Long: `Validate decodes a configuration file, with the files it includes, a
+configuration directory or the ConfigMaps of cluster://<context>/<namespace>
+strictly and reports every problem with its file:line:column:
+
+- keys that match no configuration field, e.g. path_pattern for path_patterns
+- values of the wrong type, e.g. a string where a boolean is expected
+- auto-inject conditions other than ifExists, ifNotExists, always and disabled
+- secrets, normalizer, cleaner and SOPS patterns that are not valid regular expressions
+- base chart paths that do not exist, relative to the working directory
+- clusters migrating into a target already used by another cluster
+- includes that cannot be found or that include each other
+
+The file defaults to the one given with --config. Other commands refuse to run
+with a configuration that fails these checks; only migrate also needs the base
+charts to exist.
+
+Examples:
+  # Validate ./config.yaml
+  helm-charts-migrator config validate
+
+  # Validate another file
+  helm-charts-migrator config validate configs/staging.yaml
+
+  # Validate a directory split into globals/, clusters/ and services/
+  helm-charts-migrator config validate configs/
+
+  # Validate the ConfigMaps of the migrator namespace of prod01
+  helm-charts-migrator config validate cluster://prod01/migrator`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigValidate,
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	path := cfgFile
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	_, problems, err := config.ValidatePath(path)
+	if err != nil {
+		return err
+	}
+	return reportConfigProblems(cmd.OutOrStdout(), path, problems)
+}
+
+// reportConfigProblems prints the problems of a configuration file, failing
+// when there are any
+func reportConfigProblems(out io.Writer, path string, problems config.ValidationErrors) error {
+	if len(problems) == 0 {
+		fmt.Fprintf(out, "✓ %s is valid\n", path)
+		return nil
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintln(out, problem.Error())
+	}
+	summary := fmt.Sprintf("%s has %d problems", path, len(problems))
+	if len(problems) == 1 {
+		summary = fmt.Sprintf("%s has 1 problem", path)
+	}
+	fmt.Fprintf(out, "\n❌ %s\n", summary)
+	return errkind.New(errkind.Configuration, "%s", summary)
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/errkind"
+)
+
+func TestReportConfigProblems(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, reportConfigProblems(&out, "config.yaml", nil))
+	assert.Equal(t, "✓ config.yaml is valid\n", out.String())
+
+	out.Reset()
+	err := reportConfigProblems(&out, "config.yaml", config.ValidationErrors{
+		{File: "config.yaml", Line: 12, Column: 7, Path: "globals.secrets.locations.path_pattern", Message: `unknown field "path_pattern"`},
+	})
+	assert.Equal(t, errkind.Configuration, errkind.Classify(err))
+	assert.Equal(t, `config.yaml:12:7: globals.secrets.locations.path_pattern: unknown field "path_pattern"
+
+❌ config.yaml has 1 problem
+`, out.String())
+}
@@ -16,6 +16,7 @@ var (
 	inspectNamespace string
 	inspectVerbose   bool
 	inspectFormat    string
+	inspectExplain   string
 )
 
 // inspectCmd represents the inspect command
@@ -42,7 +43,12 @@ Examples:
   helm-charts-migrator inspect --service heimdall --verbose
 
   # Output in different formats
-  helm-charts-migrator inspect --service heimdall --format json`,
+  helm-charts-migrator inspect --service heimdall --format json
+
+  # Explain which layer (defaults, globals, cluster, environment, namespace,
+  # service) set a value and which layers it overrode
+  helm-charts-migrator inspect --service heimdall --cluster prod01 --explain mappings.cleaner.key_patterns
+  helm-charts-migrator inspect --service heimdall --explain migration --format json`,
 	RunE: runInspect,
 }
 
@@ -54,6 +60,7 @@ func init() {
 	inspectCmd.Flags().StringVarP(&inspectNamespace, "namespace", "n", "", "Namespace for context")
 	inspectCmd.Flags().BoolVar(&inspectVerbose, "verbose", false, "Show detailed configuration hierarchy")
 	inspectCmd.Flags().StringVarP(&inspectFormat, "format", "f", "text", "Output format (text, json, yaml)")
+	inspectCmd.Flags().StringVar(&inspectExplain, "explain", "", "Explain where the effective value of a key comes from, e.g. mappings.cleaner.key_patterns")
 }
 
 func runInspect(cmd *cobra.Command, args []string) error {
@@ -66,6 +73,10 @@ func runInspect(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	if inspectExplain != "" {
+		return explainConfig(cmd.OutOrStdout(), cfg, inspectExplain, inspectService, inspectCluster, inspectNamespace, inspectFormat)
+	}
+
 	// If specific service requested, inspect that service
 	if inspectService != "" {
 		return inspectServiceConfig(cfg, inspectService, inspectCluster, inspectNamespace)
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/errkind"
+)
+
+// explainConfig prints where the effective value of a configuration key comes
+// from, for a service, cluster and namespace when given
+func explainConfig(out io.Writer, cfg *config.Config, key, serviceName, clusterName, namespaceName, format string) error {
+	if format != "text" && format != "json" {
+		return errkind.New(errkind.Configuration, "unsupported format %q for --explain, expected text or json", format)
+	}
+	if serviceName != "" {
+		if _, exists := cfg.Services[serviceName]; !exists {
+			return errkind.New(errkind.NotFound, "service '%s' not found in configuration", serviceName)
+		}
+	}
+	if clusterName != "" && cfg.GetCluster(clusterName) == nil {
+		return errkind.New(errkind.NotFound, "cluster '%s' not found", clusterName)
+	}
+
+	hierarchy, err := cfg.Hierarchy()
+	if err != nil {
+		return fmt.Errorf("failed to build configuration hierarchy: %w", err)
+	}
+	environment := cfg.GetClusterEnvironment(clusterName)
+	explanations := hierarchy.GetEffectiveConfig(clusterName, environment, namespaceName, serviceName).Explain(key)
+	if len(explanations) == 0 {
+		return errkind.New(errkind.NotFound, "%s is not set in the effective configuration", key)
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(explanations)
+	}
+
+	for _, explanation := range explanations {
+		fmt.Fprintf(out, "%s = %s (from %s)\n", explanation.Path, formatExplainValue(explanation.Value), explanation.Layer)
+		for _, source := range explanation.Merged {
+			fmt.Fprintf(out, "  merges %s: %s\n", source.Layer, formatExplainValue(source.Value))
+		}
+		for _, source := range explanation.Overrode {
+			fmt.Fprintf(out, "  overrides %s: %s\n", source.Layer, formatExplainValue(source.Value))
+		}
+	}
+	return nil
+}
+
+// formatExplainValue writes a value on one line, lists and mappings as JSON
+func formatExplainValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
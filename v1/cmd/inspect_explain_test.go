@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/errkind"
+)
+
+func TestExplainConfig(t *testing.T) {
+	cfg := &config.Config{
+		Globals: config.Globals{
+			Mappings: &config.Mappings{Cleaner: &config.Cleaner{KeyPatterns: []string{"^a$"}}},
+			Secrets:  &config.Secrets{Keys: []string{"password"}},
+		},
+		Services: map[string]config.Service{
+			"heimdall": {
+				Mappings: &config.Mappings{Cleaner: &config.Cleaner{KeyPatterns: []string{"^b$"}}},
+				Secrets:  &config.Secrets{Keys: []string{"apiKey"}},
+			},
+		},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, explainConfig(&out, cfg, "mappings.cleaner.key_patterns", "heimdall", "", "", "text"))
+	assert.Equal(t, `mappings.cleaner.key_patterns = ["^b$"] (from service:heimdall)
+  overrides globals: ["^a$"]
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, explainConfig(&out, cfg, "secrets.keys", "heimdall", "", "", "text"))
+	assert.Equal(t, `secrets.keys = ["apiKey","password"] (from service:heimdall)
+  merges globals: ["password"]
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, explainConfig(&out, cfg, "mappings.cleaner.key_patterns", "", "", "", "json"))
+	var explanations []config.Explanation
+	require.NoError(t, json.Unmarshal(out.Bytes(), &explanations))
+	require.Len(t, explanations, 1)
+	assert.Equal(t, "globals", explanations[0].Layer)
+	assert.Empty(t, explanations[0].Overrode)
+
+	err := explainConfig(&out, cfg, "mappings.missing", "heimdall", "", "", "text")
+	assert.Equal(t, errkind.NotFound, errkind.Classify(err))
+
+	err = explainConfig(&out, cfg, "mappings", "unknown", "", "", "text")
+	assert.Equal(t, errkind.NotFound, errkind.Classify(err))
+
+	err = explainConfig(&out, cfg, "mappings", "heimdall", "", "", "yaml")
+	assert.Equal(t, errkind.Configuration, errkind.Classify(err))
+}
@@ -19,27 +19,43 @@ var (
 	services          []string
 	migrateAwsProfile string
 	noSOPS            bool
+	runsDir           string
+	resumeRunID       string
 )
 
 var migrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Migrate Helm charts",
-	Long:  `Migrate Helm charts from a source to a target location with various options.`,
+	Long: `Migrate Helm charts from a source to a target location with various options.
+
+The configuration is read from --config, which may be a file, a directory split
+into globals/, clusters/ and services/, or cluster://<context>/<namespace> to
+read the ConfigMaps labelled helm-charts-migrator/config in that namespace.
+
+Examples:
+  # Migrate with ./config.yaml
+  helm-charts-migrator migrate
+
+  # Migrate with the configuration kept in the migrator namespace of prod01
+  helm-charts-migrator migrate --config cluster://prod01/migrator`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return migration.RunMigrationWithFactory(migration.MigratorOptions{
-			ConfigPath:   cfgFile,
-			SourcePath:   sourcePath,
-			TargetPath:   targetPath,
-			BasePath:     baseHelmChart,
-			CacheDir:     cacheDir,
-			CleanupCache: cleanupCache,
-			RefreshCache: !noRefreshCache,
-			DryRun:       dryRun,
-			Cluster:      cluster,
-			Namespaces:   namespaces,
-			Services:     services,
-			AwsProfile:   migrateAwsProfile,
-			NoSOPS:       noSOPS,
+			ConfigPath:    cfgFile,
+			SourcePath:    sourcePath,
+			TargetPath:    targetPath,
+			BasePath:      baseHelmChart,
+			CacheDir:      cacheDir,
+			CleanupCache:  cleanupCache,
+			RefreshCache:  !noRefreshCache,
+			DryRun:        dryRun,
+			Cluster:       cluster,
+			Namespaces:    namespaces,
+			Services:      services,
+			AwsProfile:    migrateAwsProfile,
+			AwsProfileSet: cmd.Flags().Changed("aws-profile"),
+			NoSOPS:        noSOPS,
+			RunsDir:       runsDir,
+			Resume:        resumeRunID,
 		})
 	},
 }
@@ -56,6 +72,8 @@ func init() {
 	migrateCmd.Flags().BoolVar(&noRefreshCache, "no-refresh-cache", false, "Skip checking if cache is outdated (use existing cache as-is)")
 	migrateCmd.Flags().StringVar(&migrateAwsProfile, "aws-profile", "cicd-sre", "AWS profile to use for SOPS encryption during secrets extraction")
 	migrateCmd.Flags().BoolVar(&noSOPS, "no-sops", false, "Skip SOPS encryption of secrets files")
+	migrateCmd.Flags().StringVar(&runsDir, "runs-dir", migration.DefaultRunsDir, "Directory where run journals and logs are stored")
+	migrateCmd.Flags().StringVar(&resumeRunID, "resume", "", "Resume a previous run by ID, skipping services/namespaces it already completed")
 
 	// New flags for selective migration
 	migrateCmd.Flags().StringVarP(&cluster, "cluster", "c", "", "Specific cluster to migrate (optional)")
@@ -240,6 +240,8 @@ func TestMigrateCommand_Flags(t *testing.T) {
 		"cluster",
 		"namespaces",
 		"services",
+		"runs-dir",
+		"resume",
 	}
 	
 	for _, flagName := range expectedFlags {
@@ -253,6 +255,8 @@ func TestMigrateCommand_Flags(t *testing.T) {
 	assert.Equal(t, "apps/", migrateCmd.Flag("target").DefValue)
 	assert.Equal(t, ".cache", migrateCmd.Flag("cache-dir").DefValue)
 	assert.Equal(t, "cicd-sre", migrateCmd.Flag("aws-profile").DefValue)
+	assert.Equal(t, ".runs", migrateCmd.Flag("runs-dir").DefValue)
+	assert.Equal(t, "", migrateCmd.Flag("resume").DefValue)
 }
 
 func TestMigrateCommand_Integration(t *testing.T) {
@@ -9,10 +9,16 @@ import (
 	"github.com/spf13/viper"
 	"k8s.io/klog/v2"
 
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/errkind"
 	"helm-charts-migrator/v1/pkg/logger"
 )
 
-var cfgFile string
+var (
+	cfgFile    string
+	logFormat  string
+	configSets []string
+)
 
 var rootCmd = &cobra.Command{
 	Use:   "helm-charts-migrator",
@@ -30,17 +36,47 @@ Get started:
   3. Run the migration:
      $ helm-charts-migrator migrate
 
-For more information, use --help with any command.`,
+Configuration values can be overridden with HCM_* environment variables, e.g.
+HCM_GLOBALS_SOPS_AWS_PROFILE=ci, and repeatable --config-set path=value flags,
+e.g. --config-set globals.sops.awsProfile=ci, which win over the environment.
+
+For more information, use --help with any command.
+
+Exit codes:
+  0  success
+  1  unexpected error
+  2  configuration error
+  3  validation error
+  4  not found
+  5  transient network error
+  6  Kubernetes API throttling
+  7  AWS KMS throttling`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		format, err := logger.ParseFormat(logFormat)
+		if err != nil {
+			return errkind.Wrap(errkind.Configuration, err)
+		}
+		logger.SetFormat(format)
+
+		overrides, err := config.ParseSetOverrides(configSets)
+		if err != nil {
+			return errkind.Wrap(errkind.Configuration, err)
+		}
+		config.SetOverrides(overrides)
+		return nil
+	},
 }
 
 func Execute() {
 	defer logger.Flush()
 	err := rootCmd.Execute()
 	if err != nil {
-		logger.Error(err, "Failed to execute command")
-		os.Exit(1)
+		kind := errkind.Classify(err)
+		logger.Error(err, "Failed to execute command", "kind", kind.String(), "exitCode", kind.ExitCode())
+		logger.Flush()
+		os.Exit(kind.ExitCode())
 	}
 }
 
@@ -51,7 +87,17 @@ func init() {
 		StringVar(&cfgFile,
 			"config",
 			"./config.yaml",
-			"config file (default is ./config.yaml)")
+			"config file, config directory or cluster://<context>/<namespace> to read labelled ConfigMaps")
+	rootCmd.PersistentFlags().
+		StringVar(&logFormat,
+			"log-format",
+			string(logger.FormatText),
+			"log output format: text or json")
+	rootCmd.PersistentFlags().
+		StringArrayVar(&configSets,
+			"config-set",
+			nil,
+			"override a config value, e.g. globals.sops.awsProfile=x (repeatable, wins over HCM_* environment variables)")
 
 	// Add klog flags to the command
 	fs := flag.NewFlagSet("klog", flag.ExitOnError)
@@ -1,16 +1,18 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/errkind"
 	"helm-charts-migrator/v1/pkg/logger"
+	"helm-charts-migrator/v1/pkg/secrets"
+	svc "helm-charts-migrator/v1/pkg/services"
 	"helm-charts-migrator/v1/pkg/sops"
-	yaml "github.com/elioetibr/golang-yaml-advanced"
 
 	"github.com/spf13/cobra"
 )
@@ -22,7 +24,11 @@ var (
 	encryptOnly     bool
 	decryptOnly     bool
 	validateOnly    bool
+	scanOnly        bool
+	scanConfidence  string
 	awsProfile      string
+	ageKeyFile      string
+	ageRecipients   []string
 	patterns        []string
 )
 
@@ -34,7 +40,7 @@ var secretsCmd = &cobra.Command{
 
 This command extracts the 'secrets' key from environment-specific values.yaml files
 into separate secrets.dec.yaml files, and provides encryption/decryption capabilities
-using SOPS with AWS KMS.
+using SOPS with AWS KMS, age or PGP keys.
 
 You can specify a path to process specific directories or files. If no path is provided,
 it will process services specified with --services flag or all enabled services.
@@ -55,8 +61,15 @@ Examples:
   # Decrypt .enc files in a specific environment
   helm-charts-migrator secrets apps/heimdall/envs/dev01 --decrypt-only
   
+  # Encrypt and decrypt with a local age key instead of AWS KMS
+  helm-charts-migrator secrets apps/heimdall --encrypt-only --age-recipient age1...
+  helm-charts-migrator secrets apps/heimdall --decrypt-only --age-key-file ~/.config/sops/age/keys.txt
+  
   # Validate all secrets files are properly encrypted
-  helm-charts-migrator secrets --validate`,
+  helm-charts-migrator secrets --validate
+
+  # Fail if plaintext secrets leaked into generated files (e.g. as a pre-commit hook)
+  helm-charts-migrator secrets --scan apps`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runSecrets,
 }
@@ -65,12 +78,16 @@ func init() {
 	rootCmd.AddCommand(secretsCmd)
 
 	secretsCmd.Flags().StringSliceVarP(&secretsServices, "services", "s", []string{}, "Services to process (comma-separated)")
-	secretsCmd.Flags().StringVarP(&sopsConfigPath, "sops-config", "", ".sops.yaml", "Path to SOPS configuration file")
-	secretsCmd.Flags().StringVarP(&awsProfile, "aws-profile", "p", "cicd-sre", "AWS profile to use for KMS operations")
+	secretsCmd.PersistentFlags().StringVarP(&sopsConfigPath, "sops-config", "", ".sops.yaml", "Path to SOPS configuration file")
+	secretsCmd.PersistentFlags().StringVarP(&awsProfile, "aws-profile", "p", "cicd-sre", "AWS profile to use for KMS operations")
+	secretsCmd.PersistentFlags().StringVar(&ageKeyFile, "age-key-file", "", "age identity file used to decrypt (sets SOPS_AGE_KEY_FILE)")
+	secretsCmd.PersistentFlags().StringSliceVar(&ageRecipients, "age-recipient", []string{}, "Encrypt with these age recipients instead of the SOPS creation rules (can be specified multiple times)")
 	secretsCmd.Flags().BoolVar(&extractOnly, "extract-only", false, "Only extract secrets, don't encrypt")
 	secretsCmd.Flags().BoolVar(&encryptOnly, "encrypt-only", false, "Only encrypt existing .dec files")
 	secretsCmd.Flags().BoolVar(&decryptOnly, "decrypt-only", false, "Only decrypt existing .enc files")
 	secretsCmd.Flags().BoolVar(&validateOnly, "validate", false, "Validate that all secrets files are properly encrypted")
+	secretsCmd.Flags().BoolVar(&scanOnly, "scan", false, "Scan non-encrypted files for plaintext secrets and fail on findings (default path: apps)")
+	secretsCmd.Flags().StringVar(&scanConfidence, "min-confidence", "medium", "Lowest confidence reported by --scan (low, medium, high)")
 	secretsCmd.Flags().StringSliceVar(&patterns, "pattern", []string{}, "File patterns to process (can be specified multiple times, default: current directory)")
 	if len(patterns) == 0 {
 		patterns = []string{"."}
@@ -81,20 +98,21 @@ func runSecrets(cmd *cobra.Command, args []string) error {
 	// Initialize logger
 	log := logger.WithName("secrets")
 
-	// Set AWS profile environment variable for SOPS
-	if awsProfile != "" {
-		if err := os.Setenv("AWS_PROFILE", awsProfile); err != nil {
-			return fmt.Errorf("failed to set AWS_PROFILE: %w", err)
-		}
-		log.V(1).InfoS("Using AWS profile for SOPS operations", "profile", awsProfile)
+	// Create the SOPS service shared with the migrate command
+	sopsConfig, err := secretsSOPSConfig(cmd, log)
+	if err != nil {
+		return err
 	}
-
-	// Create SOPS manager
-	sopsManager := sops.NewManager(log, sopsConfigPath)
+	sopsService := svc.NewSOPSService(sopsConfig)
 
 	// Handle validate mode
 	if validateOnly {
-		return validateSecrets(sopsManager, log, args)
+		return validateSecrets(sopsService, log, args)
+	}
+
+	// Handle scan mode
+	if scanOnly {
+		return scanSecrets(cmd, log, args)
 	}
 
 	// Check if a specific path was provided as argument or use patterns
@@ -116,12 +134,12 @@ func runSecrets(cmd *cobra.Command, args []string) error {
 
 			// Process the specific path
 			if decryptOnly {
-				if err := decryptPath(targetPath, sopsManager, log); err != nil {
+				if err := decryptPath(targetPath, sopsService, log); err != nil {
 					log.Error(err, "Failed to decrypt path", "path", targetPath)
 					continue
 				}
 			} else if encryptOnly {
-				if err := encryptPath(targetPath, sopsManager, log); err != nil {
+				if err := encryptPath(targetPath, sopsService, log); err != nil {
 					log.Error(err, "Failed to encrypt path", "path", targetPath)
 					continue
 				}
@@ -132,7 +150,7 @@ func runSecrets(cmd *cobra.Command, args []string) error {
 					continue
 				}
 				if !extractOnly {
-					if err := encryptPath(targetPath, sopsManager, log); err != nil {
+					if err := encryptPath(targetPath, sopsService, log); err != nil {
 						log.Error(err, "Failed to encrypt secrets", "path", targetPath)
 						continue
 					}
@@ -154,9 +172,9 @@ func runSecrets(cmd *cobra.Command, args []string) error {
 			log.V(1).InfoS("Config file not found, processing current directory", "mode", getOperationMode())
 
 			if decryptOnly {
-				return decryptPath(".", sopsManager, log)
+				return decryptPath(".", sopsService, log)
 			} else if encryptOnly {
-				return encryptPath(".", sopsManager, log)
+				return encryptPath(".", sopsService, log)
 			}
 		}
 
@@ -183,12 +201,12 @@ func runSecrets(cmd *cobra.Command, args []string) error {
 
 		// Process based on mode
 		if decryptOnly {
-			if err := decryptServiceSecrets(serviceName, sopsManager, log); err != nil {
+			if err := decryptServiceSecrets(serviceName, sopsService, log); err != nil {
 				log.Error(err, "Failed to decrypt secrets", "service", serviceName)
 				continue
 			}
 		} else if encryptOnly {
-			if err := encryptServiceSecrets(serviceName, sopsManager, log); err != nil {
+			if err := encryptServiceSecrets(serviceName, sopsService, log); err != nil {
 				log.Error(err, "Failed to encrypt secrets", "service", serviceName)
 				continue
 			}
@@ -200,7 +218,7 @@ func runSecrets(cmd *cobra.Command, args []string) error {
 			}
 
 			if !extractOnly {
-				if err := encryptServiceSecrets(serviceName, sopsManager, log); err != nil {
+				if err := encryptServiceSecrets(serviceName, sopsService, log); err != nil {
 					log.Error(err, "Failed to encrypt secrets", "service", serviceName)
 					continue
 				}
@@ -216,102 +234,60 @@ func runSecrets(cmd *cobra.Command, args []string) error {
 func extractServiceSecrets(serviceName string, log *logger.NamedLogger) error {
 	log.V(2).InfoS("Extracting secrets for service", "service", serviceName)
 
-	// Pattern: apps/{service}/envs/{cluster}/{environment}/{namespace}/values.yaml
-	pattern := filepath.Join("apps", serviceName, "envs", "*", "*", "*", "values.yaml")
-
-	files, err := filepath.Glob(pattern)
-	if err != nil {
-		return fmt.Errorf("failed to find values files: %w", err)
-	}
-
-	for _, valuesFile := range files {
-		// Create secrets file path
-		dir := filepath.Dir(valuesFile)
-		secretsFile := filepath.Join(dir, "secrets.dec.yaml")
-
-		// Extract secrets
-		if err := sops.ExtractSecretsToFile(valuesFile, secretsFile, log); err != nil {
-			log.Error(err, "Failed to extract secrets from file", "file", valuesFile)
-			continue
-		}
+	// Values files at the environment, cluster and namespace levels of
+	// apps/{service}/envs/{environment}/clusters/{cluster}/namespaces/{namespace}
+	envsDir := filepath.Join("apps", serviceName, "envs")
+	if _, err := os.Stat(envsDir); os.IsNotExist(err) {
+		log.V(2).InfoS("No environments found for service", "service", serviceName, "path", envsDir)
+		return nil
 	}
 
-	return nil
-}
-
-// encryptServiceSecrets encrypts all .dec.yaml files for a service
-func encryptServiceSecrets(serviceName string, manager *sops.Manager, log *logger.NamedLogger) error {
-	log.V(2).InfoS("Encrypting secrets for service", "service", serviceName)
-
-	// Find all .dec.yaml files
-	err := filepath.Walk(filepath.Join("apps", serviceName), func(path string, info os.FileInfo, err error) error {
-		if err != nil {
+	err := filepath.Walk(envsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
 			return err
 		}
 
-		if strings.HasSuffix(path, "secrets.dec.yaml") {
-			// Create encrypted file path
-			encPath := strings.Replace(path, ".dec.yaml", ".enc.yaml", 1)
-
-			// Encrypt the file
-			if err := manager.EncryptFile(path, encPath); err != nil {
-				log.Error(err, "Failed to encrypt file", "file", path)
-			} else {
-				// Optionally remove the .dec file after successful encryption
-				// os.Remove(path)
-			}
+		appPath, parseErr := config.ParseAppPath(path)
+		if parseErr != nil {
+			log.V(2).InfoS("Skipping file outside the environment layout", "file", path, "reason", parseErr.Error())
+			return nil
+		}
+		if appPath.Kind != config.FileKindValues || appPath.Level() == config.LevelService {
+			return nil
 		}
 
+		// Extract secrets next to the values file
+		secretsFile := filepath.Join(filepath.Dir(path), "secrets.dec.yaml")
+		if err := sops.ExtractSecretsToFile(path, secretsFile, log); err != nil {
+			log.Error(err, "Failed to extract secrets from file", "file", path)
+		}
 		return nil
 	})
-
 	if err != nil {
-		return fmt.Errorf("failed to walk directory: %w", err)
+		return fmt.Errorf("failed to find values files: %w", err)
 	}
 
 	return nil
 }
 
-// decryptServiceSecrets decrypts all .enc.yaml files for a service
-func decryptServiceSecrets(serviceName string, manager *sops.Manager, log *logger.NamedLogger) error {
-	log.V(2).InfoS("Decrypting secrets for service", "service", serviceName)
-
-	// Find all .enc.yaml files
-	err := filepath.Walk(filepath.Join("apps", serviceName), func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if strings.HasSuffix(path, "secrets.enc.yaml") {
-			// Create decrypted file path
-			decPath := strings.Replace(path, ".enc.yaml", ".dec.yaml", 1)
-
-			// Decrypt the file
-			if err := manager.DecryptFile(path, decPath); err != nil {
-				log.Error(err, "Failed to decrypt file", "file", path)
-			} else {
-				// Merge back into values.yaml if needed
-				valuesPath := filepath.Join(filepath.Dir(path), "values.yaml")
-				if err := sops.MergeSecretsIntoValues(valuesPath, decPath, log); err != nil {
-					log.Error(err, "Failed to merge secrets into values", "file", valuesPath)
-				}
-			}
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		return fmt.Errorf("failed to walk directory: %w", err)
-	}
+// encryptServiceSecrets encrypts all .dec. files for a service
+func encryptServiceSecrets(serviceName string, sopsService svc.SOPSService, log *logger.NamedLogger) error {
+	log.V(2).InfoS("Encrypting secrets for service", "service", serviceName)
+	return encryptPath(filepath.Join("apps", serviceName), sopsService, log)
+}
 
-	return nil
+// decryptServiceSecrets decrypts all .enc. files for a service
+func decryptServiceSecrets(serviceName string, sopsService svc.SOPSService, log *logger.NamedLogger) error {
+	log.V(2).InfoS("Decrypting secrets for service", "service", serviceName)
+	return decryptPath(filepath.Join("apps", serviceName), sopsService, log)
 }
 
 // getOperationMode returns the current operation mode as a string
 func getOperationMode() string {
 	if validateOnly {
 		return "validate"
+	} else if scanOnly {
+		return "scan"
 	} else if decryptOnly {
 		return "decrypt-only"
 	} else if encryptOnly {
@@ -376,96 +352,109 @@ func extractPathSecrets(targetPath string, log *logger.NamedLogger) error {
 	})
 }
 
-// encryptPath encrypts .dec files in a specific path using SOPS
-func encryptPath(targetPath string, manager *sops.Manager, log *logger.NamedLogger) error {
+// encryptPath encrypts .dec. files in a specific path using SOPS; the
+// service decides which of them match the configured path rule
+func encryptPath(targetPath string, sopsService svc.SOPSService, log *logger.NamedLogger) error {
 	log.V(2).InfoS("Encrypting files in path", "path", targetPath)
 
-	// Load SOPS config to get path regex
-	sopsConfig := &SOPSConfig{}
-	if data, err := os.ReadFile(sopsConfigPath); err == nil {
-		if err := yaml.Unmarshal(data, sopsConfig); err == nil && len(sopsConfig.CreationRules) > 0 {
-			pathRegex := sopsConfig.CreationRules[0].PathRegex
-			if pathRegex != "" {
-				regex, err := regexp.Compile(pathRegex)
-				if err == nil {
-					return encryptWithRegex(targetPath, manager, regex, log)
-				}
-			}
-		}
+	files, err := findSecretsFiles(targetPath, sops.IsDecryptedPath)
+	if err != nil {
+		return err
 	}
 
-	// Fallback to .dec.yaml pattern
-	return filepath.Walk(targetPath, func(path string, info os.FileInfo, err error) error {
+	return sopsService.EncryptBatch(files, 0)
+}
+
+// decryptPath decrypts .enc. files in a specific path
+func decryptPath(targetPath string, sopsService svc.SOPSService, log *logger.NamedLogger) error {
+	log.V(2).InfoS("Decrypting files in path", "path", targetPath)
+
+	files, err := findSecretsFiles(targetPath, sops.IsEncryptedPath)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range files {
+		decPath := sops.DecryptedPath(path)
+		plaintext, err := sopsService.Decrypt(path)
+		if err == nil {
+			err = os.WriteFile(decPath, plaintext, 0644)
+		}
 		if err != nil {
-			return err
+			log.Error(err, "Failed to decrypt file", "file", path)
+			continue
 		}
 
-		if strings.Contains(path, ".dec.") {
-			encPath := strings.Replace(path, ".dec.", ".enc.", 1)
-			if err := manager.EncryptFile(path, encPath); err != nil {
-				log.Error(err, "Failed to encrypt file", "file", path)
-			}
+		// Merge back into values.yaml if needed
+		valuesPath := filepath.Join(filepath.Dir(path), "values.yaml")
+		if err := sops.MergeSecretsIntoValues(valuesPath, decPath, log); err != nil {
+			log.Error(err, "Failed to merge secrets into values", "file", valuesPath)
 		}
+	}
 
-		return nil
-	})
+	return nil
 }
 
-// encryptWithRegex encrypts files matching the SOPS regex pattern
-func encryptWithRegex(targetPath string, manager *sops.Manager, regex *regexp.Regexp, log *logger.NamedLogger) error {
-	return filepath.Walk(targetPath, func(path string, info os.FileInfo, err error) error {
+// findSecretsFiles walks targetPath, which may be a single file, for files accepted by match
+func findSecretsFiles(targetPath string, match func(string) bool) ([]string, error) {
+	var files []string
+	err := filepath.Walk(targetPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-
-		if !info.IsDir() && regex.MatchString(path) {
-			if strings.Contains(filepath.Base(path), ".dec.") {
-				encPath := strings.Replace(path, ".dec.", ".enc.", 1)
-				if err := manager.EncryptFile(path, encPath); err != nil {
-					log.Error(err, "Failed to encrypt file", "file", path)
-				}
-			}
+		if !info.IsDir() && match(path) {
+			files = append(files, path)
 		}
-
 		return nil
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+	return files, nil
 }
 
-// decryptPath decrypts .enc files in a specific path
-func decryptPath(targetPath string, manager *sops.Manager, log *logger.NamedLogger) error {
-	log.V(2).InfoS("Decrypting files in path", "path", targetPath)
+// secretsSOPSConfig builds the SOPS settings for the secrets command: the
+// globals.sops section of the config when present, with explicit flags on top.
+// Only a default config that does not exist falls back to the flags; a config
+// given with --config that does not load is an error.
+func secretsSOPSConfig(cmd *cobra.Command, log *logger.NamedLogger) (*config.SOPSConfig, error) {
+	sopsConfig := config.SOPSConfig{
+		AwsProfile: awsProfile,
+		ConfigFile: sopsConfigPath,
+	}
 
-	return filepath.Walk(targetPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+	if cfg, err := config.LoadConfig(cfgFile); err == nil {
+		sopsConfig = cfg.Globals.SOPS
+		if cmd.Flags().Changed("aws-profile") || sopsConfig.AwsProfile == "" {
+			sopsConfig.AwsProfile = awsProfile
 		}
-
-		if strings.Contains(path, ".enc.") {
-			decPath := strings.Replace(path, ".enc.", ".dec.", 1)
-			if err := manager.DecryptFile(path, decPath); err != nil {
-				log.Error(err, "Failed to decrypt file", "file", path)
-			} else {
-				// Merge back into values.yaml if needed
-				valuesPath := filepath.Join(filepath.Dir(path), "values.yaml")
-				if err := sops.MergeSecretsIntoValues(valuesPath, decPath, log); err != nil {
-					log.Error(err, "Failed to merge secrets into values", "file", valuesPath)
-				}
-			}
+		if cmd.Flags().Changed("sops-config") || sopsConfig.ConfigFile == "" {
+			sopsConfig.ConfigFile = sopsConfigPath
 		}
+	} else if cmd.Flags().Changed("config") || !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	} else {
+		log.V(2).InfoS("Using SOPS flags only, config not found", "path", cfgFile)
+	}
 
-		return nil
-	})
-}
+	// Local keys let developers and CI work without AWS credentials
+	if ageKeyFile != "" {
+		sopsConfig.AgeKeyFile = ageKeyFile
+	}
+	if len(ageRecipients) > 0 {
+		sopsConfig.CreationRules = []config.SOPSCreationRule{{
+			PathRegex: sopsConfig.PathRegex,
+			Age:       ageRecipients,
+		}}
+	}
 
-// SOPSConfig represents the SOPS configuration
-type SOPSConfig struct {
-	CreationRules []struct {
-		PathRegex string `yaml:"path_regex"`
-	} `yaml:"creation_rules"`
+	// The secrets command always operates on SOPS files, whatever migrate is configured to do
+	sopsConfig.Enabled = true
+	return &sopsConfig, nil
 }
 
 // validateSecrets validates that all secrets files are properly encrypted
-func validateSecrets(manager *sops.Manager, log *logger.NamedLogger, args []string) error {
+func validateSecrets(sopsService svc.SOPSService, log *logger.NamedLogger, args []string) error {
 	log.InfoS("Validating secrets encryption status")
 
 	// Determine search paths
@@ -490,27 +479,27 @@ func validateSecrets(manager *sops.Manager, log *logger.NamedLogger, args []stri
 			}
 
 			// Check for .dec files
-			if strings.Contains(filepath.Base(path), ".dec.") {
+			if sops.IsDecryptedPath(path) {
 				totalFiles++
 				decryptedFiles++
 
 				// Check if corresponding .enc file exists
-				encPath := strings.Replace(path, ".dec.", ".enc.", 1)
+				encPath := sops.EncryptedPath(path)
 				if _, err := os.Stat(encPath); os.IsNotExist(err) {
 					missingEncFiles++
 					validationErrors = append(validationErrors, fmt.Sprintf("Missing encrypted file for: %s", path))
 					log.V(1).InfoS("Missing encrypted file", "decrypted", path, "expected", encPath)
 				} else {
 					// Validate the encrypted file has SOPS metadata
-					if err := validateSOPSFile(encPath, log); err != nil {
+					if err := sopsService.Validate(encPath); err != nil {
 						validationErrors = append(validationErrors, fmt.Sprintf("Invalid SOPS file %s: %v", encPath, err))
 					} else {
 						encryptedFiles++
 					}
 				}
-			} else if strings.Contains(filepath.Base(path), ".enc.") {
+			} else if sops.IsEncryptedPath(path) {
 				// Check for orphaned .enc files
-				decPath := strings.Replace(path, ".enc.", ".dec.", 1)
+				decPath := sops.DecryptedPath(path)
 				if _, err := os.Stat(decPath); os.IsNotExist(err) {
 					log.V(2).InfoS("Orphaned encrypted file (no .dec counterpart)", "encrypted", path)
 				}
@@ -548,43 +537,42 @@ func validateSecrets(manager *sops.Manager, log *logger.NamedLogger, args []stri
 	return nil
 }
 
-// validateSOPSFile checks if a file has valid SOPS encryption markers
-func validateSOPSFile(path string, log *logger.NamedLogger) error {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+// scanSecrets reports plaintext secrets in non-encrypted files as file:line findings
+func scanSecrets(cmd *cobra.Command, log *logger.NamedLogger, args []string) error {
+	root := "apps"
+	if len(args) > 0 {
+		root = args[0]
 	}
 
-	content := string(data)
-
-	// Check for SOPS metadata markers
-	hasSOPSMarker := strings.Contains(content, "sops:") ||
-		strings.Contains(content, "ENC[") ||
-		strings.Contains(content, "sops_mac")
-
-	if !hasSOPSMarker {
-		return fmt.Errorf("no SOPS encryption markers found")
+	minConfidence, err := secrets.ParseConfidence(scanConfidence)
+	if err != nil {
+		return err
 	}
 
-	// Check for specific SOPS fields that should be present
-	requiredFields := []string{"sops:", "mac:", "kms:"}
-	missingFields := []string{}
-
-	for _, field := range requiredFields {
-		if !strings.Contains(content, field) {
-			missingFields = append(missingFields, field)
-		}
+	cfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	extractor, err := secrets.NewFromMainConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create secret extractor: %w", err)
 	}
 
-	if len(missingFields) > 0 {
-		log.V(2).InfoS("File may have incomplete SOPS metadata", "file", path, "missing", missingFields)
+	log.InfoS("Scanning for plaintext secrets", "path", root, "minConfidence", minConfidence)
+	findings, err := secrets.NewScanner(extractor, minConfidence).ScanDir(root)
+	if err != nil {
+		return err
 	}
 
-	// Check if values are actually encrypted (look for ENC[)
-	if !strings.Contains(content, "ENC[") {
-		log.V(1).InfoS("Warning: File has SOPS metadata but no encrypted values", "file", path)
+	out := cmd.OutOrStdout()
+	for _, finding := range findings {
+		fmt.Fprintln(out, finding.String())
 	}
 
+	if len(findings) > 0 {
+		return errkind.New(errkind.Validation, "found %d plaintext secrets under %s", len(findings), root)
+	}
+	log.InfoS("✓ No plaintext secrets found", "path", root)
 	return nil
 }
 
@@ -611,13 +599,13 @@ func ExtractSecretsAfterMigration(cfg *config.Config, services []string, log *lo
 		}
 	}
 
-	// Optionally encrypt if SOPS config exists
-	if _, err := os.Stat(".sops.yaml"); err == nil {
-		log.InfoS("SOPS config found, encrypting secrets")
-		sopsManager := sops.NewManager(log, ".sops.yaml")
+	// Encrypt with the same SOPS service the migrator uses
+	if cfg.Globals.SOPS.Enabled {
+		log.InfoS("SOPS enabled, encrypting secrets")
+		sopsService := svc.NewSOPSService(&cfg.Globals.SOPS)
 
 		for _, serviceName := range servicesToProcess {
-			if err := encryptServiceSecrets(serviceName, sopsManager, log); err != nil {
+			if err := encryptServiceSecrets(serviceName, sopsService, log); err != nil {
 				log.Error(err, "Failed to encrypt secrets for service", "service", serviceName)
 			}
 		}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"helm-charts-migrator/v1/pkg/logger"
+	svc "helm-charts-migrator/v1/pkg/services"
+	"helm-charts-migrator/v1/pkg/sops"
+)
+
+var (
+	diffShowValues bool
+	diffTextconv   bool
+)
+
+// secretsDiffCmd shows what changed between two revisions of a secrets file
+var secretsDiffCmd = &cobra.Command{
+	Use:   "diff <old-file> <new-file>",
+	Short: "Show added, removed and changed keys between two secrets files",
+	Long: `Diff decrypts two secrets files (encrypted or plain) and lists the keys that
+were added, removed or changed. Values are masked unless --show-values is given.
+
+It can also be used by git so that diffs of secrets.enc.yaml show keys instead of
+ciphertext. Either as an external diff driver:
+
+  git config diff.sops.command "helm-charts-migrator secrets diff"
+
+or as a textconv filter, which also works with git log -p and git show:
+
+  git config diff.sops.textconv "helm-charts-migrator secrets diff --textconv"
+
+together with a .gitattributes entry:
+
+  secrets.enc.yaml diff=sops
+
+Examples:
+  # Compare two files
+  helm-charts-migrator secrets diff old/secrets.enc.yaml apps/heimdall/secrets.enc.yaml
+
+  # Print the masked keys of one file, one per line
+  helm-charts-migrator secrets diff --textconv apps/heimdall/secrets.enc.yaml`,
+	Args: validateDiffArgs,
+	RunE: runSecretsDiff,
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsDiffCmd)
+
+	secretsDiffCmd.Flags().BoolVar(&diffShowValues, "show-values", false, "Print secret values in clear text instead of masking them")
+	secretsDiffCmd.Flags().BoolVar(&diffTextconv, "textconv", false, "Print the keys and values of a single file, for use as a git textconv filter")
+}
+
+// validateDiffArgs accepts one file with --textconv, two files, or the seven
+// arguments git passes to an external diff driver
+func validateDiffArgs(cmd *cobra.Command, args []string) error {
+	if diffTextconv {
+		return cobra.ExactArgs(1)(cmd, args)
+	}
+	if len(args) != 2 && len(args) != 7 {
+		return fmt.Errorf("expected <old-file> <new-file> or the 7 arguments of a git diff driver, got %d arguments", len(args))
+	}
+	return nil
+}
+
+func runSecretsDiff(cmd *cobra.Command, args []string) error {
+	log := logger.WithName("secrets-diff")
+	sopsConfig, err := secretsSOPSConfig(cmd, log)
+	if err != nil {
+		return err
+	}
+	sopsService := svc.NewSOPSService(sopsConfig)
+	out := cmd.OutOrStdout()
+
+	if diffTextconv {
+		data, err := readSecretsRevision(sopsService, args[0])
+		if err != nil {
+			return err
+		}
+		values, err := sops.Flatten(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", args[0], err)
+		}
+		writeFlattenedSecrets(out, values, diffShowValues)
+		return nil
+	}
+
+	// git calls diff drivers with: path old-file old-hex old-mode new-file new-hex new-mode
+	label, oldFile, newFile := args[1], args[0], args[1]
+	if len(args) == 7 {
+		label, oldFile, newFile = args[0], args[1], args[4]
+	}
+
+	oldData, err := readSecretsRevision(sopsService, oldFile)
+	if err != nil {
+		return err
+	}
+	newData, err := readSecretsRevision(sopsService, newFile)
+	if err != nil {
+		return err
+	}
+
+	changes, err := sops.Diff(oldData, newData)
+	if err != nil {
+		return err
+	}
+
+	writeSecretsDiff(out, label, changes, diffShowValues)
+	return nil
+}
+
+// readSecretsRevision returns the plaintext of a file; /dev/null, which git
+// passes for added and deleted files, reads as empty
+func readSecretsRevision(sopsService svc.SOPSService, path string) ([]byte, error) {
+	if path == os.DevNull {
+		return nil, nil
+	}
+	return sopsService.Decrypt(path)
+}
+
+// writeSecretsDiff prints one line per changed key and a summary
+func writeSecretsDiff(out io.Writer, label string, changes []sops.KeyChange, showValues bool) {
+	display := displayValue(showValues)
+
+	fmt.Fprintf(out, "secrets diff %s\n", label)
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "  no changes")
+		return
+	}
+
+	counts := map[sops.ChangeKind]int{}
+	for _, change := range changes {
+		counts[change.Kind]++
+		switch change.Kind {
+		case sops.ChangeAdded:
+			fmt.Fprintf(out, "+ %s: %s\n", change.Key, display(change.New))
+		case sops.ChangeRemoved:
+			fmt.Fprintf(out, "- %s: %s\n", change.Key, display(change.Old))
+		case sops.ChangeChanged:
+			fmt.Fprintf(out, "~ %s: %s -> %s\n", change.Key, display(change.Old), display(change.New))
+		}
+	}
+	fmt.Fprintf(out, "Added: %d, removed: %d, changed: %d\n",
+		counts[sops.ChangeAdded], counts[sops.ChangeRemoved], counts[sops.ChangeChanged])
+}
+
+// writeFlattenedSecrets prints every key of a file, sorted, as key: value
+func writeFlattenedSecrets(out io.Writer, values map[string]string, showValues bool) {
+	display := displayValue(showValues)
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(out, "%s: %s\n", key, display(values[key]))
+	}
+}
+
+// displayValue returns how diffs show values: as they are, or masked with a
+// digest so that any change shows
+func displayValue(showValues bool) func(string) string {
+	if showValues {
+		return func(value string) string { return value }
+	}
+	return sops.MaskValueWithDigest
+}
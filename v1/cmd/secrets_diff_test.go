@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"helm-charts-migrator/v1/pkg/sops"
+)
+
+func TestWriteSecretsDiff(t *testing.T) {
+	changes := []sops.KeyChange{
+		{Key: "secrets.apiKey", Kind: sops.ChangeRemoved, Old: "removed-key-value"},
+		{Key: "secrets.password", Kind: sops.ChangeChanged, Old: "password123", New: "password456"},
+		{Key: "secrets.token", Kind: sops.ChangeAdded, New: "abc"},
+	}
+
+	var out bytes.Buffer
+	writeSecretsDiff(&out, "apps/heimdall/secrets.enc.yaml", changes, false)
+
+	assert.Equal(t, `secrets diff apps/heimdall/secrets.enc.yaml
+- secrets.apiKey: `+sops.MaskValueWithDigest("removed-key-value")+`
+~ secrets.password: `+sops.MaskValueWithDigest("password123")+` -> `+sops.MaskValueWithDigest("password456")+`
++ secrets.token: `+sops.MaskValueWithDigest("abc")+`
+Added: 1, removed: 1, changed: 1
+`, out.String())
+	assert.Contains(t, out.String(), "- secrets.apiKey: remo*********alue #")
+
+	out.Reset()
+	writeSecretsDiff(&out, "secrets.enc.yaml", changes, true)
+	assert.Contains(t, out.String(), "~ secrets.password: password123 -> password456")
+}
+
+func TestWriteFlattenedSecrets(t *testing.T) {
+	var out bytes.Buffer
+	writeFlattenedSecrets(&out, map[string]string{
+		"secrets.b": "password123",
+		"secrets.a": "x",
+	}, false)
+
+	assert.Equal(t, "secrets.a: "+sops.MaskValueWithDigest("x")+"\nsecrets.b: "+sops.MaskValueWithDigest("password123")+"\n", out.String())
+
+	// Values differing only in the masked middle still differ for textconv
+	out.Reset()
+	writeFlattenedSecrets(&out, map[string]string{"secrets.b": "password-one-123"}, false)
+	before := out.String()
+	out.Reset()
+	writeFlattenedSecrets(&out, map[string]string{"secrets.b": "password-two-123"}, false)
+	assert.NotEqual(t, before, out.String())
+}
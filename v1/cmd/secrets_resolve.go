@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/logger"
+	svc "helm-charts-migrator/v1/pkg/services"
+	"helm-charts-migrator/v1/pkg/sops"
+)
+
+var (
+	resolveService     string
+	resolveCluster     string
+	resolveNamespace   string
+	resolveEnvironment string
+	resolveShowValues  bool
+)
+
+// secretsResolveCmd prints the secrets a namespace effectively receives
+var secretsResolveCmd = &cobra.Command{
+	Use:   "resolve",
+	Short: "Show the effective secrets of a service in a cluster namespace",
+	Long: `Resolve decrypts the secrets files of every level that applies to a namespace and
+merges them in Helm's override order:
+
+  apps/<service>/secrets
+  apps/<service>/envs/<environment>/secrets
+  apps/<service>/envs/<environment>/clusters/<cluster>/secrets
+  apps/<service>/envs/<environment>/clusters/<cluster>/namespaces/<namespace>/secrets
+
+For each level the encrypted secrets.enc.yaml is used, or secrets.dec.yaml when no
+encrypted file exists. Every effective key is printed with the file it comes from.
+Values are masked unless --show-values is given.
+
+Examples:
+  # What will heimdall in dev01/vf-dev3 get?
+  helm-charts-migrator secrets resolve --service heimdall --cluster dev01 --namespace vf-dev3
+
+  # Disambiguate when the cluster exists under several environments
+  helm-charts-migrator secrets resolve --service heimdall --cluster dev01 --namespace vf-dev3 --environment dev`,
+	Args: cobra.NoArgs,
+	RunE: runSecretsResolve,
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsResolveCmd)
+
+	secretsResolveCmd.Flags().StringVar(&resolveService, "service", "", "Service to resolve (required)")
+	secretsResolveCmd.Flags().StringVar(&resolveCluster, "cluster", "", "Cluster to resolve (required)")
+	secretsResolveCmd.Flags().StringVar(&resolveNamespace, "namespace", "", "Namespace to resolve (required)")
+	secretsResolveCmd.Flags().StringVar(&resolveEnvironment, "environment", "", "Environment holding the cluster (default: detected from apps/<service>/envs)")
+	secretsResolveCmd.Flags().BoolVar(&resolveShowValues, "show-values", false, "Print secret values in clear text instead of masking them")
+	_ = secretsResolveCmd.MarkFlagRequired("service")
+	_ = secretsResolveCmd.MarkFlagRequired("cluster")
+	_ = secretsResolveCmd.MarkFlagRequired("namespace")
+}
+
+func runSecretsResolve(cmd *cobra.Command, args []string) error {
+	log := logger.WithName("secrets-resolve")
+
+	paths := config.NewPaths("", "apps", ".cache").ForService(resolveService)
+
+	environment := resolveEnvironment
+	if environment == "" {
+		detected, err := findClusterEnvironment(paths, resolveCluster, resolveNamespace)
+		if err != nil {
+			return err
+		}
+		environment = detected
+	}
+	paths = paths.ForCluster(resolveCluster).ForEnvironment(environment, resolveNamespace)
+
+	if _, err := os.Stat(paths.EnvironmentNamespaceDir()); err != nil {
+		return fmt.Errorf("namespace directory %s not found: %w", paths.EnvironmentNamespaceDir(), err)
+	}
+
+	sopsConfig, err := secretsSOPSConfig(cmd, log)
+	if err != nil {
+		return err
+	}
+	sopsService := svc.NewSOPSService(sopsConfig)
+
+	var layers []sops.Layer
+	for _, dir := range secretsLevelDirs(paths) {
+		file := levelSecretsFile(dir)
+		if file == "" {
+			continue
+		}
+		data, err := sopsService.Decrypt(file)
+		if err != nil {
+			return err
+		}
+		log.V(1).InfoS("Using secrets layer", "file", file)
+		layers = append(layers, sops.Layer{File: file, Data: data})
+	}
+
+	resolved, err := sops.Resolve(layers)
+	if err != nil {
+		return err
+	}
+
+	writeResolvedSecrets(cmd.OutOrStdout(), resolved, resolveShowValues)
+	return nil
+}
+
+// secretsLevelDirs returns the directories of each level, in Helm's override order
+func secretsLevelDirs(paths *config.Paths) []string {
+	return []string{
+		paths.ServiceDir(),
+		paths.EnvironmentDir(),
+		paths.EnvironmentClusterDir(),
+		paths.EnvironmentNamespaceDir(),
+	}
+}
+
+// levelSecretsFile returns the secrets file of a level: the encrypted file
+// that gets deployed, else its plaintext copy, else nothing
+func levelSecretsFile(dir string) string {
+	for _, name := range []string{"secrets.enc.yaml", "secrets.dec.yaml"} {
+		file := filepath.Join(dir, name)
+		if _, err := os.Stat(file); err == nil {
+			return file
+		}
+	}
+	return ""
+}
+
+// findClusterEnvironment finds the single environment containing the cluster namespace
+func findClusterEnvironment(paths *config.Paths, cluster, namespace string) (string, error) {
+	pattern := filepath.Join(paths.EnvsDir(), "*", "clusters", cluster, "namespaces", namespace)
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to search environments: %w", err)
+	}
+
+	var environments []string
+	for _, match := range matches {
+		appPath, err := config.ParseAppPath(match)
+		if err == nil {
+			environments = append(environments, appPath.Environment)
+		}
+	}
+	sort.Strings(environments)
+
+	switch len(environments) {
+	case 0:
+		return "", fmt.Errorf("no environment of %s has cluster %s with namespace %s", paths.EnvsDir(), cluster, namespace)
+	case 1:
+		return environments[0], nil
+	default:
+		return "", fmt.Errorf("cluster %s namespace %s exists in environments %v, use --environment", cluster, namespace, environments)
+	}
+}
+
+// writeResolvedSecrets prints the effective keys with their values and origin files
+func writeResolvedSecrets(out io.Writer, resolved []sops.ResolvedValue, showValues bool) {
+	if len(resolved) == 0 {
+		fmt.Fprintln(out, "No secrets found")
+		return
+	}
+
+	// Resolved values are not compared, so the mask needs no digest
+	display := sops.MaskValue
+	if showValues {
+		display = func(value string) string { return value }
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tORIGIN")
+	for _, value := range resolved {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", value.Key, display(value.Value), filepath.ToSlash(value.Origin))
+	}
+	_ = w.Flush()
+}
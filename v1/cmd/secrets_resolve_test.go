@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/sops"
+)
+
+func TestFindClusterEnvironment(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, dir := range []string{
+		"apps/heimdall/envs/dev/clusters/dev01/namespaces/vf-dev3",
+		"apps/heimdall/envs/staging/clusters/dev01/namespaces/vf-stage",
+		"apps/heimdall/envs/staging/clusters/dev01/namespaces/vf-dev3-copy",
+		"apps/heimdall/envs/qa/clusters/dev01/namespaces/vf-dev3-copy",
+	} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+	}
+	paths := config.NewPaths("", "apps", ".cache").ForService("heimdall")
+
+	environment, err := findClusterEnvironment(paths, "dev01", "vf-dev3")
+	require.NoError(t, err)
+	assert.Equal(t, "dev", environment)
+
+	_, err = findClusterEnvironment(paths, "dev01", "vf-dev3-copy")
+	assert.ErrorContains(t, err, "[qa staging]")
+
+	_, err = findClusterEnvironment(paths, "prod01", "vf-dev3")
+	assert.Error(t, err)
+}
+
+func TestLevelSecretsFile(t *testing.T) {
+	dir := t.TempDir()
+	assert.Empty(t, levelSecretsFile(dir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secrets.dec.yaml"), nil, 0644))
+	assert.Equal(t, filepath.Join(dir, "secrets.dec.yaml"), levelSecretsFile(dir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secrets.enc.yaml"), nil, 0644))
+	assert.Equal(t, filepath.Join(dir, "secrets.enc.yaml"), levelSecretsFile(dir))
+}
+
+func TestWriteResolvedSecrets(t *testing.T) {
+	var out bytes.Buffer
+	writeResolvedSecrets(&out, []sops.ResolvedValue{
+		{Key: "secrets.password", Value: "password123", Origin: "apps/heimdall/secrets.enc.yaml"},
+	}, false)
+
+	assert.Contains(t, out.String(), "KEY")
+	assert.Contains(t, out.String(), "secrets.password  pass***d123  apps/heimdall/secrets.enc.yaml")
+	assert.NotContains(t, out.String(), "password123")
+}
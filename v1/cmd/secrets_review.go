@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/logger"
+	"helm-charts-migrator/v1/pkg/secrets"
+)
+
+var (
+	reviewFile        string
+	reviewInteractive bool
+)
+
+// secretsReviewCmd lets low-confidence secret matches be confirmed or rejected
+var secretsReviewCmd = &cobra.Command{
+	Use:   "review [path]",
+	Short: "Review secret matches below the confidence threshold",
+	Long: `Review finds the values files under path (apps by default) and collects the
+secret matches whose confidence is below secrets.minConfidence. These matches
+are not moved to the secrets automatically; they are written to a review file
+instead, one entry per service and key:
+
+  candidates:
+    - service: heimdall
+      path: configMap.application.properties.client.id
+      key: client.id
+      confidence: low
+      decision: pending   # accept or reject
+
+Set decision to accept or reject and run review again, or use --interactive to
+decide on the terminal. Decisions are saved in the config file: accepted keys
+are added to services.<service>.secrets.keys and rejected keys to
+services.<service>.secrets.exclusions, so later runs treat them the same way.
+With include:, the file merged last that sets the service's secrets, or else
+the service, is updated. Config directories and cluster:// sources cannot be
+written to.
+
+Examples:
+  # Write the candidates of every service to secrets-review.yaml
+  helm-charts-migrator secrets review
+
+  # Decide on each candidate of heimdall interactively
+  helm-charts-migrator secrets review apps/heimdall --interactive`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSecretsReview,
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsReviewCmd)
+
+	secretsReviewCmd.Flags().StringVar(&reviewFile, "review-file", "secrets-review.yaml", "File listing the candidates and their decisions")
+	secretsReviewCmd.Flags().BoolVarP(&reviewInteractive, "interactive", "i", false, "Prompt to accept or reject each pending candidate")
+}
+
+func runSecretsReview(cmd *cobra.Command, args []string) error {
+	log := logger.WithName("secrets-review")
+	out := cmd.OutOrStdout()
+
+	root := "apps"
+	if len(args) > 0 {
+		root = args[0]
+	}
+
+	cfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	// Decisions are saved on top of the file as it is, without the overrides
+	fileCfg, err := config.LoadFileConfig(cfgFile)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	review, err := secrets.LoadReview(reviewFile)
+	if err != nil {
+		return err
+	}
+
+	// Decisions made by editing the review file are recorded first, so the
+	// scan below already honours them
+	if err := recordReviewDecisions(out, cfg, fileCfg, review); err != nil {
+		return err
+	}
+
+	extractor, err := secrets.NewFromMainConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create secret extractor: %w", err)
+	}
+	added, err := collectReviewCandidates(extractor, review, root)
+	if err != nil {
+		return err
+	}
+	log.V(1).InfoS("Collected review candidates", "path", root, "new", added)
+
+	if reviewInteractive {
+		if err := promptReviewDecisions(cmd.InOrStdin(), out, review.Pending()); err != nil {
+			return err
+		}
+		if err := recordReviewDecisions(out, cfg, fileCfg, review); err != nil {
+			return err
+		}
+	}
+
+	if err := review.Save(reviewFile); err != nil {
+		return err
+	}
+	if pending := len(review.Pending()); pending > 0 {
+		fmt.Fprintf(out, "%d candidates pending review in %s\n", pending, reviewFile)
+	} else {
+		fmt.Fprintln(out, "No candidates pending review")
+	}
+	return nil
+}
+
+// collectReviewCandidates adds the candidates of the values files under root
+func collectReviewCandidates(extractor *secrets.SecretExtractor, review *secrets.Review, root string) (int, error) {
+	added := 0
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		appPath, parseErr := config.ParseAppPath(path)
+		if parseErr != nil {
+			return nil
+		}
+		switch appPath.Kind {
+		case config.FileKindValues, config.FileKindLegacyValues, config.FileKindHelmValues:
+		default:
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		candidates, err := extractor.ReviewCandidates(data, appPath.Service, filepath.ToSlash(path))
+		if err != nil {
+			return fmt.Errorf("failed to analyze %s: %w", path, err)
+		}
+		for _, candidate := range candidates {
+			if review.Add(candidate) {
+				added++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to collect candidates under %s: %w", root, err)
+	}
+	return added, nil
+}
+
+// promptReviewDecisions asks for a decision on each candidate until input ends or q is entered
+func promptReviewDecisions(in io.Reader, out io.Writer, pending []*secrets.ReviewCandidate) error {
+	scanner := bufio.NewScanner(in)
+	for _, candidate := range pending {
+		for {
+			fmt.Fprintf(out, "%s: %s = %s (%s, %s confidence) in %s\n",
+				candidate.Service, candidate.Path, candidate.MaskedValue,
+				candidate.Classification, candidate.Confidence, candidate.File)
+			fmt.Fprint(out, "Treat as secret? [a]ccept/[r]eject/[s]kip/[q]uit: ")
+
+			if !scanner.Scan() {
+				fmt.Fprintln(out)
+				return scanner.Err()
+			}
+			answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			switch answer {
+			case "a", "accept", "y", "yes":
+				candidate.Decision = secrets.DecisionAccept
+			case "r", "reject", "n", "no":
+				candidate.Decision = secrets.DecisionReject
+			case "s", "skip", "":
+			case "q", "quit":
+				return nil
+			default:
+				fmt.Fprintf(out, "Unknown answer %q\n", answer)
+				continue
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// recordReviewDecisions saves decided keys in the config file and drops them
+// from the review. The keys are added to cfg, the configuration in use, and to
+// fileCfg, the configuration without overrides that is written back.
+func recordReviewDecisions(out io.Writer, cfg, fileCfg *config.Config, review *secrets.Review) error {
+	decisions := review.Decisions()
+	if len(decisions) == 0 {
+		return nil
+	}
+
+	serviceNames := make([]string, 0, len(decisions))
+	for name := range decisions {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+
+	// Each service is written to the file that sets its secrets
+	patches := make(map[string]map[string]interface{})
+	var files []string
+	for _, name := range serviceNames {
+		decided := decisions[name]
+		cfg.RecordSecretDecisions(name, decided.Accepted, decided.Rejected)
+		if !fileCfg.RecordSecretDecisions(name, decided.Accepted, decided.Rejected) {
+			continue
+		}
+		file, err := config.ServiceSecretsFile(cfgFile, name)
+		if err != nil {
+			return err
+		}
+		if _, ok := patches[file]; !ok {
+			patches[file] = make(map[string]interface{})
+			files = append(files, file)
+		}
+		serviceSecrets := fileCfg.Services[name].Secrets
+		patches[file][name] = map[string]interface{}{
+			"secrets": map[string]interface{}{
+				"keys":       serviceSecrets.Keys,
+				"exclusions": serviceSecrets.Exclusions,
+			},
+		}
+		fmt.Fprintf(out, "%s: accepted %d, rejected %d keys\n", name, len(decided.Accepted), len(decided.Rejected))
+	}
+
+	for _, file := range files {
+		if err := config.PatchFile(file, map[string]interface{}{"services": patches[file]}); err != nil {
+			return err
+		}
+	}
+	review.RemoveDecided()
+	return nil
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/secrets"
+)
+
+const reviewTestConfig = `# Review test configuration
+globals:
+  secrets:
+    minConfidence: high
+    patterns:
+      - ".*\\.password.*"
+    uuids:
+      - pattern: "[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}"
+        sensitive: true
+        description: "Generic UUID pattern"
+services:
+  heimdall:
+    enabled: true # reviewed below
+    name: heimdall
+`
+
+func runReviewCommand(t *testing.T, input string, interactive bool) string {
+	t.Helper()
+	oldInteractive, oldReviewFile := reviewInteractive, reviewFile
+	t.Cleanup(func() { reviewInteractive, reviewFile = oldInteractive, oldReviewFile })
+	reviewInteractive, reviewFile = interactive, "secrets-review.yaml"
+
+	var out bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOut(&out)
+	cmd.SetIn(strings.NewReader(input))
+	require.NoError(t, runSecretsReview(cmd, nil))
+	return out.String()
+}
+
+func setupReviewTree(t *testing.T) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	oldCfgFile := cfgFile
+	t.Cleanup(func() { cfgFile = oldCfgFile })
+	cfgFile = "config.yaml"
+
+	require.NoError(t, os.WriteFile("config.yaml", []byte(reviewTestConfig), 0644))
+	dir := "apps/heimdall/envs/dev/clusters/dev01/namespaces/vf-dev3"
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "values.yaml"), []byte(`configMap:
+  app:
+    db.password: hunter2hunter2
+    tenant: 11111111-2222-3333-4444-555555555555
+    plain: hello
+`), 0644))
+}
+
+func TestSecretsReview_ReviewFile(t *testing.T) {
+	setupReviewTree(t)
+
+	out := runReviewCommand(t, "", false)
+	assert.Contains(t, out, "2 candidates pending review in secrets-review.yaml")
+
+	review, err := secrets.LoadReview("secrets-review.yaml")
+	require.NoError(t, err)
+	require.Len(t, review.Candidates, 2)
+	assert.Equal(t, "configMap.app.db.password", review.Candidates[0].Path)
+	assert.Equal(t, "configMap.app.tenant", review.Candidates[1].Path)
+
+	// A second run keeps the pending candidates without duplicating them
+	runReviewCommand(t, "", false)
+	review, err = secrets.LoadReview("secrets-review.yaml")
+	require.NoError(t, err)
+	require.Len(t, review.Candidates, 2)
+
+	review.Candidates[0].Decision = secrets.DecisionAccept
+	review.Candidates[1].Decision = secrets.DecisionReject
+	require.NoError(t, review.Save("secrets-review.yaml"))
+
+	out = runReviewCommand(t, "", false)
+	assert.Contains(t, out, "heimdall: accepted 1, rejected 1 keys")
+	assert.Contains(t, out, "No candidates pending review")
+	assert.NoFileExists(t, "secrets-review.yaml")
+
+	cfg, err := config.LoadConfig("config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db.password"}, cfg.Services["heimdall"].Secrets.Keys)
+	assert.Equal(t, []string{"tenant"}, cfg.Services["heimdall"].Secrets.Exclusions)
+
+	data, err := os.ReadFile("config.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "enabled: true # reviewed below")
+}
+
+func TestSecretsReview_Interactive(t *testing.T) {
+	setupReviewTree(t)
+
+	out := runReviewCommand(t, "maybe\nr\ns\n", true)
+	assert.Contains(t, out, `Unknown answer "maybe"`)
+	assert.Contains(t, out, "heimdall: accepted 0, rejected 1 keys")
+	assert.Contains(t, out, "1 candidates pending review")
+
+	cfg, err := config.LoadConfig("config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db.password"}, cfg.Services["heimdall"].Secrets.Exclusions)
+}
+
+func TestSecretsReview_OverridesNotSaved(t *testing.T) {
+	setupReviewTree(t)
+	config.SetOverrides([]config.Override{
+		{Path: "services.heimdall.secrets.exclusions", Value: "[from-override]", Source: "--config-set services.heimdall.secrets.exclusions"},
+	})
+	t.Cleanup(func() { config.SetOverrides(nil) })
+
+	out := runReviewCommand(t, "a\na\n", true)
+	assert.Contains(t, out, "heimdall: accepted 2, rejected 0 keys")
+
+	cfg, err := config.LoadFileConfig("config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db.password", "tenant"}, cfg.Services["heimdall"].Secrets.Keys)
+	assert.Empty(t, cfg.Services["heimdall"].Secrets.Exclusions)
+
+	data, err := os.ReadFile("config.yaml")
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "from-override")
+}
+
+func TestSecretsReview_SavesToIncludedFile(t *testing.T) {
+	setupReviewTree(t)
+	root := strings.Replace(reviewTestConfig, "services:\n", "include: [teams/heimdall.yaml]\nservices:\n", 1)
+	require.NoError(t, os.WriteFile("config.yaml", []byte(root), 0644))
+	require.NoError(t, os.MkdirAll("teams", 0755))
+	require.NoError(t, os.WriteFile("teams/heimdall.yaml", []byte(`# Owned by the heimdall team
+services:
+  heimdall:
+    secrets:
+      exclusions: [plain]
+`), 0644))
+
+	out := runReviewCommand(t, "a\nr\n", true)
+	assert.Contains(t, out, "heimdall: accepted 1, rejected 1 keys")
+
+	data, err := os.ReadFile("config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, root, string(data), "the including file is left as it is")
+
+	cfg, err := config.LoadFileConfig("config.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db.password"}, cfg.Services["heimdall"].Secrets.Keys)
+	assert.Equal(t, []string{"plain", "tenant"}, cfg.Services["heimdall"].Secrets.Exclusions)
+
+	data, err = os.ReadFile("teams/heimdall.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Owned by the heimdall team")
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"helm-charts-migrator/v1/pkg/logger"
+	svc "helm-charts-migrator/v1/pkg/services"
+	"helm-charts-migrator/v1/pkg/sops"
+	"helm-charts-migrator/v1/pkg/workers"
+)
+
+var rotateWorkers int
+
+// secretsRotateCmd re-keys encrypted secrets after KMS keys or recipients change
+var secretsRotateCmd = &cobra.Command{
+	Use:   "rotate [path]",
+	Short: "Re-wrap data keys of encrypted secrets for the current SOPS keys",
+	Long: `Rotate walks the encrypted secrets files (apps/**/secrets.enc.yaml by default)
+and re-wraps each file's data key for the key groups and Shamir threshold of its
+current creation rule. Use it after adding or removing KMS keys, age recipients
+or PGP keys. The plaintext, the encrypted values and the MAC are left untouched;
+files already using the current keys are not rewritten.
+
+Decrypting the data key needs access to one of the keys the file currently uses.
+
+Examples:
+  # Rotate every secrets file under apps/
+  helm-charts-migrator secrets rotate
+
+  # Rotate one service with 10 parallel workers
+  helm-charts-migrator secrets rotate apps/heimdall --workers 10
+
+  # Move files to a new age recipient
+  helm-charts-migrator secrets rotate --age-recipient age1... --age-key-file old-keys.txt`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSecretsRotate,
+}
+
+func init() {
+	secretsCmd.AddCommand(secretsRotateCmd)
+
+	secretsRotateCmd.Flags().IntVar(&rotateWorkers, "workers", 0, "Number of files rotated in parallel (default: sops.parallelWorkers)")
+}
+
+func runSecretsRotate(cmd *cobra.Command, args []string) error {
+	log := logger.WithName("secrets-rotate")
+
+	root := "apps"
+	if len(args) > 0 {
+		root = args[0]
+	}
+
+	files, err := findSecretsFiles(root, sops.IsEncryptedPath)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		log.InfoS("No encrypted secrets files found", "path", root)
+		return nil
+	}
+	sort.Strings(files)
+
+	sopsConfig, err := secretsSOPSConfig(cmd, log)
+	if err != nil {
+		return err
+	}
+	sopsService := svc.NewSOPSService(sopsConfig)
+
+	workerCount := rotateWorkers
+	if workerCount <= 0 {
+		workerCount = sopsConfig.ParallelWorkers
+	}
+	if workerCount <= 0 {
+		workerCount = 5
+	}
+
+	tasks := make([]workers.Task, len(files))
+	rotations := make(map[string]*workers.SOPSRotationTask, len(files))
+	for i, file := range files {
+		task := workers.NewSOPSRotationTask(file, sopsConfig.AwsProfile, sopsService)
+		tasks[i] = task
+		rotations[task.ID()] = task
+	}
+
+	log.InfoS("Rotating SOPS data keys", "files", len(files), "workers", workerCount)
+	results, _ := workers.ProcessWithPool(context.Background(), tasks, workerCount)
+
+	report := make([]rotationResult, 0, len(results))
+	for _, result := range results {
+		task, ok := rotations[result.TaskID]
+		if !ok {
+			continue
+		}
+		report = append(report, rotationResult{
+			File:     task.FilePath,
+			Rotated:  task.Rotated,
+			Err:      result.Error,
+			Duration: result.Duration,
+		})
+	}
+
+	failed := writeRotationReport(cmd.OutOrStdout(), report)
+	if missing := len(files) - len(report); missing > 0 {
+		return fmt.Errorf("rotation incomplete: no result for %d of %d files", missing, len(files))
+	}
+	if failed > 0 {
+		return fmt.Errorf("rotation failed for %d of %d files", failed, len(files))
+	}
+	return nil
+}
+
+// rotationResult is the outcome of rotating a single file
+type rotationResult struct {
+	File     string
+	Rotated  bool
+	Err      error
+	Duration time.Duration
+}
+
+// Status returns rotated, unchanged or failed
+func (r rotationResult) Status() string {
+	switch {
+	case r.Err != nil:
+		return "failed"
+	case r.Rotated:
+		return "rotated"
+	default:
+		return "unchanged"
+	}
+}
+
+// writeRotationReport prints one line per file and a summary, returning the number of failures
+func writeRotationReport(out io.Writer, report []rotationResult) int {
+	sort.Slice(report, func(i, j int) bool { return report[i].File < report[j].File })
+
+	counts := map[string]int{}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tRESULT\tDURATION\tERROR")
+	for _, result := range report {
+		status := result.Status()
+		counts[status]++
+
+		errText := ""
+		if result.Err != nil {
+			errText = result.Err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", filepath.ToSlash(result.File), status,
+			result.Duration.Round(time.Millisecond), errText)
+	}
+	_ = w.Flush()
+
+	fmt.Fprintf(out, "\nRotated: %d, unchanged: %d, failed: %d\n",
+		counts["rotated"], counts["unchanged"], counts["failed"])
+	return counts["failed"]
+}
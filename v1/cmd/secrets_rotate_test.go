@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteRotationReport(t *testing.T) {
+	var out bytes.Buffer
+	failed := writeRotationReport(&out, []rotationResult{
+		{File: "apps/b/secrets.enc.yaml", Rotated: true, Duration: 120 * time.Millisecond},
+		{File: "apps/a/secrets.enc.yaml", Duration: time.Millisecond},
+		{File: "apps/c/secrets.enc.yaml", Err: errors.New("access denied")},
+	})
+
+	assert.Equal(t, 1, failed)
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	assert.Contains(t, string(lines[0]), "FILE")
+	assert.Contains(t, string(lines[1]), "apps/a/secrets.enc.yaml")
+	assert.Contains(t, string(lines[1]), "unchanged")
+	assert.Contains(t, string(lines[2]), "rotated")
+	assert.Contains(t, string(lines[3]), "access denied")
+	assert.Contains(t, out.String(), "Rotated: 1, unchanged: 1, failed: 1")
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm-charts-migrator/v1/pkg/logger"
+)
+
+func TestSecretsSOPSConfig(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte("globals:\n  sops:\n    awsProfil: x\n"), 0644))
+
+	tests := []struct {
+		name     string
+		config   string
+		explicit bool
+		wantErr  bool
+	}{
+		{"default config missing", filepath.Join(dir, "config.yaml"), false, false},
+		{"given config missing", filepath.Join(dir, "config.yaml"), true, true},
+		{"default config invalid", invalid, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldCfgFile := cfgFile
+			t.Cleanup(func() { cfgFile = oldCfgFile })
+			cfgFile = tt.config
+
+			cmd := &cobra.Command{}
+			cmd.Flags().StringVar(&cfgFile, "config", tt.config, "")
+			if tt.explicit {
+				require.NoError(t, cmd.Flags().Set("config", tt.config))
+			}
+
+			sopsConfig, err := secretsSOPSConfig(cmd, logger.WithName("test"))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, sopsConfig.Enabled)
+		})
+	}
+}
@@ -15,7 +15,9 @@ import (
 	"helm.sh/helm/v3/pkg/cli"
 
 	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/errkind"
 	"helm-charts-migrator/v1/pkg/logger"
+	"helm-charts-migrator/v1/pkg/schema"
 )
 
 var (
@@ -35,6 +37,8 @@ This command validates the Helm chart templates using various methods:
 - Helm template with --validate flag
 - Kubernetes dry-run (if cluster is accessible)
 - Kubeconform for offline validation (if installed)
+- The values of every environment layer against the values.schema.json
+  generated by migrate
 
 Examples:
   # Validate templates for auth-service in prod01 cluster
@@ -71,13 +75,13 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	// Validate cluster
 	clusterConfig := cfg.GetCluster(validateCluster)
 	if clusterConfig == nil {
-		return fmt.Errorf("cluster %s not found in configuration", validateCluster)
+		return errkind.New(errkind.Configuration, "cluster %s not found in configuration", validateCluster)
 	}
 
 	// Validate service
 	serviceConfig, exists := cfg.Services[validateService]
 	if !exists {
-		return fmt.Errorf("service %s not found in configuration", validateService)
+		return errkind.New(errkind.Configuration, "service %s not found in configuration", validateService)
 	}
 
 	if !serviceConfig.Enabled {
@@ -98,7 +102,7 @@ func runValidate(cmd *cobra.Command, args []string) error {
 			}
 		}
 		if ns == "" {
-			return fmt.Errorf("no namespace specified and no enabled namespace found")
+			return errkind.New(errkind.Configuration, "no namespace specified and no enabled namespace found")
 		}
 	}
 
@@ -109,7 +113,7 @@ func runValidate(cmd *cobra.Command, args []string) error {
 
 	// Check if base-chart exists
 	if _, err := os.Stat(baseChartPath); os.IsNotExist(err) {
-		return fmt.Errorf("base-chart not found at %s. Please run migrate command first", baseChartPath)
+		return errkind.New(errkind.NotFound, "base-chart not found at %s. Please run migrate command first", baseChartPath)
 	}
 
 	logger.InfoS("Validating Helm chart",
@@ -157,13 +161,35 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		logger.InfoS("✓ Chart structure validation passed")
 	}
 
+	// 5. Validate every env values layer against the values schema
+	serviceDir := config.NewPaths("", "apps", ".cache").ForService(validateService).ServiceDir()
+	schemaErrors, err := validateValuesSchema(serviceDir)
+	switch {
+	case os.IsNotExist(err):
+		warning := fmt.Sprintf("Values schema not found at %s, run migrate to generate it", filepath.Join(serviceDir, schema.Filename))
+		logger.Warning(warning)
+		if validateStrict {
+			validationErrors = append(validationErrors, warning)
+		}
+	case err != nil:
+		validationErrors = append(validationErrors, fmt.Sprintf("Values schema: %v", err))
+		logger.Error(err, "Values schema validation failed")
+	case len(schemaErrors) > 0:
+		for _, layerErr := range schemaErrors {
+			validationErrors = append(validationErrors, fmt.Sprintf("Values schema: %v", layerErr))
+			logger.Error(layerErr, "Values schema validation failed", "file", layerErr.File)
+		}
+	default:
+		logger.InfoS("✓ Values schema validation passed")
+	}
+
 	// Report results
 	if len(validationErrors) > 0 {
 		fmt.Println("\n❌ Validation failed with the following errors:")
 		for _, err := range validationErrors {
 			fmt.Printf("  - %s\n", err)
 		}
-		return fmt.Errorf("validation failed with %d errors", len(validationErrors))
+		return errkind.New(errkind.Validation, "validation failed with %d errors", len(validationErrors))
 	}
 
 	fmt.Println("\n✅ All validations passed successfully!")
@@ -210,7 +236,7 @@ func validateWithHelm(chartPath, valuesPath, namespace string) error {
 	// Run validation
 	_, err = client.Run(chart, values)
 	if err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+		return errkind.New(errkind.Validation, "validation failed: %w", err)
 	}
 
 	return nil
@@ -274,6 +300,21 @@ func checkDeprecatedAPIs(chartPath, valuesPath, namespace string) []string {
 	return warnings
 }
 
+// validateValuesSchema checks the values of every layer of a migrated chart
+// against its values.schema.json
+func validateValuesSchema(serviceDir string) ([]schema.LayerError, error) {
+	schemaJSON, err := os.ReadFile(filepath.Join(serviceDir, schema.Filename))
+	if err != nil {
+		return nil, err
+	}
+
+	layers, err := schema.ServiceLayers(serviceDir)
+	if err != nil {
+		return nil, err
+	}
+	return schema.Validate(schemaJSON, layers)
+}
+
 func validateChartStructure(chartPath string) error {
 	// Check required files
 	requiredFiles := []string{
@@ -109,7 +109,7 @@ func runYamlMerge(cmd *cobra.Command, args []string) error {
 			for i := 0; i < maxChangesToShow; i++ {
 				diff := diffs[i]
 				log.V(2).InfoS("Change detected",
-					"type", string(diff.Type),
+					"type", diff.Type.String(),
 					"path", diff.Path)
 			}
 
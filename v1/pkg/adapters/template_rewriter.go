@@ -0,0 +1,108 @@
+package adapters
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/keycase"
+	"helm-charts-migrator/v1/pkg/logger"
+)
+
+// templateDirs are the chart directories holding Go templates
+var templateDirs = []string{"templates", "dashboards"}
+
+// TemplateRewriter rewrites the values references of a migrated chart's
+// templates after its keys were renamed
+type TemplateRewriter interface {
+	RewriteTemplates(serviceName, chartDir string, renames keycase.RenameMap) ([]keycase.TemplateReference, error)
+}
+
+// templateRewriter implements TemplateRewriter
+type templateRewriter struct {
+	config *config.Config
+	log    *logger.NamedLogger
+}
+
+// NewTemplateRewriter creates a new TemplateRewriter
+func NewTemplateRewriter(cfg *config.Config) TemplateRewriter {
+	return &templateRewriter{
+		config: cfg,
+		log:    logger.WithName("template-rewriter"),
+	}
+}
+
+// RewriteTemplates rewrites the .Values references of the templates and
+// dashboards of a chart with the renames of its values. It returns the
+// references rewritten and the ones that could not be resolved, which are left
+// as they are.
+func (t *templateRewriter) RewriteTemplates(serviceName, chartDir string, renames keycase.RenameMap) ([]keycase.TemplateReference, error) {
+	converter, err := keycase.NewFromConfig(t.config.GetConverterConfig(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("invalid converter configuration for service %s: %w", serviceName, err)
+	}
+
+	var references []keycase.TemplateReference
+	for _, dir := range templateDirs {
+		root := filepath.Join(chartDir, dir)
+		if _, err := os.Stat(root); os.IsNotExist(err) {
+			continue
+		}
+
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			fileReferences, err := t.rewriteFile(converter, chartDir, path, info, renames)
+			if err != nil {
+				return err
+			}
+			references = append(references, fileReferences...)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewrite templates of %s: %w", serviceName, err)
+		}
+	}
+
+	for _, reference := range references {
+		if !reference.Resolved {
+			t.log.Warning("Unresolved values reference", "service", serviceName,
+				"file", reference.File, "line", reference.Line, "path", reference.Path)
+		}
+	}
+	return references, nil
+}
+
+// rewriteFile rewrites one template, writing it back when it changed
+func (t *templateRewriter) rewriteFile(converter *keycase.Converter, chartDir, path string, info os.FileInfo, renames keycase.RenameMap) ([]keycase.TemplateReference, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: %w", path, err)
+	}
+	if !bytes.Contains(content, []byte("{{")) || !bytes.Contains(content, []byte(".Values")) {
+		return nil, nil
+	}
+
+	name, err := filepath.Rel(chartDir, path)
+	if err != nil {
+		name = path
+	}
+	rewritten, references, err := converter.RewriteTemplate(filepath.ToSlash(name), content, renames)
+	if err != nil {
+		// Files that are not templates, such as dashboards using {{ }} of
+		// their own, are left alone
+		t.log.V(2).InfoS("Skipping file that does not parse as a template", "path", path, "error", err)
+		return nil, nil
+	}
+
+	if !bytes.Equal(rewritten, content) {
+		if err := os.WriteFile(path, rewritten, info.Mode()); err != nil {
+			return nil, fmt.Errorf("failed to write template %s: %w", path, err)
+		}
+		t.log.V(2).InfoS("Rewrote values references", "path", path)
+	}
+	return references, nil
+}
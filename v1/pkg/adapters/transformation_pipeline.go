@@ -5,9 +5,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"helm-charts-migrator/v1/pkg/config"
 	"helm-charts-migrator/v1/pkg/logger"
+	"helm-charts-migrator/v1/pkg/secrets"
 	"helm-charts-migrator/v1/pkg/services"
 	yaml "github.com/elioetibr/golang-yaml-advanced"
 )
@@ -17,6 +19,8 @@ type TransformationPipeline struct {
 	config    *config.Config
 	file      services.FileService
 	transform services.TransformationService
+	imported  map[string]map[string]interface{} // secrets imported this run, by secrets file path
+	mu        sync.Mutex
 	log       *logger.NamedLogger
 }
 
@@ -26,17 +30,61 @@ func NewTransformationPipeline(cfg *config.Config, file services.FileService, tr
 		config:    cfg,
 		file:      file,
 		transform: transform,
+		imported:  make(map[string]map[string]interface{}),
 		log:       logger.WithName("transformation-pipeline"),
 	}
 }
 
+// AddImportedSecrets queues secrets imported from the cluster for a secrets
+// file. They are written with the secrets extracted from the values next to
+// it when the service is transformed; extracted secrets win on conflicts.
+func (tp *TransformationPipeline) AddImportedSecrets(secretsPath string, secrets map[string]interface{}) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	key := filepath.Clean(secretsPath)
+	tp.imported[key] = tp.transform.MergeValues(tp.imported[key], secrets)
+}
+
+// takeImportedSecrets removes and returns the secrets queued for a secrets file
+func (tp *TransformationPipeline) takeImportedSecrets(secretsPath string) map[string]interface{} {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	key := filepath.Clean(secretsPath)
+	secrets := tp.imported[key]
+	delete(tp.imported, key)
+	return secrets
+}
+
+// takeImportedSecretsUnder removes and returns the queued secrets of every
+// secrets file under dir
+func (tp *TransformationPipeline) takeImportedSecretsUnder(dir string) map[string]map[string]interface{} {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	prefix := filepath.Clean(dir) + string(filepath.Separator)
+	taken := make(map[string]map[string]interface{})
+	for path, secrets := range tp.imported {
+		if strings.HasPrefix(path, prefix) {
+			taken[path] = secrets
+			delete(tp.imported, path)
+		}
+	}
+	return taken
+}
+
 // TransformService transforms all values files for a service
 func (tp *TransformationPipeline) TransformService(serviceName string) error {
 	paths := config.NewPaths("", "apps", ".cache").ForService(serviceName)
 	serviceDir := paths.ServiceDir()
+	service, _ := tp.config.GetMergedServiceConfig(serviceName)
+
+	extractor, err := secrets.NewFromMainConfig(tp.config)
+	if err != nil {
+		return fmt.Errorf("failed to create secret extractor: %w", err)
+	}
+	separator := secrets.NewSeparator(extractor)
 
 	// Process all values.yaml files in the service directory
-	err := filepath.Walk(serviceDir, func(path string, info os.FileInfo, err error) error {
+	err = filepath.Walk(serviceDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -77,23 +125,38 @@ func (tp *TransformationPipeline) TransformService(serviceName string) error {
 			return nil
 		}
 
+		appPath, err := config.ParseAppPath(path)
+		if err != nil {
+			tp.log.Error(err, "Failed to parse values file path", "path", path)
+			return nil
+		}
+
+		if service != nil {
+			tp.applyAutoInject(service.AutoInjectRulesFor(appPath), values, path)
+		}
+
 		// Extract secrets if in envs directory
-		if strings.Contains(path, "/envs/") {
-			secrets, cleaned := tp.transform.ExtractSecrets(values)
+		if appPath.Environment != "" {
+			extracted, cleaned, err := tp.separateSecrets(separator, serviceName, path, values)
+			if err != nil {
+				tp.log.Error(err, "Failed to separate secrets", "path", path)
+				return nil
+			}
+			secretsPath := filepath.Join(filepath.Dir(path), "secrets.dec.yaml")
+			imported := tp.takeImportedSecrets(secretsPath)
 
-			if len(secrets) > 0 {
+			if len(extracted) > 0 || len(imported) > 0 {
 				// Save secrets to secrets.dec.yaml
-				secretsPath := strings.Replace(path, "values.yaml", "secrets.dec.yaml", 1)
-
-				// Create secrets document with proper structure
-				secretsDoc := tp.createSecretsDocument(path, secrets)
+				secretsDoc := tp.createSecretsDocument(path, tp.transform.MergeValues(imported, extracted))
 
 				if err := tp.saveSecretsFile(secretsPath, secretsDoc); err != nil {
 					tp.log.Error(err, "Failed to save secrets file", "path", secretsPath)
 				} else {
 					tp.log.V(2).InfoS("Saved secrets file", "path", secretsPath)
 				}
+			}
 
+			if len(extracted) > 0 {
 				// Update values file with cleaned values
 				values = cleaned
 			}
@@ -123,7 +186,11 @@ func (tp *TransformationPipeline) TransformService(serviceName string) error {
 			return nil
 		}
 
-		// Merge the trees (override takes precedence, preserving comments from base)
+		// Drop the keys moved out of the values, then merge the trees
+		// (override takes precedence, preserving comments from base)
+		for _, doc := range baseChartValuesTree.Documents {
+			pruneNode(doc.Root, transformedDataMap)
+		}
 		mergedTree := yaml.MergeTrees(baseChartValuesTree, overrideTree)
 
 		// Convert merged tree to YAML
@@ -146,10 +213,115 @@ func (tp *TransformationPipeline) TransformService(serviceName string) error {
 		return fmt.Errorf("failed to transform service %s: %w", serviceName, err)
 	}
 
+	// Imported secrets whose values file was skipped still get their own file
+	for secretsPath, secrets := range tp.takeImportedSecretsUnder(serviceDir) {
+		if err := tp.saveSecretsFile(secretsPath, tp.createSecretsDocument(secretsPath, secrets)); err != nil {
+			tp.log.Error(err, "Failed to save secrets file", "path", secretsPath)
+		}
+	}
+
 	tp.log.InfoS("Transformed service", "service", serviceName)
 	return nil
 }
 
+// separateSecrets moves the secrets detected in the values out of them with
+// the service's secrets configuration: exclusions and exact keys apply, and
+// matches below the confidence threshold stay in place for secrets review
+func (tp *TransformationPipeline) separateSecrets(separator *secrets.Separator, serviceName, path string, values map[string]interface{}) (extracted, cleaned map[string]interface{}, err error) {
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, result, err := separator.SeparateSecrets(data, serviceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, warning := range result.Warnings {
+		tp.log.V(2).InfoS("Secret separation warning", "path", path, "warning", warning)
+	}
+	if len(result.Candidates) > 0 {
+		tp.log.InfoS("Left low-confidence secrets in place, run secrets review to decide on them",
+			"path", path, "candidates", len(result.Candidates))
+	}
+
+	cleaned, ok := result.ModifiedData.(map[string]interface{})
+	if !ok {
+		return nil, values, nil
+	}
+	extracted, _ = cleaned[result.StorePath].(map[string]interface{})
+	delete(cleaned, result.StorePath)
+	return extracted, cleaned, nil
+}
+
+// pruneNode removes the mapping keys of node that values does not have
+func pruneNode(node *yaml.Node, values map[string]interface{}) {
+	if node != nil && node.Kind == yaml.DocumentNode {
+		for _, child := range node.Children {
+			pruneNode(child, values)
+		}
+		return
+	}
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	kept := make([]*yaml.Node, 0, len(node.Children))
+	for i := 0; i+1 < len(node.Children); i += 2 {
+		key, value := node.Children[i], node.Children[i+1]
+		child, ok := values[fmt.Sprintf("%v", key.Value)]
+		if !ok {
+			continue
+		}
+		if childValues, isMap := child.(map[string]interface{}); isMap {
+			pruneNode(value, childValues)
+		}
+		kept = append(kept, key, value)
+	}
+	node.Children = kept
+}
+
+// applyAutoInject sets the keys of the auto-inject rules whose condition holds
+// for the values. Missing parent maps are created; a rule is skipped when a
+// parent key holds something other than a map.
+func (tp *TransformationPipeline) applyAutoInject(rules []config.AutoInjectKey, values map[string]interface{}, path string) {
+	for _, rule := range rules {
+		segments, err := rule.Path()
+		if err != nil {
+			tp.log.Error(err, "Skipping auto-inject rule", "path", path)
+			continue
+		}
+		condition := config.InjectionCondition(rule.Condition)
+
+		parent := values
+		for _, segment := range segments[:len(segments)-1] {
+			next, exists := parent[segment]
+			if !exists {
+				if !condition.ShouldApply(false) {
+					parent = nil
+					break
+				}
+				next = make(map[string]interface{})
+				parent[segment] = next
+			}
+			child, ok := next.(map[string]interface{})
+			if !ok {
+				parent = nil
+				break
+			}
+			parent = child
+		}
+		if parent == nil {
+			continue
+		}
+
+		key := segments[len(segments)-1]
+		if _, exists := parent[key]; !condition.ShouldApply(exists) {
+			continue
+		}
+		parent[key] = rule.Value
+		tp.log.V(2).InfoS("Injected value", "path", path, "key", rule.Key)
+	}
+}
+
 // createSecretsDocument creates a properly formatted secrets document
 func (tp *TransformationPipeline) createSecretsDocument(path string, secrets map[string]interface{}) map[string]interface{} {
 	// For now, just return the secrets map
@@ -2,10 +2,10 @@ package adapters
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
-
-	"helm.sh/helm/v3/pkg/release"
+	"sync"
 
 	"helm-charts-migrator/v1/pkg/config"
 	"helm-charts-migrator/v1/pkg/keycase"
@@ -16,54 +16,56 @@ import (
 
 // ValuesExtractor handles extracting values from releases
 type ValuesExtractor interface {
-	ExtractLegacyHelmValues(serviceRelease *release.Release, outputPath string) error
+	ConvertValues(serviceName, outputPath string, values map[string]interface{}) (*yaml.NodeTree, *keycase.Result, error)
 	ExtractLegacySourceValues(sourcePath, serviceName, outputPath string) error
+	ServiceRenames(serviceDir string) keycase.RenameMap
 }
 
 // valuesExtractor implements ValuesExtractor
 type valuesExtractor struct {
-	config    config.ConverterConfig
+	config    *config.Config
 	transform services.TransformationService
+	renames   map[string]*keycase.Result // renames of this run, by rename map path
+	mu        sync.Mutex
 	log       *logger.NamedLogger
 }
 
 // NewValuesExtractor creates a new ValuesExtractor
 func NewValuesExtractor(cfg *config.Config, transform services.TransformationService) ValuesExtractor {
 	return &valuesExtractor{
-		config:    cfg.Globals.Converter,
+		config:    cfg,
 		transform: transform,
+		renames:   make(map[string]*keycase.Result),
 		log:       logger.WithName("values-extractor"),
 	}
 }
 
-// ExtractLegacyHelmValues extracts and converts values from a Helm release
-func (v *valuesExtractor) ExtractLegacyHelmValues(serviceRelease *release.Release, outputPath string) error {
-	if serviceRelease == nil || serviceRelease.Config == nil {
-		return fmt.Errorf("no values to extract")
+// ConvertValues converts the keys of release values to be written to
+// outputPath to the service's style. The keys are converted on the returned
+// node tree, and the renames are added to the service's rename map.
+func (v *valuesExtractor) ConvertValues(serviceName, outputPath string, values map[string]interface{}) (*yaml.NodeTree, *keycase.Result, error) {
+	yamlBytes, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal YAML: %w", err)
 	}
-
-	// Convert to camelCase
-	values := serviceRelease.Config
-	convertedValues := v.convertKeys(values)
-
-	// Ensure directory exists
-	dir := filepath.Dir(outputPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+	tree, err := yaml.UnmarshalYAML(yamlBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
-	// Save as YAML
-	yamlBytes, err := yaml.Marshal(convertedValues)
+	converter, err := v.newConverter(serviceName)
 	if err != nil {
-		return fmt.Errorf("failed to marshal YAML: %w", err)
+		return nil, nil, err
 	}
-
-	if err := os.WriteFile(outputPath, yamlBytes, 0644); err != nil {
-		return fmt.Errorf("failed to write values: %w", err)
+	renames, err := converter.ConvertTree(tree)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert keys of %s: %w", outputPath, err)
 	}
 
-	v.log.InfoS("Extracted legacy Helm values", "path", outputPath)
-	return nil
+	if err := v.saveRenames(outputPath, renames); err != nil {
+		return nil, nil, err
+	}
+	return tree, renames, nil
 }
 
 // ExtractLegacySourceValues extracts values from source path
@@ -82,16 +84,23 @@ func (v *valuesExtractor) ExtractLegacySourceValues(sourcePath, serviceName, out
 		return fmt.Errorf("failed to read source values: %w", err)
 	}
 
-	var values map[string]interface{}
-	if err := yaml.Unmarshal(data, &values); err != nil {
+	tree, err := yaml.UnmarshalYAML(data)
+	if err != nil {
 		return fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
-	// Convert to camelCase
-	convertedValues := v.convertKeys(values)
+	// Convert to camelCase in place, keeping comments and key order
+	converter, err := v.newConverter(serviceName)
+	if err != nil {
+		return err
+	}
+	renames, err := converter.ConvertTree(tree)
+	if err != nil {
+		return fmt.Errorf("failed to convert keys of %s: %w", sourceFile, err)
+	}
 
 	// Save to output path
-	yamlBytes, err := yaml.Marshal(convertedValues)
+	yamlBytes, err := tree.ToYAML()
 	if err != nil {
 		return fmt.Errorf("failed to marshal YAML: %w", err)
 	}
@@ -100,17 +109,76 @@ func (v *valuesExtractor) ExtractLegacySourceValues(sourcePath, serviceName, out
 		return fmt.Errorf("failed to write values: %w", err)
 	}
 
+	if err := v.saveRenames(outputPath, renames); err != nil {
+		return err
+	}
+
 	v.log.InfoS("Extracted legacy source values", "path", outputPath)
 	return nil
 }
 
-// convertKeys converts keys to camelCase based on configuration
-func (v *valuesExtractor) convertKeys(values map[string]interface{}) map[string]interface{} {
-	converter := keycase.NewConverter()
+// ServiceRenames returns the renames of this run saved to the rename map of
+// the service in serviceDir
+func (v *valuesExtractor) ServiceRenames(serviceDir string) keycase.RenameMap {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	renames := make(keycase.RenameMap)
+	if saved, ok := v.renames[filepath.Join(serviceDir, keycase.RenamesFilename)]; ok {
+		maps.Copy(renames, saved.Renames)
+	}
+	return renames
+}
 
-	converter.SkipJavaProperties = v.config.SkipJavaProperties
-	converter.SkipUppercaseKeys = v.config.SkipUppercaseKeys
-	converter.MinUppercaseChars = v.config.MinUppercaseChars
+// newConverter creates a key converter from the service's converter configuration
+func (v *valuesExtractor) newConverter(serviceName string) (*keycase.Converter, error) {
+	converter, err := keycase.NewFromConfig(v.config.GetConverterConfig(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("invalid converter configuration for service %s: %w", serviceName, err)
+	}
+	return converter, nil
+}
+
+// saveRenames adds the renames of a converted file to the ones of this run and
+// rewrites its service's rename map with them, so renames of earlier runs that
+// no longer apply are dropped
+func (v *valuesExtractor) saveRenames(outputPath string, renames *keycase.Result) error {
+	for _, collision := range renames.Collisions {
+		v.log.InfoS("Resolved key collision",
+			"path", collision.Path,
+			"keys", collision.Keys,
+			"kept", collision.Kept,
+			"merged", collision.Merged)
+	}
+	path := renamesPath(outputPath)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	saved, ok := v.renames[path]
+	if !ok {
+		saved = keycase.NewResult()
+		v.renames[path] = saved
+	}
+	saved.Merge(renames)
 
-	return converter.ConvertMap(values)
+	if saved.IsEmpty() {
+		// Nothing renamed this run so far; drop a map left by an earlier run
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale rename map: %w", err)
+		}
+		return nil
+	}
+	if err := saved.Save(path); err != nil {
+		return err
+	}
+
+	v.log.V(2).InfoS("Saved key renames", "path", path, "renamed", len(renames.Renames))
+	return nil
+}
+
+// renamesPath returns the rename map of the service a values file belongs to,
+// or the one next to the file when it is not under an apps directory
+func renamesPath(valuesPath string) string {
+	if appPath, err := config.ParseAppPath(valuesPath); err == nil {
+		return filepath.Join(filepath.FromSlash(appPath.Root), appPath.Service, keycase.RenamesFilename)
+	}
+	return filepath.Join(filepath.Dir(valuesPath), keycase.RenamesFilename)
 }
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileKind identifies the role of a file in a migrated chart
+type FileKind string
+
+const (
+	FileKindValues           FileKind = "values"
+	FileKindLegacyValues     FileKind = "legacy-values"
+	FileKindHelmValues       FileKind = "helm-values"
+	FileKindSecrets          FileKind = "secrets"
+	FileKindEncryptedSecrets FileKind = "encrypted-secrets"
+	FileKindChart            FileKind = "chart"
+	FileKindTemplate         FileKind = "template"
+	FileKindDirectory        FileKind = "directory"
+	FileKindOther            FileKind = "other"
+)
+
+// PathLevel is the level of the values hierarchy a path belongs to
+type PathLevel string
+
+const (
+	LevelService     PathLevel = "service"
+	LevelEnvironment PathLevel = "environment"
+	LevelCluster     PathLevel = "cluster"
+	LevelNamespace   PathLevel = "namespace"
+)
+
+// AppPath is a path under apps/ broken down into the parts Paths builds it from:
+// {root}/{service}/envs/{environment}/clusters/{cluster}/namespaces/{namespace}/{file}
+type AppPath struct {
+	Root        string // directory holding the services, usually apps
+	Service     string
+	Environment string
+	Cluster     string
+	Namespace   string
+	Rel         string // slash-separated path relative to the service directory
+	Kind        FileKind
+}
+
+// ParseAppPath parses a path containing an apps/ directory
+func ParseAppPath(p string) (AppPath, error) {
+	parts := splitPath(p)
+	for i := len(parts) - 2; i >= 0; i-- {
+		if parts[i] == "apps" {
+			return parseAppParts(strings.Join(parts[:i+1], "/"), parts[i+1:], p)
+		}
+	}
+	return AppPath{}, fmt.Errorf("path %s is not under an apps directory", p)
+}
+
+// ParseAppPathUnder parses a path relative to root, the directory holding the services
+func ParseAppPathUnder(root, p string) (AppPath, error) {
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == "." || strings.HasPrefix(filepath.ToSlash(rel), "../") || rel == ".." {
+		return AppPath{}, fmt.Errorf("path %s is not under %s", p, root)
+	}
+	return parseAppParts(filepath.ToSlash(filepath.Clean(root)), splitPath(rel), p)
+}
+
+// Parse parses a path under the migration target directory
+func (p *Paths) Parse(path string) (AppPath, error) {
+	if p.TargetPath == "" {
+		return ParseAppPath(path)
+	}
+	return ParseAppPathUnder(p.TargetPath, path)
+}
+
+func splitPath(p string) []string {
+	cleaned := filepath.ToSlash(filepath.Clean(p))
+	return strings.Split(cleaned, "/")
+}
+
+// parseAppParts parses the segments following the root: {service}/...
+func parseAppParts(root string, parts []string, original string) (AppPath, error) {
+	appPath := AppPath{Root: root, Service: parts[0], Rel: strings.Join(parts[1:], "/")}
+	rest := parts[1:]
+
+	if len(rest) > 0 && rest[0] == "envs" {
+		// Each level is a name optionally followed by the directory of the next level
+		levels := []struct {
+			marker string
+			value  *string
+		}{
+			{"envs", &appPath.Environment},
+			{"clusters", &appPath.Cluster},
+			{"namespaces", &appPath.Namespace},
+		}
+		for _, level := range levels {
+			if len(rest) == 0 || rest[0] != level.marker {
+				break
+			}
+			if len(rest) == 1 {
+				appPath.Kind = FileKindDirectory
+				return appPath, nil
+			}
+			*level.value = rest[1]
+			rest = rest[2:]
+		}
+
+		if len(rest) > 1 || (len(rest) == 1 && isLevelMarker(rest[0])) {
+			return AppPath{}, fmt.Errorf("path %s does not follow the envs/<environment>/clusters/<cluster>/namespaces/<namespace> layout", original)
+		}
+	}
+
+	appPath.Kind = fileKind(rest)
+	return appPath, nil
+}
+
+func isLevelMarker(name string) bool {
+	return name == "envs" || name == "clusters" || name == "namespaces"
+}
+
+// fileKind classifies the segments left after the service and hierarchy directories
+func fileKind(rest []string) FileKind {
+	if len(rest) == 0 {
+		return FileKindDirectory
+	}
+	if rest[0] == "templates" {
+		return FileKindTemplate
+	}
+	if len(rest) > 1 {
+		return FileKindOther
+	}
+
+	switch rest[0] {
+	case "values.yaml":
+		return FileKindValues
+	case "legacy-values.yaml":
+		return FileKindLegacyValues
+	case "helm-values.yaml":
+		return FileKindHelmValues
+	case "secrets.dec.yaml", "secrets.yaml":
+		return FileKindSecrets
+	case "secrets.enc.yaml":
+		return FileKindEncryptedSecrets
+	case "Chart.yaml":
+		return FileKindChart
+	default:
+		return FileKindOther
+	}
+}
+
+// Level returns the most specific hierarchy level of the path
+func (p AppPath) Level() PathLevel {
+	switch {
+	case p.Namespace != "":
+		return LevelNamespace
+	case p.Cluster != "":
+		return LevelCluster
+	case p.Environment != "":
+		return LevelEnvironment
+	default:
+		return LevelService
+	}
+}
+
+// ServiceDir returns the service directory the path belongs to
+func (p AppPath) ServiceDir() string {
+	return filepath.FromSlash(path.Join(p.Root, p.Service))
+}
+
+// Matches reports whether the service-relative path matches a glob pattern such
+// as "envs/*/clusters/dev01/**/values.yaml". "*" matches within one segment
+// and "**" matches any number of segments.
+func (p AppPath) Matches(pattern string) bool {
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(p.Rel, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], parts[0]); err != nil || !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// Expand replaces the {service}, {environment}, {cluster} and {namespace}
+// placeholders in s with the parts of the path
+func (p AppPath) Expand(s string) string {
+	return strings.NewReplacer(
+		"{service}", p.Service,
+		"{environment}", p.Environment,
+		"{cluster}", p.Cluster,
+		"{namespace}", p.Namespace,
+	).Replace(s)
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAppPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected AppPath
+		level    PathLevel
+	}{
+		{
+			path:     "apps/heimdall/values.yaml",
+			expected: AppPath{Root: "apps", Service: "heimdall", Rel: "values.yaml", Kind: FileKindValues},
+			level:    LevelService,
+		},
+		{
+			path:     "apps/heimdall/legacy-values.yaml",
+			expected: AppPath{Root: "apps", Service: "heimdall", Rel: "legacy-values.yaml", Kind: FileKindLegacyValues},
+			level:    LevelService,
+		},
+		{
+			path:     "apps/heimdall/templates/deployment.yaml",
+			expected: AppPath{Root: "apps", Service: "heimdall", Rel: "templates/deployment.yaml", Kind: FileKindTemplate},
+			level:    LevelService,
+		},
+		{
+			path: "apps/heimdall/envs/production/secrets.dec.yaml",
+			expected: AppPath{Root: "apps", Service: "heimdall", Environment: "production",
+				Rel: "envs/production/secrets.dec.yaml", Kind: FileKindSecrets},
+			level: LevelEnvironment,
+		},
+		{
+			path: "apps/heimdall/envs/production/clusters/prod01/values.yaml",
+			expected: AppPath{Root: "apps", Service: "heimdall", Environment: "production", Cluster: "prod01",
+				Rel: "envs/production/clusters/prod01/values.yaml", Kind: FileKindValues},
+			level: LevelCluster,
+		},
+		{
+			path: "/work/apps/heimdall/envs/production/clusters/prod01/namespaces/viafoura/secrets.enc.yaml",
+			expected: AppPath{Root: "/work/apps", Service: "heimdall", Environment: "production", Cluster: "prod01", Namespace: "viafoura",
+				Rel: "envs/production/clusters/prod01/namespaces/viafoura/secrets.enc.yaml", Kind: FileKindEncryptedSecrets},
+			level: LevelNamespace,
+		},
+		{
+			path: "apps/heimdall/envs/production/clusters/prod01/namespaces/viafoura",
+			expected: AppPath{Root: "apps", Service: "heimdall", Environment: "production", Cluster: "prod01", Namespace: "viafoura",
+				Rel: "envs/production/clusters/prod01/namespaces/viafoura", Kind: FileKindDirectory},
+			level: LevelNamespace,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			appPath, err := ParseAppPath(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, appPath)
+			assert.Equal(t, tt.level, appPath.Level())
+		})
+	}
+}
+
+func TestParseAppPath_Errors(t *testing.T) {
+	for _, path := range []string{
+		"charts/heimdall/values.yaml",
+		"apps",
+		// Legacy envs/{cluster}/{environment}/{namespace} layout
+		"apps/heimdall/envs/dev01/dev/viafoura/values.yaml",
+		"apps/heimdall/envs/production/clusters/prod01/extra/values.yaml",
+	} {
+		t.Run(path, func(t *testing.T) {
+			_, err := ParseAppPath(path)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseAppPath_RoundTripsPaths(t *testing.T) {
+	paths := NewPaths("", "apps", ".cache").ForService("heimdall").ForCluster("prod01").ForEnvironment("production", "viafoura")
+
+	appPath, err := paths.Parse(paths.EnvironmentNamespaceSecretsPath())
+	require.NoError(t, err)
+	assert.Equal(t, "heimdall", appPath.Service)
+	assert.Equal(t, "production", appPath.Environment)
+	assert.Equal(t, "prod01", appPath.Cluster)
+	assert.Equal(t, "viafoura", appPath.Namespace)
+	assert.Equal(t, FileKindSecrets, appPath.Kind)
+	assert.Equal(t, filepath.Join("apps", "heimdall"), appPath.ServiceDir())
+
+	appPath, err = paths.Parse(paths.EnvironmentClusterValuesPath())
+	require.NoError(t, err)
+	assert.Equal(t, LevelCluster, appPath.Level())
+
+	_, err = paths.Parse(filepath.Join("other", "heimdall", "values.yaml"))
+	assert.Error(t, err)
+}
+
+func TestAppPath_Matches(t *testing.T) {
+	appPath, err := ParseAppPath("apps/heimdall/envs/dev/clusters/dev01/namespaces/viafoura/values.yaml")
+	require.NoError(t, err)
+
+	assert.True(t, appPath.Matches("envs/**/values.yaml"))
+	assert.True(t, appPath.Matches("envs/*/clusters/dev01/**/values.yaml"))
+	assert.True(t, appPath.Matches("**/dev01/**"))
+	assert.True(t, appPath.Matches("envs/dev/clusters/*/namespaces/*/values.yaml"))
+	assert.False(t, appPath.Matches("values.yaml"))
+	assert.False(t, appPath.Matches("envs/*/values.yaml"))
+	assert.False(t, appPath.Matches("envs/**/prod01/**"))
+}
+
+func TestService_AutoInjectRulesFor(t *testing.T) {
+	service := Service{
+		AutoInject: map[string]AutoInjectFile{
+			"values.yaml": {Keys: []AutoInjectKey{{Key: "a", Value: "{service}"}}},
+			"envs/**/dev01/**/values.yaml": {Keys: []AutoInjectKey{
+				{Key: "b", Value: "{environment}-{cluster}-{namespace}", Condition: string(ConditionIfExists)},
+			}},
+		},
+	}
+
+	appPath, err := ParseAppPath("apps/heimdall/envs/dev/clusters/dev01/namespaces/viafoura/values.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []AutoInjectKey{
+		{Key: "b", Value: "dev-dev01-viafoura", Condition: "ifExists"},
+	}, service.AutoInjectRulesFor(appPath))
+
+	appPath, err = ParseAppPath("apps/heimdall/values.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []AutoInjectKey{{Key: "a", Value: "heimdall"}}, service.AutoInjectRulesFor(appPath))
+}
+
+func TestAutoInjectKey_Path(t *testing.T) {
+	segments, err := AutoInjectKey{Key: `secrets."root.properties"."9487e74c".value`}.Path()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"secrets", "root.properties", "9487e74c", "value"}, segments)
+
+	segments, err = AutoInjectKey{Key: "image.tag"}.Path()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"image", "tag"}, segments)
+
+	for _, key := range []string{"", "a..b", "a.", `a."b`, `a."b"c`} {
+		_, err := AutoInjectKey{Key: key}.Path()
+		assert.Error(t, err, key)
+	}
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"helm-charts-migrator/v1/pkg/kubernetes"
+)
+
+// ClusterScheme prefixes a --config that is read from ConfigMaps, as in
+// cluster://<context>/<namespace>
+const ClusterScheme = "cluster://"
+
+// Labels and data key of the ConfigMaps holding migrator configuration
+const (
+	// ConfigMapLabel selects the ConfigMaps and names the section they hold:
+	// config, globals, cluster or service
+	ConfigMapLabel = "helm-charts-migrator/config"
+	// ConfigMapNameLabel names the cluster or service of a fragment; the
+	// ConfigMap name is used without it
+	ConfigMapNameLabel = "helm-charts-migrator/name"
+	// ConfigMapAccountLabel names the account of a cluster fragment
+	ConfigMapAccountLabel = "helm-charts-migrator/account"
+	// ConfigMapDataKey holds the YAML of a ConfigMap
+	ConfigMapDataKey = "config.yaml"
+)
+
+// sectionOrder is the order ConfigMaps are merged in, whole configurations first
+var sectionOrder = map[string]int{
+	sectionConfig:  0,
+	sectionGlobals: 1,
+	sectionCluster: 2,
+	sectionService: 3,
+}
+
+// IsClusterSource reports whether a config path names ConfigMaps in a cluster
+func IsClusterSource(path string) bool {
+	return strings.HasPrefix(path, ClusterScheme)
+}
+
+// ParseClusterSource splits cluster://<context>/<namespace> into its context and namespace
+func ParseClusterSource(source string) (string, string, error) {
+	kubeContext, namespace, ok := strings.Cut(strings.TrimPrefix(source, ClusterScheme), "/")
+	if !IsClusterSource(source) || !ok || kubeContext == "" || namespace == "" || strings.Contains(namespace, "/") {
+		return "", "", fmt.Errorf("invalid cluster config %q, expected %s<context>/<namespace>", source, ClusterScheme)
+	}
+	return kubeContext, namespace, nil
+}
+
+// loadConfigMaps decodes and merges the labelled ConfigMaps of a namespace.
+// The problems of every ConfigMap are returned together.
+func (c *configLoader) loadConfigMaps(ctx context.Context, client *kubernetes.Client, namespace string) (*Config, error) {
+	configMaps, err := client.GetConfigMapsWithSelector(ctx, namespace, ConfigMapLabel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list config maps: %w", err)
+	}
+	sort.SliceStable(configMaps, func(i, j int) bool {
+		left, right := sectionOrder[configMaps[i].Labels[ConfigMapLabel]], sectionOrder[configMaps[j].Labels[ConfigMapLabel]]
+		if left != right {
+			return left < right
+		}
+		return configMaps[i].Name < configMaps[j].Name
+	})
+
+	merged := &Config{
+		Accounts: make(map[string]Account),
+		Services: make(map[string]Service),
+	}
+	var problems ValidationErrors
+	for _, configMap := range configMaps {
+		c.log.V(3).InfoS("Loading config map", "namespace", namespace, "name", configMap.Name)
+
+		cfg, configMapProblems := c.decodeConfigMap(configMap, merged)
+		problems = append(problems, configMapProblems...)
+		if cfg != nil {
+			merged = c.MergeConfigs(merged, cfg)
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return merged, nil
+}
+
+// decodeConfigMap decodes the section a ConfigMap holds. Cluster fragments
+// without an account label go to the account of merged that already has the
+// cluster, or to the default account.
+func (c *configLoader) decodeConfigMap(configMap *corev1.ConfigMap, merged *Config) (*Config, ValidationErrors) {
+	v := c.newValidator(fmt.Sprintf("configmap %s/%s", configMap.Namespace, configMap.Name))
+
+	data, ok := configMap.Data[ConfigMapDataKey]
+	if !ok {
+		v.add("", "no %s key", ConfigMapDataKey)
+		return nil, v.result()
+	}
+
+	section := configMap.Labels[ConfigMapLabel]
+	if section == sectionConfig {
+		var cfg Config
+		if v.decode([]byte(data), &cfg, "") {
+			if len(cfg.Include) > 0 {
+				v.add("include", "include is not supported in config maps")
+			}
+			v.markEnabledServices(&cfg)
+			v.checkConfig(&cfg)
+		}
+		return &cfg, v.result()
+	}
+
+	name := configMap.Labels[ConfigMapNameLabel]
+	if name == "" {
+		name = configMap.Name
+	}
+	account := configMap.Labels[ConfigMapAccountLabel]
+	if account == "" {
+		account = defaultAccount
+	}
+
+	cfg, ok := c.decodeSection(v, section, name, account, []byte(data))
+	if !ok {
+		return nil, v.result()
+	}
+	if section == sectionCluster && configMap.Labels[ConfigMapAccountLabel] == "" {
+		assignClusterAccounts(merged, cfg)
+	}
+	return cfg, v.result()
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"helm-charts-migrator/v1/pkg/kubernetes"
+	"helm-charts-migrator/v1/pkg/logger"
+)
+
+// newFakeClusterLoader creates a loader reading ConfigMaps from a fake clientset
+func newFakeClusterLoader(objects ...runtime.Object) *configLoader {
+	clientset := fake.NewSimpleClientset(objects...)
+	return &configLoader{
+		log: logger.WithName("config-loader"),
+		newClient: func(kubeContext string) (*kubernetes.Client, error) {
+			return kubernetes.NewClientForClientset(clientset, kubeContext), nil
+		},
+	}
+}
+
+// configMap creates a ConfigMap holding config.yaml
+func configMap(namespace, name string, labels map[string]string, data string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Data:       map[string]string{ConfigMapDataKey: data},
+	}
+}
+
+func TestParseClusterSource(t *testing.T) {
+	kubeContext, namespace, err := ParseClusterSource("cluster://prod01/migrator")
+	require.NoError(t, err)
+	assert.Equal(t, "prod01", kubeContext)
+	assert.Equal(t, "migrator", namespace)
+
+	for _, source := range []string{"cluster://prod01", "cluster:///migrator", "cluster://prod01/", "cluster://a/b/c", "prod01/migrator"} {
+		_, _, err := ParseClusterSource(source)
+		assert.Error(t, err, source)
+	}
+
+	assert.True(t, IsClusterSource("cluster://prod01/migrator"))
+	assert.False(t, IsClusterSource("./config.yaml"))
+}
+
+func TestConfigLoader_LoadFromCluster_ConfigMaps(t *testing.T) {
+	t.Run("fragments are merged", func(t *testing.T) {
+		loader := newFakeClusterLoader(
+			configMap("migrator", "migrator-base", map[string]string{ConfigMapLabel: "config"}, `
+accounts:
+  prod:
+    clusters:
+      prod01:
+        enabled: true
+        source: legacy-prod
+services:
+  heimdall:
+    enabled: false
+`),
+			configMap("migrator", "sops", map[string]string{ConfigMapLabel: "globals"}, `
+sops:
+  enabled: true
+  awsProfile: prod
+`),
+			configMap("migrator", "prod01", map[string]string{ConfigMapLabel: "cluster"}, "target: eks-prod\n"),
+			configMap("migrator", "dev01", map[string]string{ConfigMapLabel: "cluster", ConfigMapAccountLabel: "dev"}, "target: eks-dev\n"),
+			configMap("migrator", "heimdall-config", map[string]string{ConfigMapLabel: "service", ConfigMapNameLabel: "heimdall"}, `
+enabled: true
+parameterStore: heimdall
+`),
+			configMap("migrator", "unlabelled", nil, "services: {other: {enabled: true}}\n"),
+			configMap("elsewhere", "auth", map[string]string{ConfigMapLabel: "service"}, "enabled: true\n"),
+		)
+
+		cfg, err := loader.LoadFromCluster("prod01", "migrator")
+		require.NoError(t, err)
+
+		assert.True(t, cfg.Globals.SOPS.Enabled)
+		assert.Equal(t, "prod", cfg.Globals.SOPS.AwsProfile)
+
+		prod01 := cfg.Accounts["prod"].Clusters["prod01"]
+		assert.Equal(t, "eks-prod", prod01.Target)
+		assert.Equal(t, "legacy-prod", prod01.Source)
+		assert.NotContains(t, cfg.Accounts, defaultAccount)
+		assert.Equal(t, "eks-dev", cfg.Accounts["dev"].Clusters["dev01"].Target)
+
+		require.Contains(t, cfg.Services, "heimdall")
+		assert.True(t, cfg.Services["heimdall"].Enabled)
+		assert.Equal(t, "heimdall", cfg.Services["heimdall"].ParameterStore)
+		assert.NotContains(t, cfg.Services, "other")
+		assert.NotContains(t, cfg.Services, "auth")
+	})
+
+	t.Run("problems name the config map", func(t *testing.T) {
+		loader := newFakeClusterLoader(
+			configMap("migrator", "heimdall", map[string]string{ConfigMapLabel: "service"}, "enabld: true\n"),
+			configMap("migrator", "empty", map[string]string{ConfigMapLabel: "globals"}, ""),
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name: "no-data", Namespace: "migrator", Labels: map[string]string{ConfigMapLabel: "service"},
+			}},
+		)
+
+		_, err := loader.LoadFromCluster("prod01", "migrator")
+		var problems ValidationErrors
+		require.ErrorAs(t, err, &problems)
+		require.Len(t, problems, 2)
+		assert.Equal(t, "configmap migrator/heimdall", problems[0].File)
+		assert.Equal(t, "services.heimdall.enabld", problems[0].Path)
+		assert.Equal(t, "configmap migrator/no-data", problems[1].File)
+		assert.Contains(t, problems[1].Message, "no config.yaml key")
+	})
+
+	t.Run("no config maps", func(t *testing.T) {
+		_, err := newFakeClusterLoader().LoadFromCluster("prod01", "migrator")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no config maps labelled")
+	})
+}
@@ -1,11 +1,8 @@
 package config
 
 import (
-	"fmt"
-	"os"
-
+	"helm-charts-migrator/v1/pkg/errkind"
 	"helm-charts-migrator/v1/pkg/logger"
-	yaml "github.com/elioetibr/golang-yaml-advanced"
 )
 
 // Config represents the main configuration structure
@@ -16,6 +13,10 @@ type Config struct {
 	Cluster   string             `yaml:"cluster"`
 	Namespace string             `yaml:"namespace"`
 
+	// Include lists config files, directories or globs, relative to this
+	// file, merged before it so its own settings win
+	Include []string `yaml:"include,omitempty"`
+
 	// Paths provides centralized path management (not from YAML)
 	Paths *Paths `yaml:"-"`
 }
@@ -35,6 +36,10 @@ type Globals struct {
 	Mappings    *Mappings                 `yaml:"mappings,omitempty"`
 	Secrets     *Secrets                  `yaml:"secrets,omitempty"`
 	Migration   Migration                 `yaml:"migration"`
+	// ImportReferences copies Secrets and ConfigMaps used by releases into the values
+	ImportReferences *ReferenceImport `yaml:"importReferences,omitempty"`
+	// ValuesSchema configures the values.schema.json inferred for migrated charts
+	ValuesSchema *ValuesSchema `yaml:"valuesSchema,omitempty"`
 }
 
 // PipelineConfig represents migration pipeline configuration
@@ -50,6 +55,27 @@ type PipelineStep struct {
 	Description string `yaml:"description"`
 }
 
+// Pipeline steps migrate checks before running them
+const (
+	StepConvertLegacyKeycase = "convert_legacy_keycase"
+	StepRewriteTemplates     = "rewrite_templates"
+	StepGenerateValuesSchema = "generate_values_schema"
+)
+
+// IsStepEnabled reports whether a pipeline step runs. Every step runs unless
+// the pipeline is enabled and lists the step as disabled.
+func (p PipelineConfig) IsStepEnabled(name string) bool {
+	if !p.Enabled {
+		return true
+	}
+	for _, step := range p.Steps {
+		if step.Name == name {
+			return step.Enabled
+		}
+	}
+	return true
+}
+
 // PerformanceConfig represents performance tuning configuration
 type PerformanceConfig struct {
 	MaxConcurrentServices int  `yaml:"maxConcurrentServices"`
@@ -65,6 +91,32 @@ type SOPSConfig struct {
 	PathRegex       string `yaml:"pathRegex"`
 	SkipUnchanged   bool   `yaml:"skipUnchanged"`
 	Timeout         int    `yaml:"timeout"`
+	// AgeKeyFile is the age identity file used to decrypt (SOPS_AGE_KEY_FILE)
+	AgeKeyFile string `yaml:"ageKeyFile,omitempty"`
+	// GnuPGHome is the GnuPG home holding the PGP keys (GNUPGHOME)
+	GnuPGHome string `yaml:"gnupgHome,omitempty"`
+	// CreationRules select the keys per file; when empty the rules of ConfigFile are used
+	CreationRules []SOPSCreationRule `yaml:"creationRules,omitempty"`
+}
+
+// SOPSCreationRule selects the keys used to encrypt files matching PathRegex.
+// KMS, Age and PGP form a single key group; KeyGroups allows several groups
+// combined with ShamirThreshold.
+type SOPSCreationRule struct {
+	PathRegex       string         `yaml:"pathRegex,omitempty"`
+	KMS             []string       `yaml:"kms,omitempty"`
+	Age             []string       `yaml:"age,omitempty"`
+	PGP             []string       `yaml:"pgp,omitempty"`
+	KeyGroups       []SOPSKeyGroup `yaml:"keyGroups,omitempty"`
+	ShamirThreshold int            `yaml:"shamirThreshold,omitempty"`
+	EncryptedRegex  string         `yaml:"encryptedRegex,omitempty"`
+}
+
+// SOPSKeyGroup is a set of master keys that can each decrypt a share of the data key
+type SOPSKeyGroup struct {
+	KMS []string `yaml:"kms,omitempty"`
+	Age []string `yaml:"age,omitempty"`
+	PGP []string `yaml:"pgp,omitempty"`
 }
 
 // ConverterConfig represents configuration for the camelCase converter
@@ -72,6 +124,15 @@ type ConverterConfig struct {
 	SkipJavaProperties bool `yaml:"skipJavaProperties"`
 	SkipUppercaseKeys  bool `yaml:"skipUppercaseKeys"`
 	MinUppercaseChars  int  `yaml:"minUppercaseChars"`
+	// CollisionPolicy is fail (default), keep-first, keep-camel or merge
+	CollisionPolicy string `yaml:"collisionPolicy,omitempty"`
+	// TargetStyle is camelCase (default), snake_case, kebab-case or PascalCase
+	TargetStyle string `yaml:"targetStyle,omitempty"`
+	// PreserveKeys are keys that are never converted
+	PreserveKeys []string `yaml:"preserveKeys,omitempty"`
+	// SkipPaths are globs of paths whose keys, and everything beneath them,
+	// are left as they are, such as configMap.* or env.*
+	SkipPaths []string `yaml:"skipPaths,omitempty"`
 }
 
 // SetPaths initializes the Paths structure with the provided paths
@@ -87,20 +148,27 @@ func (c *Config) GetServicePaths(serviceName string) *Paths {
 	return c.Paths.ForService(serviceName)
 }
 
+// LoadConfig loads the configuration at configPath, then applies the HCM_
+// environment variables and --config-set flags on top of it
 func LoadConfig(configPath string) (*Config, error) {
 	log := logger.WithName("config")
 	log.InfoS("Loading configuration", "path", configPath)
 
-	// Read the file
-	data, err := os.ReadFile(configPath)
+	config, err := LoadFileConfig(configPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, err
 	}
 
-	// Use gopkg.in/yaml.v3 directly to unmarshal
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	// Environment variables, then --config-set flags, override the file
+	overrides := config.loadOverrides()
+	if err := config.ApplyOverrides(overrides); err != nil {
+		return nil, errkind.Wrap(errkind.Configuration, err)
+	}
+	if len(overrides) > 0 {
+		// Overrides skip the checks of the files, so check the result again
+		if problems := validateOverridden(config); len(problems) > 0 {
+			return nil, errkind.New(errkind.Configuration, "invalid config after overrides:\n%w", problems)
+		}
 	}
 
 	// Count total clusters across all accounts
@@ -116,7 +184,27 @@ func LoadConfig(configPath string) (*Config, error) {
 		"cluster", config.Cluster,
 		"namespace", config.Namespace)
 
-	return &config, nil
+	return config, nil
+}
+
+// LoadFileConfig loads the configuration at configPath without the overrides,
+// for commands that write settings back to the configuration file. The path
+// may be a file, with the files it includes, a config directory or
+// cluster://<context>/<namespace>.
+func LoadFileConfig(configPath string) (*Config, error) {
+	// Decode strictly, so misspelt keys and invalid values are not ignored
+	config, problems, err := validatePath(newConfigLoader(), configPath)
+	if err != nil {
+		// Missing files and unreachable clusters keep their own kind
+		if errkind.Classify(err) != errkind.Unknown {
+			return nil, err
+		}
+		return nil, errkind.Wrap(errkind.Configuration, err)
+	}
+	if len(problems) > 0 {
+		return nil, errkind.New(errkind.Configuration, "invalid config:\n%w", problems)
+	}
+	return config, nil
 }
 
 func (c *Config) GetEnabledClusters() []string {
@@ -177,6 +265,17 @@ func (c *Config) GetCluster(clusterName string) *Cluster {
 	return nil
 }
 
+// GetClusterEnvironment returns the environment of a cluster, the name of the
+// account holding it, or "" when no account does
+func (c *Config) GetClusterEnvironment(clusterName string) string {
+	for accountName, account := range c.Accounts {
+		if _, exists := account.Clusters[clusterName]; exists {
+			return accountName
+		}
+	}
+	return ""
+}
+
 // GetEnabledNamespacesForCluster returns enabled namespaces for a cluster
 func (c *Config) GetEnabledNamespacesForCluster(clusterName string) []Namespace {
 	cluster := c.GetCluster(clusterName)
@@ -6,6 +6,11 @@ import (
 	"testing"
 )
 
+// testAccounts puts clusters in a single account
+func testAccounts(clusters map[string]Cluster) map[string]Account {
+	return map[string]Account{"default": {Clusters: clusters}}
+}
+
 func TestLoadConfig(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -16,24 +21,26 @@ func TestLoadConfig(t *testing.T) {
 		{
 			name: "valid config with all fields",
 			configYAML: `
-clusters:
-  prod01:
-    default: true
-    enabled: true
-    target: prod-cluster
-    source: prod-source
-    aws_profile: prod-profile
-    aws_region: us-west-2
-    namespaces:
-      default:
+accounts:
+  default:
+    clusters:
+      prod01:
+        default: true
         enabled: true
-        name: default
-      monitoring:
+        target: prod-cluster
+        source: prod-source
+        aws_profile: prod-profile
+        aws_region: us-west-2
+        namespaces:
+          default:
+            enabled: true
+            name: default
+          monitoring:
+            enabled: false
+            name: monitoring
+      staging:
         enabled: false
-        name: monitoring
-  staging:
-    enabled: false
-    target: staging-cluster
+        target: staging-cluster
 services:
   api:
     enabled: true
@@ -43,18 +50,16 @@ services:
     name: web-service
 cluster: prod01
 namespace: default
-excludeKeys:
-  sensitive: true
-  internal: true
 `,
 			wantErr: false,
 			validateFn: func(t *testing.T, cfg *Config) {
 				// Validate clusters
-				if len(cfg.Clusters) != 2 {
-					t.Errorf("expected 2 clusters, got %d", len(cfg.Clusters))
+				clusters := cfg.Accounts["default"].Clusters
+				if len(clusters) != 2 {
+					t.Errorf("expected 2 clusters, got %d", len(clusters))
 				}
 
-				prod := cfg.Clusters["prod01"]
+				prod := clusters["prod01"]
 				if !prod.Default {
 					t.Error("prod01 should be default")
 				}
@@ -87,17 +92,19 @@ excludeKeys:
 		{
 			name: "minimal valid config",
 			configYAML: `
-clusters:
-  local:
-    enabled: true
+accounts:
+  default:
+    clusters:
+      local:
+        enabled: true
 services:
   test:
     enabled: true
 `,
 			wantErr: false,
 			validateFn: func(t *testing.T, cfg *Config) {
-				if len(cfg.Clusters) != 1 {
-					t.Errorf("expected 1 cluster, got %d", len(cfg.Clusters))
+				if len(cfg.Accounts["default"].Clusters) != 1 {
+					t.Errorf("expected 1 cluster, got %d", len(cfg.Accounts["default"].Clusters))
 				}
 				if len(cfg.Services) != 1 {
 					t.Errorf("expected 1 service, got %d", len(cfg.Services))
@@ -162,21 +169,21 @@ func TestConfig_GetEnabledClusters(t *testing.T) {
 		{
 			name: "multiple enabled clusters",
 			config: &Config{
-				Clusters: map[string]Cluster{
+				Accounts: testAccounts(map[string]Cluster{
 					"prod":    {Enabled: true},
 					"staging": {Enabled: false},
 					"dev":     {Enabled: true},
-				},
+				}),
 			},
 			expected: []string{"prod", "dev"},
 		},
 		{
 			name: "no enabled clusters",
 			config: &Config{
-				Clusters: map[string]Cluster{
+				Accounts: testAccounts(map[string]Cluster{
 					"prod":    {Enabled: false},
 					"staging": {Enabled: false},
-				},
+				}),
 			},
 			expected: []string{},
 		},
@@ -287,20 +294,15 @@ func TestConfig_GetEnabledNamespaces(t *testing.T) {
 		{
 			name: "cluster with multiple namespaces",
 			config: &Config{
-				Clusters: map[string]Cluster{
+				Accounts: testAccounts(map[string]Cluster{
 					"prod": {
-						Environments: map[string]Environment{
-							"production": {
-								Enabled: true,
-								Namespaces: map[string]Namespace{
-									"default":    {Enabled: true, Name: "default"},
-									"monitoring": {Enabled: false, Name: "monitoring"},
-									"logging":    {Enabled: true, Name: "logging"},
-								},
-							},
+						Namespaces: map[string]Namespace{
+							"default":    {Enabled: true, Name: "default"},
+							"monitoring": {Enabled: false, Name: "monitoring"},
+							"logging":    {Enabled: true, Name: "logging"},
 						},
 					},
-				},
+				}),
 			},
 			clusterName: "prod",
 			expected:    []string{"default", "logging"},
@@ -308,19 +310,14 @@ func TestConfig_GetEnabledNamespaces(t *testing.T) {
 		{
 			name: "cluster with no enabled namespaces",
 			config: &Config{
-				Clusters: map[string]Cluster{
+				Accounts: testAccounts(map[string]Cluster{
 					"staging": {
-						Environments: map[string]Environment{
-							"staging": {
-								Enabled: true,
-								Namespaces: map[string]Namespace{
-									"default": {Enabled: false, Name: "default"},
-									"test":    {Enabled: false, Name: "test"},
-								},
-							},
+						Namespaces: map[string]Namespace{
+							"default": {Enabled: false, Name: "default"},
+							"test":    {Enabled: false, Name: "test"},
 						},
 					},
-				},
+				}),
 			},
 			clusterName: "staging",
 			expected:    []string{},
@@ -328,9 +325,9 @@ func TestConfig_GetEnabledNamespaces(t *testing.T) {
 		{
 			name: "non-existent cluster",
 			config: &Config{
-				Clusters: map[string]Cluster{
+				Accounts: testAccounts(map[string]Cluster{
 					"prod": {},
-				},
+				}),
 			},
 			clusterName: "non-existent",
 			expected:    nil,
@@ -338,11 +335,11 @@ func TestConfig_GetEnabledNamespaces(t *testing.T) {
 		{
 			name: "cluster with nil namespaces",
 			config: &Config{
-				Clusters: map[string]Cluster{
+				Accounts: testAccounts(map[string]Cluster{
 					"dev": {
 						Enabled: true,
 					},
-				},
+				}),
 			},
 			clusterName: "dev",
 			expected:    []string{},
@@ -394,10 +391,10 @@ func TestConfig_GetDefaultCluster(t *testing.T) {
 		{
 			name: "has default cluster",
 			config: &Config{
-				Clusters: map[string]Cluster{
+				Accounts: testAccounts(map[string]Cluster{
 					"prod":    {Default: true, Enabled: true, Target: "prod-target"},
 					"staging": {Default: false, Enabled: true},
-				},
+				}),
 			},
 			expectedName:   "prod",
 			expectedExists: true,
@@ -405,10 +402,10 @@ func TestConfig_GetDefaultCluster(t *testing.T) {
 		{
 			name: "no default cluster",
 			config: &Config{
-				Clusters: map[string]Cluster{
+				Accounts: testAccounts(map[string]Cluster{
 					"prod":    {Default: false},
 					"staging": {Default: false},
-				},
+				}),
 			},
 			expectedName:   "",
 			expectedExists: false,
@@ -416,16 +413,16 @@ func TestConfig_GetDefaultCluster(t *testing.T) {
 		{
 			name: "multiple defaults (first wins)",
 			config: &Config{
-				Clusters: map[string]Cluster{
+				Accounts: testAccounts(map[string]Cluster{
 					"prod":    {Default: true},
 					"staging": {Default: true},
-				},
+				}),
 			},
 			expectedExists: true, // One of them will be returned
 		},
 		{
 			name:           "empty clusters",
-			config:         &Config{Clusters: map[string]Cluster{}},
+			config:         &Config{Accounts: testAccounts(map[string]Cluster{})},
 			expectedName:   "",
 			expectedExists: false,
 		},
@@ -465,13 +462,8 @@ func TestCluster_Properties(t *testing.T) {
 		Source:     "prod-source",
 		AWSProfile: "prod-profile",
 		AWSRegion:  "us-west-2",
-		Environments: map[string]Environment{
-			"production": {
-				Enabled: true,
-				Namespaces: map[string]Namespace{
-					"default": {Enabled: true, Name: "default"},
-				},
-			},
+		Namespaces: map[string]Namespace{
+			"default": {Enabled: true, Name: "default"},
 		},
 	}
 
@@ -0,0 +1,43 @@
+package config
+
+// GetConverterConfig returns the key converter settings of a service: the
+// global ones with the service's converter settings applied on top. Preserved
+// keys and skipped paths are added to the global lists; other settings replace
+// the global ones when set.
+func (c *Config) GetConverterConfig(serviceName string) ConverterConfig {
+	result := c.Globals.Converter
+	result.PreserveKeys = append([]string(nil), result.PreserveKeys...)
+	result.SkipPaths = append([]string(nil), result.SkipPaths...)
+
+	service, ok := c.Services[serviceName]
+	if !ok || service.Converter == nil {
+		return result
+	}
+	override := service.Converter
+
+	result.SkipJavaProperties = result.SkipJavaProperties || override.SkipJavaProperties
+	result.SkipUppercaseKeys = result.SkipUppercaseKeys || override.SkipUppercaseKeys
+	if override.MinUppercaseChars > 0 {
+		result.MinUppercaseChars = override.MinUppercaseChars
+	}
+	if override.CollisionPolicy != "" {
+		result.CollisionPolicy = override.CollisionPolicy
+	}
+	if override.TargetStyle != "" {
+		result.TargetStyle = override.TargetStyle
+	}
+	result.PreserveKeys = appendUnique(result.PreserveKeys, override.PreserveKeys...)
+	result.SkipPaths = appendUnique(result.SkipPaths, override.SkipPaths...)
+
+	return result
+}
+
+// appendUnique appends the values not already in the slice
+func appendUnique(slice []string, values ...string) []string {
+	for _, value := range values {
+		if !contains(slice, value) {
+			slice = append(slice, value)
+		}
+	}
+	return slice
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_GetConverterConfig(t *testing.T) {
+	cfg := &Config{
+		Globals: Globals{
+			Converter: ConverterConfig{
+				SkipJavaProperties: true,
+				MinUppercaseChars:  3,
+				CollisionPolicy:    "fail",
+				PreserveKeys:       []string{"AWS_REGION"},
+				SkipPaths:          []string{"configMap.*"},
+			},
+		},
+		Services: map[string]Service{
+			"heimdall": {
+				Converter: &ConverterConfig{
+					CollisionPolicy: "keep-camel",
+					TargetStyle:     "snake_case",
+					PreserveKeys:    []string{"AWS_REGION", "JAVA_OPTS"},
+					SkipPaths:       []string{"env.*"},
+				},
+			},
+			"livecomments": {},
+		},
+	}
+
+	t.Run("service override", func(t *testing.T) {
+		converter := cfg.GetConverterConfig("heimdall")
+		assert.True(t, converter.SkipJavaProperties)
+		assert.Equal(t, 3, converter.MinUppercaseChars)
+		assert.Equal(t, "keep-camel", converter.CollisionPolicy)
+		assert.Equal(t, "snake_case", converter.TargetStyle)
+		assert.Equal(t, []string{"AWS_REGION", "JAVA_OPTS"}, converter.PreserveKeys)
+		assert.Equal(t, []string{"configMap.*", "env.*"}, converter.SkipPaths)
+	})
+
+	t.Run("globals only", func(t *testing.T) {
+		assert.Equal(t, cfg.Globals.Converter, cfg.GetConverterConfig("livecomments"))
+		assert.Equal(t, cfg.Globals.Converter, cfg.GetConverterConfig("unknown"))
+	})
+
+	t.Run("globals are not modified", func(t *testing.T) {
+		cfg.GetConverterConfig("heimdall")
+		assert.Equal(t, []string{"configMap.*"}, cfg.Globals.Converter.SkipPaths)
+		assert.Equal(t, "fail", cfg.Globals.Converter.CollisionPolicy)
+	})
+}
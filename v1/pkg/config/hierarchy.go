@@ -14,6 +14,11 @@ import (
 type ConfigLayer struct {
 	Name   string
 	Values map[string]interface{}
+	// Sources holds, per value path, the layers that set it, oldest first.
+	// Only effective configurations track them.
+	Sources map[string][]ValueSource
+	// lists holds the merged lists of Sources by path, see mergedLists
+	lists map[string]interface{}
 }
 
 // Clone creates a deep copy of the config layer
@@ -28,10 +33,23 @@ func (c *ConfigLayer) Clone() *ConfigLayer {
 		Name:   c.Name,
 		Values: deepCopyMap(c.Values),
 	}
+	if c.Sources != nil {
+		cloned.Sources = make(map[string][]ValueSource, len(c.Sources))
+		for path, sources := range c.Sources {
+			cloned.Sources[path] = append([]ValueSource(nil), sources...)
+		}
+	}
+	if c.lists != nil {
+		cloned.lists = make(map[string]interface{}, len(c.lists))
+		for path, list := range c.lists {
+			cloned.lists[path] = list
+		}
+	}
 	return cloned
 }
 
-// Merge merges another config layer into this one
+// Merge merges another config layer into this one, recording it as the
+// source of its values when this layer tracks sources
 func (c *ConfigLayer) Merge(other *ConfigLayer) {
 	if other == nil || other.Values == nil {
 		return
@@ -41,6 +59,13 @@ func (c *ConfigLayer) Merge(other *ConfigLayer) {
 		c.Values = make(map[string]interface{})
 	}
 
+	if c.Sources != nil {
+		if c.lists == nil {
+			c.lists = make(map[string]interface{})
+		}
+		c.mergeTracked(c.Values, other.Values, "", nil, other.Name)
+		return
+	}
 	mergeMapRecursive(c.Values, other.Values)
 }
 
@@ -118,10 +143,13 @@ func (h *HierarchicalConfig) SetServiceConfig(service string, values map[string]
 	}
 }
 
-// GetEffectiveConfig returns the effective configuration after applying the override chain
+// GetEffectiveConfig returns the effective configuration after applying the
+// override chain, with the layers that set each value in Sources
 func (h *HierarchicalConfig) GetEffectiveConfig(cluster, env, namespace, service string) *ConfigLayer {
 	// Start with defaults
 	effective := h.defaults.Clone()
+	effective.Sources = make(map[string][]ValueSource)
+	recordSources(effective.Values, "", h.defaults.Name, effective.Sources)
 
 	// Apply override chain
 	layers := []struct {
@@ -1,5 +1,12 @@
 package config
 
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
 // AutoInjectFile represents auto-injection configuration for a specific file pattern
 type AutoInjectFile struct {
 	Keys []AutoInjectKey `yaml:"keys"`
@@ -13,6 +20,44 @@ type AutoInjectKey struct {
 	Description string `yaml:"description"`
 }
 
+// Path splits the key into its segments. Segments are separated by dots and
+// may be double-quoted to contain dots: secrets."root.properties".password
+func (k AutoInjectKey) Path() ([]string, error) {
+	var segments []string
+	rest := k.Key
+	for rest != "" {
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auto-inject key %q", k.Key)
+			}
+			segment, _ := strconv.Unquote(quoted)
+			segments = append(segments, segment)
+			rest = rest[len(quoted):]
+		} else {
+			end := strings.IndexByte(rest, '.')
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid auto-inject key %q", k.Key)
+			}
+			segments = append(segments, rest[:end])
+			rest = rest[end:]
+		}
+		if rest != "" {
+			if !strings.HasPrefix(rest, ".") || len(rest) == 1 {
+				return nil, fmt.Errorf("invalid auto-inject key %q", k.Key)
+			}
+			rest = rest[1:]
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid auto-inject key %q", k.Key)
+	}
+	return segments, nil
+}
+
 // InjectionCondition represents when to apply an injection
 type InjectionCondition string
 
@@ -48,3 +93,25 @@ func (c InjectionCondition) ShouldApply(keyExists bool) bool {
 		return false
 	}
 }
+
+// AutoInjectRulesFor returns the auto-inject rules whose file pattern matches
+// the path, in pattern order, with placeholders in their values expanded
+func (s *Service) AutoInjectRulesFor(appPath AppPath) []AutoInjectKey {
+	patterns := make([]string, 0, len(s.AutoInject))
+	for pattern := range s.AutoInject {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	var rules []AutoInjectKey
+	for _, pattern := range patterns {
+		if !appPath.Matches(pattern) {
+			continue
+		}
+		for _, rule := range s.AutoInject[pattern].Keys {
+			rule.Value = appPath.Expand(rule.Value)
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
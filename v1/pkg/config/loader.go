@@ -1,52 +1,83 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 
+	"helm-charts-migrator/v1/pkg/kubernetes"
 	"helm-charts-migrator/v1/pkg/logger"
-	yaml "github.com/elioetibr/golang-yaml-advanced"
 )
 
+// defaultAccount holds the clusters of a clusters directory that no account has
+const defaultAccount = "default"
+
+// Sections of the configuration a file or ConfigMap can hold on its own
+const (
+	sectionConfig  = "config"
+	sectionGlobals = "globals"
+	sectionCluster = "cluster"
+	sectionService = "service"
+)
+
+// subdirSections maps the subdirectories of a configuration directory to the
+// section their files hold
+var subdirSections = map[string]string{
+	"globals":  sectionGlobals,
+	"clusters": sectionCluster,
+	"services": sectionService,
+}
+
 // ConfigLoader handles loading and merging configurations
 type ConfigLoader interface {
 	LoadFromFile(path string) (*Config, error)
 	LoadFromDirectory(dir string) (*Config, error)
-	LoadFromCluster(context string) (*Config, error)
+	LoadFromCluster(kubeContext, namespace string) (*Config, error)
 	MergeConfigs(configs ...*Config) *Config
 	LoadHierarchicalConfig(baseDir string) (*HierarchicalConfig, error)
 }
 
 // configLoader implements ConfigLoader
 type configLoader struct {
-	log *logger.NamedLogger
+	log       *logger.NamedLogger
+	newClient func(kubeContext string) (*kubernetes.Client, error)
+	// baseCharts checks that base chart paths exist, which only migrate and
+	// config validate need
+	baseCharts bool
 }
 
 // NewConfigLoader creates a new ConfigLoader
 func NewConfigLoader() ConfigLoader {
+	return newConfigLoader()
+}
+
+// newConfigLoader creates a configLoader
+func newConfigLoader() *configLoader {
 	return &configLoader{
 		log: logger.WithName("config-loader"),
+		newClient: func(kubeContext string) (*kubernetes.Client, error) {
+			return kubernetes.NewClient(kubernetes.ClientOptions{Context: kubeContext})
+		},
 	}
 }
 
-// LoadFromFile loads configuration from a single file
+// LoadFromFile loads configuration from a single file, merging the files it
+// includes before its own settings
 func (c *configLoader) LoadFromFile(path string) (*Config, error) {
 	c.log.V(2).InfoS("Loading config from file", "path", path)
 
-	data, err := os.ReadFile(path)
+	config, err := c.loadFile(path, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
-	}
-
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		return nil, err
 	}
 
 	// Apply defaults
-	c.applyDefaults(&config)
+	c.applyDefaults(config)
 
 	// Count total clusters across all accounts
 	totalClusters := 0
@@ -59,82 +90,203 @@ func (c *configLoader) LoadFromFile(path string) (*Config, error) {
 		"clusters", totalClusters,
 		"services", len(config.Services))
 
-	return &config, nil
+	return config, nil
+}
+
+// loadFile decodes a configuration file strictly and resolves its includes.
+// Included files are merged in order, then the file's own settings on top.
+// stack holds the files including this one, to detect cycles.
+func (c *configLoader) loadFile(path string, stack []string) (*Config, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config path: %w", err)
+	}
+	for _, including := range stack {
+		if including == absPath {
+			return nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), absPath)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	config, problems := c.validateData(path, data)
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	if len(config.Include) == 0 {
+		return config, nil
+	}
+
+	stack = append(stack, absPath)
+	var includes []*Config
+	for _, include := range config.Include {
+		included, err := c.loadInclude(filepath.Dir(path), include, stack)
+		if err != nil {
+			return nil, fmt.Errorf("failed to include %s from %s: %w", include, path, err)
+		}
+		includes = append(includes, included...)
+	}
+	config.Include = nil
+	return c.MergeConfigs(append(includes, config)...), nil
+}
+
+// loadInclude loads an include entry: a file, a configuration directory or a
+// glob of files, relative to the including file
+func (c *configLoader) loadInclude(baseDir, include string, stack []string) ([]*Config, error) {
+	pattern := include
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(baseDir, pattern)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include pattern: %w", err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no config found at %s", pattern)
+	}
+
+	var configs []*Config
+	for _, match := range matches {
+		c.log.V(2).InfoS("Including config", "path", match)
+		var config *Config
+		if info, statErr := os.Stat(match); statErr == nil && info.IsDir() {
+			config, err = c.loadDirectory(match, stack)
+		} else {
+			config, err = c.loadFile(match, stack)
+		}
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
 }
 
-// LoadFromDirectory loads all config files from a directory
+// LoadFromDirectory loads a configuration split into files. The YAML files of
+// the directory hold whole configurations and are merged in name order, then
+// the files of its subdirectories hold one section each:
+//
+//	globals/*.yaml          settings of globals
+//	clusters/<name>.yaml    a cluster, added to the account that already has it or to "default"
+//	services/<name>.yaml    a service
 func (c *configLoader) LoadFromDirectory(dir string) (*Config, error) {
 	c.log.V(2).InfoS("Loading configs from directory", "dir", dir)
 
+	merged, err := c.loadDirectory(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.applyDefaults(merged)
+
+	// Count total clusters
+	totalClusters := 0
+	for _, account := range merged.Accounts {
+		totalClusters += len(account.Clusters)
+	}
+
+	c.log.InfoS("Loaded configuration from directory",
+		"dir", dir,
+		"accounts", len(merged.Accounts),
+		"clusters", totalClusters,
+		"services", len(merged.Services))
+
+	return merged, nil
+}
+
+// loadDirectory loads and merges the files of a configuration directory. The
+// problems of every file are returned together.
+func (c *configLoader) loadDirectory(dir string, stack []string) (*Config, error) {
 	// Start with empty config
 	merged := &Config{
 		Accounts: make(map[string]Account),
 		Services: make(map[string]Service),
 	}
 
-	// Find all YAML files
-	pattern := filepath.Join(dir, "*.yaml")
-	files, err := filepath.Glob(pattern)
+	files, err := listConfigFiles(dir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list config files: %w", err)
-	}
-
-	// Also check for .yml files
-	pattern = filepath.Join(dir, "*.yml")
-	ymlFiles, err := filepath.Glob(pattern)
-	if err == nil {
-		files = append(files, ymlFiles...)
+		return nil, err
 	}
 
 	// Load and merge each file
+	var problems ValidationErrors
 	for _, file := range files {
 		c.log.V(3).InfoS("Loading config file", "file", file)
 
-		cfg, err := c.LoadFromFile(file)
-		if err != nil {
-			c.log.Error(err, "Failed to load config file", "file", file)
+		cfg, err := c.loadFile(file, stack)
+		var fileProblems ValidationErrors
+		if errors.As(err, &fileProblems) {
+			problems = append(problems, fileProblems...)
 			continue
 		}
+		if err != nil {
+			return nil, err
+		}
 
 		merged = c.MergeConfigs(merged, cfg)
 	}
 
 	// Check subdirectories for specific configs
-	subdirs := []string{"clusters", "services", "environments"}
+	subdirs := []string{"globals", "clusters", "services"}
 	for _, subdir := range subdirs {
 		subdirPath := filepath.Join(dir, subdir)
 		if info, err := os.Stat(subdirPath); err == nil && info.IsDir() {
 			subdirConfig, err := c.loadSubdirectoryConfigs(subdirPath, subdir)
-			if err != nil {
-				c.log.Error(err, "Failed to load subdirectory configs", "subdir", subdirPath)
+			var subdirProblems ValidationErrors
+			if errors.As(err, &subdirProblems) {
+				problems = append(problems, subdirProblems...)
 				continue
 			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to load %s configs: %w", subdir, err)
+			}
+			assignClusterAccounts(merged, subdirConfig)
 			merged = c.MergeConfigs(merged, subdirConfig)
 		}
 	}
 
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return merged, nil
+}
+
+// LoadFromCluster loads configuration from the ConfigMaps of a namespace
+// labelled with ConfigMapLabel. ConfigMaps labelled config hold whole
+// configurations; globals, cluster and service ones hold a section, named by
+// ConfigMapNameLabel or the ConfigMap name. They are merged in that order.
+func (c *configLoader) LoadFromCluster(kubeContext, namespace string) (*Config, error) {
+	c.log.V(2).InfoS("Loading config from cluster", "context", kubeContext, "namespace", namespace)
+
+	client, err := c.newClient(kubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	config, err := c.loadConfigMaps(context.Background(), client, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Accounts) == 0 && len(config.Services) == 0 && reflect.ValueOf(config.Globals).IsZero() {
+		return nil, fmt.Errorf("no config maps labelled %s in %s/%s", ConfigMapLabel, kubeContext, namespace)
+	}
+	c.applyDefaults(config)
+
 	// Count total clusters
 	totalClusters := 0
-	for _, account := range merged.Accounts {
+	for _, account := range config.Accounts {
 		totalClusters += len(account.Clusters)
 	}
 
-	c.log.InfoS("Loaded configuration from directory",
-		"dir", dir,
-		"accounts", len(merged.Accounts),
+	c.log.InfoS("Loaded configuration from cluster",
+		"context", kubeContext,
+		"namespace", namespace,
+		"accounts", len(config.Accounts),
 		"clusters", totalClusters,
-		"services", len(merged.Services))
-
-	return merged, nil
-}
-
-// LoadFromCluster loads configuration from a Kubernetes cluster
-func (c *configLoader) LoadFromCluster(context string) (*Config, error) {
-	c.log.V(2).InfoS("Loading config from cluster", "context", context)
+		"services", len(config.Services))
 
-	// This would connect to the cluster and load ConfigMaps/Secrets
-	// For now, return an error indicating it's not implemented
-	return nil, fmt.Errorf("loading config from cluster not yet implemented")
+	return config, nil
 }
 
 // MergeConfigs merges multiple configurations together
@@ -173,6 +325,13 @@ func (c *configLoader) MergeConfigs(configs ...*Config) *Config {
 		// Merge globals
 		merged.Globals = c.mergeGlobals(merged.Globals, cfg.Globals)
 
+		if cfg.Cluster != "" {
+			merged.Cluster = cfg.Cluster
+		}
+		if cfg.Namespace != "" {
+			merged.Namespace = cfg.Namespace
+		}
+
 		// Merge accounts and their clusters
 		for accountName, account := range cfg.Accounts {
 			if existingAccount, exists := merged.Accounts[accountName]; exists {
@@ -269,97 +428,186 @@ func (c *configLoader) applyDefaults(config *Config) {
 	}
 }
 
+// loadSubdirectoryConfigs loads the files of a globals, clusters or services
+// directory, each holding one section of the configuration
 func (c *configLoader) loadSubdirectoryConfigs(dir, configType string) (*Config, error) {
 	merged := &Config{
 		Accounts: make(map[string]Account),
 		Services: make(map[string]Service),
 	}
 
-	// Find all YAML files in subdirectory
-	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	files, err := listConfigFiles(dir)
 	if err != nil {
 		return nil, err
 	}
 
-	ymlFiles, err := filepath.Glob(filepath.Join(dir, "*.yml"))
-	if err == nil {
-		files = append(files, ymlFiles...)
-	}
-
+	var problems ValidationErrors
 	for _, file := range files {
 		name := getNameFromFile(file)
 
 		data, err := os.ReadFile(file)
 		if err != nil {
-			c.log.Error(err, "Failed to read file", "file", file)
-			continue
+			return nil, fmt.Errorf("failed to read config file: %w", err)
 		}
 
-		switch configType {
-		case "clusters":
-			var cluster Cluster
-			if err := yaml.Unmarshal(data, &cluster); err != nil {
-				c.log.Error(err, "Failed to unmarshal cluster config", "file", file)
-				continue
-			}
-			// Add to default account for backward compatibility
-			if _, exists := merged.Accounts["default"]; !exists {
-				merged.Accounts["default"] = Account{
-					Clusters: make(map[string]Cluster),
+		v := c.newValidator(file)
+		if section, ok := c.decodeSection(v, subdirSections[configType], name, defaultAccount, data); ok {
+			merged = c.MergeConfigs(merged, section)
+		}
+		problems = append(problems, v.result()...)
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return merged, nil
+}
+
+// decodeSection decodes data holding one section of the configuration, the
+// globals, a cluster of account or a service, into a partial configuration
+func (c *configLoader) decodeSection(v *configValidator, section, name, account string, data []byte) (*Config, bool) {
+	switch section {
+	case sectionGlobals:
+		var globals Globals
+		if !v.decode(data, &globals, "globals") {
+			return nil, false
+		}
+		cfg := &Config{Globals: globals}
+		v.checkConfig(cfg)
+		return cfg, true
+
+	case sectionCluster:
+		var cluster Cluster
+		if !v.decode(data, &cluster, joinConfigPath(joinConfigPath("accounts", account)+".clusters", name)) {
+			return nil, false
+		}
+		return &Config{
+			Accounts: map[string]Account{
+				account: {Clusters: map[string]Cluster{name: cluster}},
+			},
+		}, true
+
+	case sectionService:
+		var service Service
+		if !v.decode(data, &service, joinConfigPath("services", name)) {
+			return nil, false
+		}
+		if service.Name == "" {
+			service.Name = name
+		}
+		if service.Capitalized == "" {
+			service.Capitalized = c.capitalize(name)
+		}
+		cfg := &Config{Services: map[string]Service{name: service}}
+		v.markEnabledServices(cfg)
+		v.checkConfig(cfg)
+		return cfg, true
+	}
+
+	v.add("", "unknown configuration section %q, expected %s, %s or %s", section, sectionGlobals, sectionCluster, sectionService)
+	return nil, false
+}
+
+// assignClusterAccounts moves the clusters of a clusters directory, loaded
+// into the default account, to the account that already has them
+func assignClusterAccounts(base, clusters *Config) {
+	loaded, ok := clusters.Accounts[defaultAccount]
+	if !ok {
+		return
+	}
+	for name, cluster := range loaded.Clusters {
+		for accountName, account := range base.Accounts {
+			if _, exists := account.Clusters[name]; exists && accountName != defaultAccount {
+				if _, ok := clusters.Accounts[accountName]; !ok {
+					clusters.Accounts[accountName] = Account{Clusters: make(map[string]Cluster)}
 				}
+				clusters.Accounts[accountName].Clusters[name] = cluster
+				delete(loaded.Clusters, name)
+				break
 			}
-			account := merged.Accounts["default"]
-			account.Clusters[name] = cluster
-			merged.Accounts["default"] = account
-
-		case "services":
-			var service Service
-			if err := yaml.Unmarshal(data, &service); err != nil {
-				c.log.Error(err, "Failed to unmarshal service config", "file", file)
-				continue
-			}
-			if service.Name == "" {
-				service.Name = name
-			}
-			if service.Capitalized == "" {
-				service.Capitalized = c.capitalize(name)
-			}
-			merged.Services[name] = service
 		}
 	}
+	if len(loaded.Clusters) == 0 {
+		delete(clusters.Accounts, defaultAccount)
+	}
+}
 
-	return merged, nil
+// listConfigFiles returns the YAML files of a directory in name order
+func listConfigFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list config files: %w", err)
+	}
+	ymlFiles, err := filepath.Glob(filepath.Join(dir, "*.yml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list config files: %w", err)
+	}
+	files = append(files, ymlFiles...)
+	sort.Strings(files)
+	return files, nil
 }
 
 func (c *configLoader) mergeGlobals(base, override Globals) Globals {
 	// Start with base
 	result := base
 
-	// Override migration settings
-	if override.Migration.HelmValuesFilename != "" {
-		result.Migration.HelmValuesFilename = override.Migration.HelmValuesFilename
+	// Sections set as a whole replace the base
+	if !reflect.ValueOf(override.Pipeline).IsZero() {
+		result.Pipeline = override.Pipeline
 	}
-	if override.Migration.LegacyValuesFilename != "" {
-		result.Migration.LegacyValuesFilename = override.Migration.LegacyValuesFilename
+	if !reflect.ValueOf(override.SOPS).IsZero() {
+		result.SOPS = override.SOPS
 	}
-	if override.Migration.BaseValuesPath != "" {
-		result.Migration.BaseValuesPath = override.Migration.BaseValuesPath
+	if override.Mappings != nil {
+		result.Mappings = override.Mappings
 	}
-	if override.Migration.EnvValuesPattern != "" {
-		result.Migration.EnvValuesPattern = override.Migration.EnvValuesPattern
+	if override.Secrets != nil {
+		result.Secrets = override.Secrets
 	}
 
+	// Merge auto-inject rules by file pattern
+	if len(override.AutoInject) > 0 {
+		autoInject := make(map[string]AutoInjectFile, len(base.AutoInject)+len(override.AutoInject))
+		for pattern, file := range base.AutoInject {
+			autoInject[pattern] = file
+		}
+		for pattern, file := range override.AutoInject {
+			autoInject[pattern] = file
+		}
+		result.AutoInject = autoInject
+	}
+
+	// Override migration settings
+	result.Migration = mergeMigration(base.Migration, override.Migration)
+
 	// Override converter settings
 	if override.Converter.MinUppercaseChars > 0 {
 		result.Converter.MinUppercaseChars = override.Converter.MinUppercaseChars
 	}
 	result.Converter.SkipJavaProperties = override.Converter.SkipJavaProperties || base.Converter.SkipJavaProperties
 	result.Converter.SkipUppercaseKeys = override.Converter.SkipUppercaseKeys || base.Converter.SkipUppercaseKeys
+	if override.Converter.CollisionPolicy != "" {
+		result.Converter.CollisionPolicy = override.Converter.CollisionPolicy
+	}
+	if override.Converter.TargetStyle != "" {
+		result.Converter.TargetStyle = override.Converter.TargetStyle
+	}
+	result.Converter.PreserveKeys = appendUnique(append([]string(nil), base.Converter.PreserveKeys...), override.Converter.PreserveKeys...)
+	result.Converter.SkipPaths = appendUnique(append([]string(nil), base.Converter.SkipPaths...), override.Converter.SkipPaths...)
 
 	// Override performance settings
 	if override.Performance.MaxConcurrentServices > 0 {
 		result.Performance.MaxConcurrentServices = override.Performance.MaxConcurrentServices
 	}
+	result.Performance.ShowProgress = override.Performance.ShowProgress || base.Performance.ShowProgress
+
+	if override.ImportReferences != nil {
+		result.ImportReferences = override.ImportReferences
+	}
+
+	if override.ValuesSchema != nil {
+		result.ValuesSchema = override.ValuesSchema
+	}
 
 	return result
 }
@@ -407,6 +655,31 @@ func (c *configLoader) mergeService(base, override Service) Service {
 	if override.Capitalized != "" {
 		result.Capitalized = override.Capitalized
 	}
+	if override.Alias != "" {
+		result.Alias = override.Alias
+	}
+	if override.ServiceType != "" {
+		result.ServiceType = override.ServiceType
+	}
+	if override.ServiceTypeCapitalized != "" {
+		result.ServiceTypeCapitalized = override.ServiceTypeCapitalized
+	}
+	if override.GitRepo != "" {
+		result.GitRepo = override.GitRepo
+	}
+	if override.ParameterStore != "" {
+		result.ParameterStore = override.ParameterStore
+	}
+	if override.Mappings != nil {
+		result.Mappings = override.Mappings
+	}
+	// Enabled is only true when set, such as through a YAML merge key, which
+	// has no position of its own
+	if override.enabledSet || override.Enabled {
+		result.Enabled = override.Enabled
+		result.enabledSet = true
+	}
+	result.Migration = mergeMigration(base.Migration, override.Migration)
 
 	// Merge auto-inject rules
 	if len(override.AutoInject) > 0 {
@@ -418,8 +691,37 @@ func (c *configLoader) mergeService(base, override Service) Service {
 		result.Secrets = override.Secrets
 	}
 
-	result.Enabled = override.Enabled
+	if override.ImportReferences != nil {
+		result.ImportReferences = override.ImportReferences
+	}
+
+	if override.Converter != nil {
+		result.Converter = override.Converter
+	}
+
+	return result
+}
 
+// mergeMigration overrides the migration settings the override sets
+func mergeMigration(base, override Migration) Migration {
+	result := base
+	overrides := []struct{ value, target *string }{
+		{&override.LegacyHelmChartsPath, &result.LegacyHelmChartsPath},
+		{&override.LegacyEnvironmentManifestsPath, &result.LegacyEnvironmentManifestsPath},
+		{&override.LegacyOutputPath, &result.LegacyOutputPath},
+		{&override.Source, &result.Source},
+		{&override.Target, &result.Target},
+		{&override.BaseChartPath, &result.BaseChartPath},
+		{&override.BaseValuesPath, &result.BaseValuesPath},
+		{&override.EnvValuesPattern, &result.EnvValuesPattern},
+		{&override.HelmValuesFilename, &result.HelmValuesFilename},
+		{&override.LegacyValuesFilename, &result.LegacyValuesFilename},
+	}
+	for _, o := range overrides {
+		if *o.value != "" {
+			*o.target = *o.value
+		}
+	}
 	return result
 }
 
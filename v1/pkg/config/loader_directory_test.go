@@ -0,0 +1,225 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfigFiles writes files, by path relative to dir
+func writeConfigFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestConfigLoader_LoadFromDirectory_SplitLayout(t *testing.T) {
+	t.Run("sections are merged", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFiles(t, dir, map[string]string{
+			"config.yaml": `
+accounts:
+  prod:
+    clusters:
+      prod01:
+        enabled: true
+        source: legacy-prod
+globals:
+  performance:
+    maxConcurrentServices: 2
+`,
+			"globals/sops.yaml": `
+sops:
+  enabled: true
+  awsProfile: prod
+performance:
+  maxConcurrentServices: 8
+`,
+			"clusters/prod01.yaml": `
+enabled: true
+target: eks-prod
+`,
+			"clusters/dev01.yaml": `
+enabled: true
+target: eks-dev
+`,
+			"services/heimdall.yaml": `
+enabled: true
+parameterStore: heimdall-ps
+gitRepo: git@example.com:heimdall.git
+migration:
+  target: apps/heimdall
+`,
+		})
+
+		cfg, err := NewConfigLoader().LoadFromDirectory(dir)
+		require.NoError(t, err)
+
+		assert.True(t, cfg.Globals.SOPS.Enabled)
+		assert.Equal(t, "prod", cfg.Globals.SOPS.AwsProfile)
+		assert.Equal(t, 8, cfg.Globals.Performance.MaxConcurrentServices)
+
+		prod01 := cfg.Accounts["prod"].Clusters["prod01"]
+		assert.Equal(t, "eks-prod", prod01.Target)
+		assert.Equal(t, "legacy-prod", prod01.Source)
+		assert.Equal(t, "eks-dev", cfg.Accounts[defaultAccount].Clusters["dev01"].Target)
+		assert.NotContains(t, cfg.Accounts[defaultAccount].Clusters, "prod01")
+
+		heimdall := cfg.Services["heimdall"]
+		assert.Equal(t, "heimdall", heimdall.Name)
+		assert.Equal(t, "Heimdall", heimdall.Capitalized)
+		assert.Equal(t, "heimdall-ps", heimdall.ParameterStore)
+		assert.Equal(t, "git@example.com:heimdall.git", heimdall.GitRepo)
+		assert.Equal(t, "apps/heimdall", heimdall.Migration.Target)
+		assert.Equal(t, "values.yaml", cfg.Globals.Migration.HelmValuesFilename)
+	})
+
+	t.Run("problems of every file are reported", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFiles(t, dir, map[string]string{
+			"globals/converter.yaml": `converter:
+  minUppercaseChars: many
+`,
+			"services/heimdall.yaml": `
+enabld: true
+`,
+		})
+
+		_, err := NewConfigLoader().LoadFromDirectory(dir)
+		var problems ValidationErrors
+		require.ErrorAs(t, err, &problems)
+		require.Len(t, problems, 2)
+
+		assert.Equal(t, filepath.Join(dir, "globals", "converter.yaml"), problems[0].File)
+		assert.Equal(t, "globals.converter.minUppercaseChars", problems[0].Path)
+		assert.Equal(t, 2, problems[0].Line)
+
+		assert.Equal(t, filepath.Join(dir, "services", "heimdall.yaml"), problems[1].File)
+		assert.Equal(t, "services.heimdall.enabld", problems[1].Path)
+		assert.Contains(t, problems[1].Message, `did you mean "enabled"?`)
+	})
+}
+
+func TestConfigLoader_Include(t *testing.T) {
+	t.Run("including file wins", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFiles(t, dir, map[string]string{
+			"config.yaml": `
+include:
+  - base.yaml
+  - teams/*.yaml
+globals:
+  sops:
+    awsProfile: override
+`,
+			"base.yaml": `
+globals:
+  sops:
+    enabled: true
+    awsProfile: base
+services:
+  heimdall:
+    enabled: false
+`,
+			"teams/heimdall.yaml": `
+services:
+  heimdall:
+    enabled: true
+    parameterStore: heimdall
+`,
+			"teams/auth.yaml": `
+services:
+  auth:
+    enabled: true
+`,
+		})
+
+		cfg, err := NewConfigLoader().LoadFromFile(filepath.Join(dir, "config.yaml"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "override", cfg.Globals.SOPS.AwsProfile)
+		assert.True(t, cfg.Services["heimdall"].Enabled)
+		assert.Equal(t, "heimdall", cfg.Services["heimdall"].ParameterStore)
+		assert.True(t, cfg.Services["auth"].Enabled)
+		assert.Empty(t, cfg.Include)
+	})
+
+	t.Run("enabled is kept unless set", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFiles(t, dir, map[string]string{
+			"config.yaml": `
+include: [base.yaml]
+services:
+  heimdall:
+    parameterStore: heimdall
+  auth:
+    enabled: false
+`,
+			"base.yaml": `
+services:
+  heimdall:
+    enabled: true
+  auth:
+    enabled: true
+`,
+		})
+
+		cfg, err := NewConfigLoader().LoadFromFile(filepath.Join(dir, "config.yaml"))
+		require.NoError(t, err)
+
+		assert.True(t, cfg.Services["heimdall"].Enabled)
+		assert.Equal(t, "heimdall", cfg.Services["heimdall"].ParameterStore)
+		assert.False(t, cfg.Services["auth"].Enabled)
+	})
+
+	t.Run("cycles are reported", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFiles(t, dir, map[string]string{
+			"a.yaml": "include: [b.yaml]\n",
+			"b.yaml": "include: [a.yaml]\n",
+		})
+
+		_, err := NewConfigLoader().LoadFromFile(filepath.Join(dir, "a.yaml"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "include cycle")
+	})
+
+	t.Run("missing include", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFiles(t, dir, map[string]string{
+			"config.yaml": "include: [missing.yaml]\n",
+		})
+
+		_, err := NewConfigLoader().LoadFromFile(filepath.Join(dir, "config.yaml"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no config found")
+	})
+}
+
+func TestValidatePath(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml": `
+accounts:
+  prod:
+    clusters:
+      prod01:
+        target: eks-prod
+`,
+		"clusters/prod02.yaml": "target: eks-prod\n",
+	})
+
+	_, problems, err := ValidatePath(dir)
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	assert.Contains(t, problems[0].Message, `target "eks-prod" is already used`)
+
+	_, _, err = ValidatePath(filepath.Join(dir, "missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -25,11 +25,13 @@ globals:
   performance:
     maxConcurrentServices: 10
     showProgress: true
-clusters:
-  prod01:
-    source: kops-prod
-    target: eks-prod
-    default: true
+accounts:
+  default:
+    clusters:
+      prod01:
+        source: kops-prod
+        target: eks-prod
+        default: true
 services:
   heimdall:
     enabled: true
@@ -52,7 +54,7 @@ services:
 	assert.Equal(t, 10, cfg.Globals.Performance.MaxConcurrentServices)
 	assert.True(t, cfg.Globals.Performance.ShowProgress)
 
-	cluster, exists := cfg.Clusters["prod01"]
+	cluster, exists := cfg.Accounts["default"].Clusters["prod01"]
 	require.True(t, exists)
 	assert.Equal(t, "kops-prod", cluster.Source)
 	assert.Equal(t, "eks-prod", cluster.Target)
@@ -73,10 +75,12 @@ func TestConfigLoader_LoadFromDirectory(t *testing.T) {
 globals:
   converter:
     minUppercaseChars: 3
-clusters:
-  dev01:
-    source: kops-dev
-    target: eks-dev
+accounts:
+  default:
+    clusters:
+      dev01:
+        source: kops-dev
+        target: eks-dev
 `
 	err := os.WriteFile(filepath.Join(tmpDir, "base.yaml"), []byte(baseConfig), 0644)
 	require.NoError(t, err)
@@ -87,11 +91,13 @@ globals:
   converter:
     minUppercaseChars: 5
     skipJavaProperties: true
-clusters:
-  prod01:
-    source: kops-prod
-    target: eks-prod
-    default: true
+accounts:
+  default:
+    clusters:
+      prod01:
+        source: kops-prod
+        target: eks-prod
+        default: true
 services:
   heimdall:
     enabled: true
@@ -109,10 +115,10 @@ services:
 	assert.True(t, cfg.Globals.Converter.SkipJavaProperties)
 
 	// Should have both clusters
-	assert.Len(t, cfg.Clusters, 2)
-	_, hasdev := cfg.Clusters["dev01"]
+	assert.Len(t, cfg.Accounts["default"].Clusters, 2)
+	_, hasdev := cfg.Accounts["default"].Clusters["dev01"]
 	assert.True(t, hasdev)
-	_, hasprod := cfg.Clusters["prod01"]
+	_, hasprod := cfg.Accounts["default"].Clusters["prod01"]
 	assert.True(t, hasprod)
 
 	// Service from override
@@ -131,12 +137,12 @@ func TestConfigLoader_MergeConfigs(t *testing.T) {
 				MinUppercaseChars: 3,
 			},
 		},
-		Clusters: map[string]Cluster{
+		Accounts: testAccounts(map[string]Cluster{
 			"dev01": {
 				Source: "kops-dev",
 				Target: "eks-dev",
 			},
-		},
+		}),
 		Services: map[string]Service{
 			"auth": {
 				Enabled: true,
@@ -155,13 +161,13 @@ func TestConfigLoader_MergeConfigs(t *testing.T) {
 				MaxConcurrentServices: 10,
 			},
 		},
-		Clusters: map[string]Cluster{
+		Accounts: testAccounts(map[string]Cluster{
 			"prod01": {
 				Source:  "kops-prod",
 				Target:  "eks-prod",
 				Default: true,
 			},
-		},
+		}),
 		Services: map[string]Service{
 			"heimdall": {
 				Enabled:     true,
@@ -180,15 +186,15 @@ func TestConfigLoader_MergeConfigs(t *testing.T) {
 	assert.Equal(t, 10, merged.Globals.Performance.MaxConcurrentServices)
 
 	// Maps are merged
-	assert.Len(t, merged.Clusters, 2)
+	assert.Len(t, merged.Accounts["default"].Clusters, 2)
 	assert.Len(t, merged.Services, 2)
 
 	// Verify individual entries
-	dev, exists := merged.Clusters["dev01"]
+	dev, exists := merged.Accounts["default"].Clusters["dev01"]
 	require.True(t, exists)
 	assert.Equal(t, "kops-dev", dev.Source)
 
-	prod, exists := merged.Clusters["prod01"]
+	prod, exists := merged.Accounts["default"].Clusters["prod01"]
 	require.True(t, exists)
 	assert.Equal(t, "kops-prod", prod.Source)
 	assert.True(t, prod.Default)
@@ -284,7 +290,7 @@ func TestConfigLoader_LoadFromCluster(t *testing.T) {
 	// This test would require mocking kubectl context
 	// For now, just test that it doesn't panic with invalid context
 	loader := NewConfigLoader()
-	cfg, err := loader.LoadFromCluster("non-existent-context")
+	cfg, err := loader.LoadFromCluster("non-existent-context", "default")
 	
 	// Should return error for non-existent context
 	assert.Error(t, err)
@@ -303,8 +309,6 @@ func TestApplyDefaults(t *testing.T) {
 			name:  "empty config gets defaults",
 			input: &Config{},
 			validate: func(t *testing.T, cfg *Config) {
-				assert.NotNil(t, cfg.Clusters)
-				assert.NotNil(t, cfg.Services)
 				assert.Equal(t, 3, cfg.Globals.Converter.MinUppercaseChars)
 			},
 		},
@@ -439,12 +443,12 @@ func TestValidateConfig(t *testing.T) {
 		{
 			name: "valid config",
 			config: &Config{
-				Clusters: map[string]Cluster{
+				Accounts: testAccounts(map[string]Cluster{
 					"prod01": {
 						Source: "kops-prod",
 						Target: "eks-prod",
 					},
-				},
+				}),
 				Services: map[string]Service{
 					"heimdall": {
 						Enabled: true,
@@ -456,11 +460,11 @@ func TestValidateConfig(t *testing.T) {
 		{
 			name: "missing source in cluster",
 			config: &Config{
-				Clusters: map[string]Cluster{
+				Accounts: testAccounts(map[string]Cluster{
 					"prod01": {
 						Target: "eks-prod",
 					},
-				},
+				}),
 			},
 			expectError: true,
 			errorMsg:    "source is required",
@@ -496,12 +500,14 @@ func TestValidateConfig(t *testing.T) {
 // Helper function for testing - might need to be implemented in actual code
 func validateConfig(cfg *Config) error {
 	// Basic validation
-	for name, cluster := range cfg.Clusters {
-		if cluster.Source == "" {
-			return fmt.Errorf("cluster %s: source is required", name)
-		}
-		if cluster.Target == "" {
-			return fmt.Errorf("cluster %s: target is required", name)
+	for _, account := range cfg.Accounts {
+		for name, cluster := range account.Clusters {
+			if cluster.Source == "" {
+				return fmt.Errorf("cluster %s: source is required", name)
+			}
+			if cluster.Target == "" {
+				return fmt.Errorf("cluster %s: target is required", name)
+			}
 		}
 	}
 	return nil
@@ -515,5 +521,5 @@ func TestConfigPaths(t *testing.T) {
 	require.NotNil(t, paths)
 
 	// Verify paths are correctly set for service
-	assert.Contains(t, paths.TargetPath, "heimdall")
+	assert.Equal(t, filepath.Join("/target", "heimdall"), paths.ServiceDir())
 }
\ No newline at end of file
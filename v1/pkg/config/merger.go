@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 
 	"helm-charts-migrator/v1/pkg/logger"
 )
@@ -57,9 +59,10 @@ func (cm *ConfigurationMerger) mergeAutoInject(service *Service, globalAutoInjec
 		return diffs
 	}
 
-	if service.AutoInject == nil {
-		service.AutoInject = make(map[string]AutoInjectFile)
-	}
+	// Work on a copy, the service's map belongs to the configuration
+	autoInject := make(map[string]AutoInjectFile, len(service.AutoInject)+len(globalAutoInject))
+	maps.Copy(autoInject, service.AutoInject)
+	service.AutoInject = autoInject
 
 	for path, globalInject := range globalAutoInject {
 		if _, exists := service.AutoInject[path]; !exists {
@@ -77,7 +80,7 @@ func (cm *ConfigurationMerger) mergeAutoInject(service *Service, globalAutoInjec
 				}
 				if !found {
 					file := service.AutoInject[path]
-					file.Keys = append(file.Keys, globalKey)
+					file.Keys = append(slices.Clone(file.Keys), globalKey)
 					service.AutoInject[path] = file
 					diffs = append(diffs, fmt.Sprintf("autoInject.%s.%s: added from global", path, globalKey.Key))
 				}
@@ -119,9 +122,18 @@ func (cm *ConfigurationMerger) mergeSecrets(service *Service, globalSecrets *Sec
 		return diffs
 	}
 
-	if service.Secrets == nil {
-		service.Secrets = &Secrets{}
+	// Work on a copy, the service's secrets belong to the configuration
+	secrets := &Secrets{}
+	if service.Secrets != nil {
+		copied := *service.Secrets
+		copied.Patterns = slices.Clone(copied.Patterns)
+		copied.Keys = slices.Clone(copied.Keys)
+		copied.Exclusions = slices.Clone(copied.Exclusions)
+		copied.UUIDs = slices.Clone(copied.UUIDs)
+		copied.Values = slices.Clone(copied.Values)
+		secrets = &copied
 	}
+	service.Secrets = secrets
 
 	// Merge patterns from global secrets
 	for _, pattern := range globalSecrets.Patterns {
@@ -139,6 +151,14 @@ func (cm *ConfigurationMerger) mergeSecrets(service *Service, globalSecrets *Sec
 		}
 	}
 
+	// Merge exclusions from global secrets
+	for _, key := range globalSecrets.Exclusions {
+		if !contains(service.Secrets.Exclusions, key) {
+			service.Secrets.Exclusions = append(service.Secrets.Exclusions, key)
+			diffs = append(diffs, fmt.Sprintf("secrets.exclusions: added %s from global", key))
+		}
+	}
+
 	// Merge UUIDs from global secrets
 	for _, uuid := range globalSecrets.UUIDs {
 		// Check if UUID pattern already exists
@@ -171,6 +191,11 @@ func (cm *ConfigurationMerger) mergeSecrets(service *Service, globalSecrets *Sec
 		}
 	}
 
+	if service.Secrets.MinConfidence == "" && globalSecrets.MinConfidence != "" {
+		service.Secrets.MinConfidence = globalSecrets.MinConfidence
+		diffs = append(diffs, fmt.Sprintf("secrets.minConfidence: using global %s", globalSecrets.MinConfidence))
+	}
+
 	return diffs
 }
 
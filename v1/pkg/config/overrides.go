@@ -0,0 +1,349 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+
+	"helm-charts-migrator/v1/pkg/logger"
+)
+
+// EnvPrefix prefixes the environment variables that override configuration values
+const EnvPrefix = "HCM_"
+
+// sourceFile is the source of values no override replaced, for logging
+const sourceFile = "file"
+
+// setOverrides are the --config-set overrides LoadConfig applies after the environment
+var setOverrides []Override
+
+// Override sets one configuration path to a value. The value is YAML, so
+// lists and mappings can be given as [a, b] or {a: b}; string fields take the
+// value as it is.
+type Override struct {
+	Path   string // e.g. globals.sops.awsProfile or services.heimdall.enabled
+	Value  string
+	Source string // where the override comes from, e.g. env HCM_GLOBALS_SOPS_AWSPROFILE
+}
+
+// pathSegment is a key or a list index of a configuration path
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// ParseSetOverrides parses path=value overrides, such as the values of --config-set
+func ParseSetOverrides(sets []string) ([]Override, error) {
+	overrides := make([]Override, 0, len(sets))
+	for _, set := range sets {
+		path, value, ok := strings.Cut(set, "=")
+		if !ok || strings.TrimSpace(path) == "" {
+			return nil, fmt.Errorf("invalid override %q, expected path=value", set)
+		}
+		path = strings.TrimSpace(path)
+		if _, err := splitConfigPath(path); err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, Override{Path: path, Value: value, Source: "--config-set " + path})
+	}
+	return overrides, nil
+}
+
+// SetOverrides registers the overrides LoadConfig applies last, after the
+// configuration file and the HCM_ environment variables
+func SetOverrides(overrides []Override) {
+	setOverrides = overrides
+}
+
+// EnvOverrides returns an override for every HCM_ variable of an environment,
+// in name order. The rest of the name is matched against the configuration
+// case-insensitively, a key at a time: HCM_GLOBALS_SOPS_AWSPROFILE and
+// HCM_GLOBALS_SOPS_AWS_PROFILE both set globals.sops.awsProfile, and
+// HCM_SERVICES_AUTH_SERVICE_ENABLED sets services.auth-service.enabled.
+// Variables matching no configuration path, which may belong to another
+// tool, are logged and skipped.
+func (c *Config) EnvOverrides(environ []string) []Override {
+	log := logger.WithName("config")
+	sort.Strings(environ)
+
+	var overrides []Override
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, EnvPrefix) || name == EnvPrefix {
+			continue
+		}
+
+		tokens := strings.Split(strings.ToUpper(strings.TrimPrefix(name, EnvPrefix)), "_")
+		segments, ok := resolveEnvPath(reflect.ValueOf(c).Elem(), tokens)
+		if !ok {
+			log.Warning("Ignoring environment variable matching no configuration path", "variable", name)
+			continue
+		}
+		overrides = append(overrides, Override{Path: formatConfigPath(segments), Value: value, Source: "env " + name})
+	}
+	return overrides
+}
+
+// ApplyOverrides sets the value of every override in order, so later
+// overrides win, logging what each one replaced
+func (c *Config) ApplyOverrides(overrides []Override) error {
+	log := logger.WithName("config")
+	applied := make(map[string]string)
+	for _, override := range overrides {
+		segments, err := splitConfigPath(override.Path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", override.Source, err)
+		}
+		if err := setConfigPath(reflect.ValueOf(c).Elem(), segments, override.Value, ""); err != nil {
+			return fmt.Errorf("%s: %w", override.Source, err)
+		}
+
+		path := formatConfigPath(segments)
+		replaced, ok := applied[path]
+		if !ok {
+			replaced = sourceFile
+		}
+		applied[path] = override.Source
+		log.InfoS("Applied configuration override", "path", path, "source", override.Source, "overrides", replaced)
+	}
+	return nil
+}
+
+// loadOverrides returns the overrides LoadConfig applies: the environment's,
+// then the registered --config-set ones
+func (c *Config) loadOverrides() []Override {
+	return append(c.EnvOverrides(os.Environ()), setOverrides...)
+}
+
+// setConfigPath sets the value at a path beneath v, creating the maps,
+// pointers and list items on the way
+func setConfigPath(v reflect.Value, segments []pathSegment, value, walked string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setConfigPath(v.Elem(), segments, value, walked)
+	}
+	if len(segments) == 0 {
+		return setConfigValue(v, value, walked)
+	}
+
+	segment := segments[0]
+	next := appendConfigPath(walked, segment)
+	switch v.Kind() {
+	case reflect.Struct:
+		field, ok := yamlFields(v.Type())[segment.key]
+		if segment.isIndex || !ok {
+			return fmt.Errorf("unknown configuration path %s", next)
+		}
+		return setConfigPath(v.FieldByIndex(field.Index), segments[1:], value, next)
+	case reflect.Map:
+		if segment.isIndex || v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unknown configuration path %s", next)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		key := reflect.ValueOf(segment.key).Convert(v.Type().Key())
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if existing := v.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := setConfigPath(elem, segments[1:], value, next); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+	case reflect.Slice:
+		if !segment.isIndex || segment.index > v.Len() {
+			return fmt.Errorf("%s is out of range, %s has %d items", next, walked, v.Len())
+		}
+		if segment.index == v.Len() {
+			v.Set(reflect.Append(v, reflect.New(v.Type().Elem()).Elem()))
+		}
+		return setConfigPath(v.Index(segment.index), segments[1:], value, next)
+	default:
+		return fmt.Errorf("unknown configuration path %s, %s is a value", next, walked)
+	}
+}
+
+// setConfigValue sets a value: strings as they are, anything else decoded as YAML
+func setConfigValue(v reflect.Value, value, path string) error {
+	if v.Kind() == reflect.String {
+		v.SetString(value)
+		return nil
+	}
+	decoded := reflect.New(v.Type())
+	if err := yaml.Unmarshal([]byte(value), decoded.Interface()); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", path, err)
+	}
+	v.Set(decoded.Elem())
+	return nil
+}
+
+// resolveEnvPath matches the tokens of an environment variable name against
+// the configuration, trying the longest key first
+func resolveEnvPath(v reflect.Value, tokens []string) ([]pathSegment, bool) {
+	t := v.Type()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if v.IsValid() && !v.IsNil() {
+			v = v.Elem()
+		} else {
+			v = reflect.Value{}
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, true
+	}
+
+	var candidates []string
+	childValue := func(key string) reflect.Value { return reflect.Value{} }
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := yamlFields(t)
+		for name := range fields {
+			candidates = append(candidates, name)
+		}
+		childValue = func(key string) reflect.Value {
+			if v.IsValid() {
+				return v.FieldByIndex(fields[key].Index)
+			}
+			return reflect.Zero(fields[key].Type)
+		}
+	case reflect.Map:
+		if v.IsValid() {
+			for _, key := range v.MapKeys() {
+				candidates = append(candidates, key.String())
+			}
+		}
+		// Keys not in the configuration yet are taken a token at a time
+		candidates = append(candidates, strings.ToLower(tokens[0]))
+		childValue = func(key string) reflect.Value {
+			if v.IsValid() {
+				if existing := v.MapIndex(reflect.ValueOf(key).Convert(t.Key())); existing.IsValid() {
+					return existing
+				}
+			}
+			return reflect.Zero(t.Elem())
+		}
+	case reflect.Slice:
+		index, err := strconv.Atoi(tokens[0])
+		if err != nil || index < 0 {
+			return nil, false
+		}
+		var item reflect.Value
+		if v.IsValid() && index < v.Len() {
+			item = v.Index(index)
+		} else {
+			item = reflect.Zero(t.Elem())
+		}
+		rest, ok := resolveEnvPath(item, tokens[1:])
+		if !ok {
+			return nil, false
+		}
+		return append([]pathSegment{{index: index, isIndex: true}}, rest...), true
+	default:
+		return nil, false
+	}
+
+	sort.Strings(candidates)
+	for n := len(tokens); n > 0; n-- {
+		name := strings.Join(tokens[:n], "_")
+		for _, candidate := range candidates {
+			if !envNameMatches(name, candidate) {
+				continue
+			}
+			if rest, ok := resolveEnvPath(childValue(candidate), tokens[n:]); ok {
+				return append([]pathSegment{{key: candidate}}, rest...), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// envNameMatches reports whether part of an environment variable name spells
+// a key, as AWSPROFILE or AWS_PROFILE for awsProfile and AUTH_SERVICE for auth-service
+func envNameMatches(name, key string) bool {
+	var flat, snake strings.Builder
+	var previous rune
+	for _, r := range key {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if unicode.IsUpper(r) && (unicode.IsLower(previous) || unicode.IsDigit(previous)) {
+				snake.WriteRune('_')
+			}
+			flat.WriteRune(unicode.ToUpper(r))
+			snake.WriteRune(unicode.ToUpper(r))
+		default:
+			flat.WriteRune('_')
+			snake.WriteRune('_')
+		}
+		previous = r
+	}
+	return name == flat.String() || name == snake.String() || name == strings.ReplaceAll(flat.String(), "_", "")
+}
+
+// splitConfigPath splits a path such as globals.autoInject["values.yaml"].keys[0]
+func splitConfigPath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	rest := path
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, `["`):
+			quoted, err := strconv.QuotedPrefix(rest[1:])
+			if err != nil || !strings.HasPrefix(rest[1+len(quoted):], "]") {
+				return nil, fmt.Errorf("invalid configuration path %q", path)
+			}
+			key, _ := strconv.Unquote(quoted)
+			segments = append(segments, pathSegment{key: key})
+			rest = rest[len(quoted)+2:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			index, err := strconv.Atoi(rest[1:max(end, 1)])
+			if end < 0 || err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid configuration path %q", path)
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+			rest = rest[end+1:]
+		default:
+			rest = strings.TrimPrefix(rest, ".")
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid configuration path %q", path)
+			}
+			segments = append(segments, pathSegment{key: rest[:end]})
+			rest = rest[end:]
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid configuration path %q", path)
+	}
+	return segments, nil
+}
+
+// formatConfigPath writes segments the way validation errors name paths
+func formatConfigPath(segments []pathSegment) string {
+	path := ""
+	for _, segment := range segments {
+		path = appendConfigPath(path, segment)
+	}
+	return path
+}
+
+func appendConfigPath(path string, segment pathSegment) string {
+	if segment.isIndex {
+		return fmt.Sprintf("%s[%d]", path, segment.index)
+	}
+	return joinConfigPath(path, segment.key)
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func overridesTestConfig() *Config {
+	return &Config{
+		Accounts: map[string]Account{
+			"prod": {Clusters: map[string]Cluster{"prod01": {Target: "eks-prod"}}},
+		},
+		Services: map[string]Service{
+			"auth-service": {Name: "auth-service"},
+		},
+		Globals: Globals{
+			SOPS: SOPSConfig{AwsProfile: "cicd-sre"},
+			AutoInject: map[string]AutoInjectFile{
+				"values.yaml": {Keys: []AutoInjectKey{{Key: "a", Condition: "ifExists"}}},
+			},
+		},
+	}
+}
+
+func TestParseSetOverrides(t *testing.T) {
+	overrides, err := ParseSetOverrides([]string{"globals.sops.awsProfile=x", "services.heimdall.gitRepo=https://a/b?c=d"})
+	require.NoError(t, err)
+	assert.Equal(t, []Override{
+		{Path: "globals.sops.awsProfile", Value: "x", Source: "--config-set globals.sops.awsProfile"},
+		{Path: "services.heimdall.gitRepo", Value: "https://a/b?c=d", Source: "--config-set services.heimdall.gitRepo"},
+	}, overrides)
+
+	for _, invalid := range []string{"globals.sops.awsProfile", "=x", "globals..sops=x", "keys[a]=x"} {
+		_, err := ParseSetOverrides([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestConfig_EnvOverrides(t *testing.T) {
+	cfg := overridesTestConfig()
+	overrides := cfg.EnvOverrides([]string{
+		"PATH=/usr/bin",
+		"HCM_GLOBALS_SOPS_AWSPROFILE=ci",
+		"HCM_GLOBALS_PERFORMANCE_MAX_CONCURRENT_SERVICES=3",
+		"HCM_SERVICES_AUTH_SERVICE_ENABLED=true",
+		"HCM_SERVICES_HEIMDALL_ENABLED=true",
+		"HCM_ACCOUNTS_PROD_CLUSTERS_PROD01_DEFAULT_NAMESPACE=viafoura",
+		"HCM_GLOBALS_SECRETS_LOCATIONS_PATH_PATTERNS=[secrets.*]",
+		"HCM_GLOBALS_SOPS_UNKNOWN=1",
+	})
+
+	paths := make(map[string]string)
+	for _, override := range overrides {
+		paths[override.Path] = override.Value
+	}
+	assert.Equal(t, map[string]string{
+		"accounts.prod.clusters.prod01.default_namespace": "viafoura",
+		"globals.performance.maxConcurrentServices":       "3",
+		"globals.secrets.locations.path_patterns":         "[secrets.*]",
+		"globals.sops.awsProfile":                         "ci",
+		"services.auth-service.enabled":                   "true",
+		"services.heimdall.enabled":                       "true",
+	}, paths)
+	assert.Equal(t, "env HCM_ACCOUNTS_PROD_CLUSTERS_PROD01_DEFAULT_NAMESPACE", overrides[0].Source)
+}
+
+func TestConfig_ApplyOverrides(t *testing.T) {
+	cfg := overridesTestConfig()
+	err := cfg.ApplyOverrides([]Override{
+		{Path: "globals.sops.awsProfile", Value: "ci", Source: "env HCM_GLOBALS_SOPS_AWSPROFILE"},
+		{Path: "globals.sops.awsProfile", Value: "flag", Source: "--config-set globals.sops.awsProfile"},
+		{Path: "services.auth-service.enabled", Value: "true"},
+		{Path: "services.heimdall.secrets.keys", Value: "[db.password, api.key]"},
+		{Path: `globals.autoInject["values.yaml"].keys[0].condition`, Value: "always"},
+		{Path: `globals.autoInject["values.yaml"].keys[1].key`, Value: "b"},
+		{Path: "accounts.prod.clusters.prod01.target", Value: "true"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "flag", cfg.Globals.SOPS.AwsProfile)
+	assert.True(t, cfg.Services["auth-service"].Enabled)
+	assert.Equal(t, "auth-service", cfg.Services["auth-service"].Name)
+	require.NotNil(t, cfg.Services["heimdall"].Secrets)
+	assert.Equal(t, []string{"db.password", "api.key"}, cfg.Services["heimdall"].Secrets.Keys)
+	assert.Equal(t, []AutoInjectKey{{Key: "a", Condition: "always"}, {Key: "b"}}, cfg.Globals.AutoInject["values.yaml"].Keys)
+	assert.Equal(t, "true", cfg.GetCluster("prod01").Target, "strings are taken as they are")
+
+	tests := []struct {
+		path, value, errorMsg string
+	}{
+		{"globals.sops.unknown", "x", "unknown configuration path globals.sops.unknown"},
+		{"globals.sops.awsProfile.name", "x", "globals.sops.awsProfile is a value"},
+		{"globals.performance.maxConcurrentServices", "many", "invalid value for globals.performance.maxConcurrentServices"},
+		{`globals.autoInject["values.yaml"].keys[5].key`, "x", "out of range"},
+	}
+	for _, tt := range tests {
+		err := overridesTestConfig().ApplyOverrides([]Override{{Path: tt.path, Value: tt.value, Source: "test"}})
+		assert.ErrorContains(t, err, tt.errorMsg, tt.path)
+	}
+}
+
+func TestLoadConfig_ChecksOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("globals:\n  secrets:\n    patterns: [\".*password.*\"]\n"), 0644))
+	SetOverrides([]Override{{Path: "globals.secrets.patterns", Value: `["(unclosed"]`, Source: "--config-set globals.secrets.patterns"}})
+	t.Cleanup(func() { SetOverrides(nil) })
+
+	_, err := LoadConfig(path)
+	assert.ErrorContains(t, err, `overrides: globals.secrets.patterns[0]: invalid regular expression "(unclosed"`)
+
+	cfg, err := LoadFileConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{".*password.*"}, cfg.Globals.Secrets.Patterns, "the file config has no overrides")
+}
+
+func TestSplitConfigPath(t *testing.T) {
+	for _, path := range []string{
+		"globals.sops.awsProfile",
+		`globals.autoInject["envs/*/values.yaml"].keys[0].condition`,
+		"globals.sops.creationRules[1].kms[0]",
+	} {
+		segments, err := splitConfigPath(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, path, formatConfigPath(segments))
+	}
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+)
+
+// PatchFile merges patch into the YAML configuration file at path, keeping
+// the file's comments and key order. Maps are merged and lists in the patch
+// replace the existing ones.
+func PatchFile(path string, patch map[string]interface{}) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat config file: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	base, err := yaml.UnmarshalYAML(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	patchData, err := yaml.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config patch: %w", err)
+	}
+	overlay, err := yaml.UnmarshalYAML(patchData)
+	if err != nil {
+		return fmt.Errorf("failed to parse config patch: %w", err)
+	}
+
+	merged, err := yaml.MergeTrees(base, overlay).ToYAML()
+	if err != nil {
+		return fmt.Errorf("failed to render config file: %w", err)
+	}
+
+	if err := os.WriteFile(path, merged, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// patchedFile holds what ServiceSecretsFile reads of a configuration file
+type patchedFile struct {
+	Include  []string                          `yaml:"include"`
+	Services map[string]map[string]interface{} `yaml:"services"`
+}
+
+// ServiceSecretsFile returns the file of a configuration that settings of
+// services.<name>.secrets are written to: the file merged last that sets the
+// service's secrets, else the one merged last that sets the service, else the
+// configuration file itself. Included files are merged before the file
+// including them, in order. Directories and cluster:// sources cannot be
+// written to and are reported as errors.
+func ServiceSecretsFile(configPath, serviceName string) (string, error) {
+	if IsClusterSource(configPath) {
+		return "", fmt.Errorf("cannot save settings to %s, use a config file", configPath)
+	}
+
+	files, err := mergedFiles(configPath, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var serviceFile string
+	for i := len(files) - 1; i >= 0; i-- {
+		file := files[i]
+		if file.dir {
+			return "", fmt.Errorf("cannot save settings of service %s: it may be set in config directory %s, use a config file", serviceName, file.path)
+		}
+		service, ok := file.config.Services[serviceName]
+		if !ok {
+			continue
+		}
+		if _, ok := service["secrets"]; ok {
+			return file.path, nil
+		}
+		if serviceFile == "" {
+			serviceFile = file.path
+		}
+	}
+	if serviceFile != "" {
+		return serviceFile, nil
+	}
+	return configPath, nil
+}
+
+// mergedFile is a file or directory of a configuration, in merge order
+type mergedFile struct {
+	path   string
+	dir    bool
+	config patchedFile
+}
+
+// mergedFiles lists the files a configuration file is merged from, in merge
+// order: its includes, then the file itself. stack holds the files including
+// this one, to stop on cycles.
+func mergedFiles(path string, stack []string) ([]mergedFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if info.IsDir() {
+		return []mergedFile{{path: path, dir: true}}, nil
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config path: %w", err)
+	}
+	for _, including := range stack {
+		if including == absPath {
+			return nil, fmt.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), absPath)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var config patchedFile
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	var files []mergedFile
+	for _, include := range config.Include {
+		pattern := include
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(path), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include pattern: %w", err)
+		}
+		for _, match := range matches {
+			included, err := mergedFiles(match, append(stack, absPath))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, included...)
+		}
+	}
+	return append(files, mergedFile{path: path, config: config}), nil
+}
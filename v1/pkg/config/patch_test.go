@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPatchFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`# Services configuration
+services:
+  heimdall:
+    enabled: true # keep me
+    secrets:
+      # Client secrets
+      keys:
+        - old
+      description: "Heimdall secrets"
+`), 0600))
+
+	err := PatchFile(path, map[string]interface{}{
+		"services": map[string]interface{}{
+			"heimdall": map[string]interface{}{
+				"secrets": map[string]interface{}{
+					"keys":       []string{"old", "new"},
+					"exclusions": []string{"tenant"},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content := string(data)
+	assert.Contains(t, content, "# Services configuration")
+	assert.Contains(t, content, "enabled: true # keep me")
+	assert.Contains(t, content, "# Client secrets")
+	assert.Contains(t, content, `description: "Heimdall secrets"`)
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"old", "new"}, cfg.Services["heimdall"].Secrets.Keys)
+	assert.Equal(t, []string{"tenant"}, cfg.Services["heimdall"].Secrets.Exclusions)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	assert.Error(t, PatchFile(filepath.Join(t.TempDir(), "missing.yaml"), nil))
+}
+
+func TestServiceSecretsFile(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml": `
+include: [teams/*.yaml]
+services:
+  root-only:
+    enabled: true
+`,
+		"teams/auth.yaml": `
+services:
+  auth:
+    secrets:
+      patterns: [".*password.*"]
+  heimdall:
+    enabled: true
+`,
+		"teams/heimdall.yaml": `
+services:
+  auth:
+    enabled: true
+  heimdall:
+    gitRepo: heimdall
+`,
+		"with-dir.yaml": `
+include: [shared/]
+services:
+  auth:
+    secrets:
+      keys: [token]
+`,
+		"shared/config.yaml": "",
+	})
+	root := filepath.Join(dir, "config.yaml")
+
+	tests := []struct {
+		root, service, file, errorMsg string
+	}{
+		{root: root, service: "root-only", file: root},
+		{root: root, service: "auth", file: filepath.Join(dir, "teams", "auth.yaml")},
+		{root: root, service: "heimdall", file: filepath.Join(dir, "teams", "heimdall.yaml")},
+		{root: root, service: "unknown", file: root},
+		{root: filepath.Join(dir, "with-dir.yaml"), service: "auth", file: filepath.Join(dir, "with-dir.yaml")},
+		{root: filepath.Join(dir, "with-dir.yaml"), service: "heimdall", errorMsg: "config directory " + filepath.Join(dir, "shared")},
+		{root: dir, service: "auth", errorMsg: "config directory"},
+		{root: "cluster://prod/migrator", service: "auth", errorMsg: "use a config file"},
+	}
+	for _, tt := range tests {
+		file, err := ServiceSecretsFile(tt.root, tt.service)
+		if tt.errorMsg != "" {
+			assert.ErrorContains(t, err, tt.errorMsg, tt.service)
+			continue
+		}
+		require.NoError(t, err, tt.service)
+		assert.Equal(t, tt.file, file, tt.service)
+	}
+}
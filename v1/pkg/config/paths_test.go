@@ -65,7 +65,7 @@ func TestPaths(t *testing.T) {
 
 		assert.Equal(t, filepath.Join("/target", "heimdall", "envs", "production", "secrets.yaml"), envPaths.EnvironmentSecretsPath())
 		assert.Equal(t, filepath.Join("/target", "heimdall", "envs", "production", "clusters", "prod01", "secrets.yaml"), envPaths.EnvironmentClusterSecretsPath())
-		assert.Equal(t, filepath.Join("/target", "heimdall", "envs", "production", "clusters", "prod01", "namespaces", "viafoura", "secrets.yaml"), envPaths.EnvironmentNamespaceSecretsPath())
+		assert.Equal(t, filepath.Join("/target", "heimdall", "envs", "production", "clusters", "prod01", "namespaces", "viafoura", "secrets.dec.yaml"), envPaths.EnvironmentNamespaceSecretsPath())
 	})
 }
 
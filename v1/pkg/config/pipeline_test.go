@@ -0,0 +1,22 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipelineConfig_IsStepEnabled(t *testing.T) {
+	steps := []PipelineStep{
+		{Name: StepRewriteTemplates, Enabled: false},
+		{Name: StepGenerateValuesSchema, Enabled: true},
+	}
+
+	enabled := PipelineConfig{Enabled: true, Steps: steps}
+	assert.False(t, enabled.IsStepEnabled(StepRewriteTemplates))
+	assert.True(t, enabled.IsStepEnabled(StepGenerateValuesSchema))
+	assert.True(t, enabled.IsStepEnabled("process_secrets"), "unlisted steps run")
+
+	disabled := PipelineConfig{Enabled: false, Steps: steps}
+	assert.True(t, disabled.IsStepEnabled(StepRewriteTemplates), "a disabled pipeline runs every step")
+}
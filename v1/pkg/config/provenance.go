@@ -0,0 +1,375 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+)
+
+// ValueSource is a value a configuration layer set at a path
+type ValueSource struct {
+	Layer string      `json:"layer"` // e.g. globals or service:heimdall
+	Value interface{} `json:"value"`
+}
+
+// Explanation tells where the effective value of a path comes from. A layer
+// setting a list replaces it, but for the lists the configuration merger adds
+// to, such as secrets.patterns, which hold the items of every layer setting
+// them and list the earlier ones in Merged.
+type Explanation struct {
+	Path     string        `json:"path"`
+	Value    interface{}   `json:"value"`
+	Layer    string        `json:"layer"`
+	Overrode []ValueSource `json:"overrode,omitempty"` // most recent first
+	Merged   []ValueSource `json:"merged,omitempty"`   // most recent first
+}
+
+// Explain returns the provenance of a path of an effective configuration, or
+// of every value beneath it when the path is a mapping
+func (c *ConfigLayer) Explain(path string) []Explanation {
+	var paths []string
+	for leaf := range c.Sources {
+		if leaf == path || strings.HasPrefix(leaf, path+".") || strings.HasPrefix(leaf, path+"[") {
+			paths = append(paths, leaf)
+		}
+	}
+	sort.Strings(paths)
+
+	explanations := make([]Explanation, 0, len(paths))
+	for _, leaf := range paths {
+		sources := c.Sources[leaf]
+		last := sources[len(sources)-1]
+		explanation := Explanation{Path: leaf, Value: last.Value, Layer: last.Layer}
+		var earlier []ValueSource
+		for i := len(sources) - 2; i >= 0; i-- {
+			earlier = append(earlier, sources[i])
+		}
+		if list, merged := c.lists[leaf]; merged {
+			explanation.Value = list
+			explanation.Merged = earlier
+		} else {
+			explanation.Overrode = earlier
+		}
+		explanations = append(explanations, explanation)
+	}
+	return explanations
+}
+
+// Hierarchy builds the configuration hierarchy of a loaded configuration,
+// keyed the way a service is configured: the built-in defaults, the globals,
+// every cluster under cluster, the account holding it as its environment, its
+// namespaces under namespace, and every service. Globals equal to their
+// default are left to the defaults layer.
+func (c *Config) Hierarchy() (*HierarchicalConfig, error) {
+	h := NewHierarchicalConfig()
+
+	defaults := &Config{}
+	(&configLoader{}).applyDefaults(defaults)
+	defaultValues, err := toValueMap(defaults.Globals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert defaults: %w", err)
+	}
+	h.SetDefaults(defaultValues)
+
+	globals, err := toValueMap(c.Globals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert globals: %w", err)
+	}
+	pruneEqual(globals, defaultValues)
+	h.SetGlobals(globals)
+
+	for accountName, account := range c.Accounts {
+		for clusterName, cluster := range account.Clusters {
+			namespaces := cluster.Namespaces
+			cluster.Namespaces = nil
+			values, err := toValueMap(cluster)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert cluster %s: %w", clusterName, err)
+			}
+			h.SetClusterConfig(clusterName, map[string]interface{}{"cluster": values})
+			h.SetEnvironmentConfig(clusterName, accountName, map[string]interface{}{"environment": accountName})
+
+			for namespaceName, namespace := range namespaces {
+				values, err := toValueMap(namespace)
+				if err != nil {
+					return nil, fmt.Errorf("failed to convert namespace %s/%s: %w", clusterName, namespaceName, err)
+				}
+				h.SetNamespaceConfig(clusterName, accountName, namespaceName, map[string]interface{}{"namespace": values})
+			}
+		}
+	}
+
+	for serviceName, service := range c.Services {
+		values, err := toValueMap(service)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert service %s: %w", serviceName, err)
+		}
+		if converter, ok := values["converter"].(map[string]interface{}); ok {
+			// GetConverterConfig only takes the options a service turns on
+			// and the numbers it sets
+			pruneUnset(converter)
+			if len(converter) == 0 {
+				delete(values, "converter")
+			}
+		}
+		h.SetServiceConfig(serviceName, values)
+	}
+
+	return h, nil
+}
+
+// toValueMap converts a configuration struct to the values of a layer,
+// leaving out empty strings, lists and mappings
+func toValueMap(in interface{}) (map[string]interface{}, error) {
+	data, err := yaml.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	pruneEmpty(values)
+	return values, nil
+}
+
+// pruneEmpty removes the unset values of a map
+func pruneEmpty(values map[string]interface{}) {
+	for key, value := range values {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			pruneEmpty(v)
+			if len(v) == 0 {
+				delete(values, key)
+			}
+		case []interface{}:
+			if len(v) == 0 {
+				delete(values, key)
+			}
+		case string:
+			if v == "" {
+				delete(values, key)
+			}
+		case nil:
+			delete(values, key)
+		}
+	}
+}
+
+// pruneUnset removes the false flags and zero numbers of a map
+func pruneUnset(values map[string]interface{}) {
+	for key, value := range values {
+		switch v := value.(type) {
+		case bool:
+			if !v {
+				delete(values, key)
+			}
+		case int:
+			if v == 0 {
+				delete(values, key)
+			}
+		}
+	}
+}
+
+// pruneEqual removes the values of a map equal to those of base
+func pruneEqual(values, base map[string]interface{}) {
+	for key, value := range values {
+		baseValue, ok := base[key]
+		if !ok {
+			continue
+		}
+		valueMap, valueIsMap := value.(map[string]interface{})
+		baseMap, baseIsMap := baseValue.(map[string]interface{})
+		switch {
+		case valueIsMap && baseIsMap:
+			pruneEqual(valueMap, baseMap)
+			if len(valueMap) == 0 {
+				delete(values, key)
+			}
+		case reflect.DeepEqual(value, baseValue):
+			delete(values, key)
+		}
+	}
+}
+
+// listMerge is how a list combines with the list of an earlier layer
+type listMerge struct {
+	identity   string // the field telling the items of a list of mappings apart
+	laterFirst bool   // the items of the later layer come first
+}
+
+// mergedLists are the lists a layer adds its items to instead of replacing,
+// the way ConfigurationMerger and GetConverterConfig merge them. A * matches
+// any key.
+var mergedLists = map[string]listMerge{
+	"secrets.patterns":       {laterFirst: true},
+	"secrets.keys":           {laterFirst: true},
+	"secrets.exclusions":     {laterFirst: true},
+	"secrets.uuids":          {identity: "pattern", laterFirst: true},
+	"secrets.values":         {identity: "pattern", laterFirst: true},
+	"autoInject.*.keys":      {identity: "key", laterFirst: true},
+	"converter.preserveKeys": {},
+	"converter.skipPaths":    {},
+}
+
+// replacedMappings are the mappings a layer replaces as a whole, the way
+// mergeMappingsDeep and GetReferenceImport pick them
+var replacedMappings = []string{
+	"mappings.locations",
+	"mappings.normalizer",
+	"mappings.transform",
+	"mappings.extract",
+	"mappings.cleaner",
+	"importReferences",
+}
+
+// union combines the list of an earlier layer with the list of a later one,
+// leaving out the items already there
+func (m listMerge) union(earlier, later []interface{}) []interface{} {
+	first, second := earlier, later
+	if m.laterFirst {
+		first, second = later, earlier
+	}
+	result := deepCopySlice(first)
+	for _, item := range second {
+		if !m.holds(result, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// holds reports whether items has an item equal to item, or with the same
+// identity field
+func (m listMerge) holds(items []interface{}, item interface{}) bool {
+	for _, existing := range items {
+		if m.identity == "" {
+			if reflect.DeepEqual(existing, item) {
+				return true
+			}
+			continue
+		}
+		existingMap, _ := existing.(map[string]interface{})
+		itemMap, _ := item.(map[string]interface{})
+		if existingMap != nil && itemMap != nil && reflect.DeepEqual(existingMap[m.identity], itemMap[m.identity]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchKeys reports whether the keys of a path match a pattern of mergedLists
+// or replacedMappings
+func matchKeys(pattern string, keys []string) bool {
+	parts := strings.Split(pattern, ".")
+	if len(parts) != len(keys) {
+		return false
+	}
+	for i, part := range parts {
+		if part != "*" && part != keys[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// mergedListAt returns how the list at a path merges, if layers add to it
+func mergedListAt(keys []string) (listMerge, bool) {
+	for pattern, merge := range mergedLists {
+		if matchKeys(pattern, keys) {
+			return merge, true
+		}
+	}
+	return listMerge{}, false
+}
+
+// isReplacedMapping reports whether a layer replaces the mapping at a path as a whole
+func isReplacedMapping(keys []string) bool {
+	for _, pattern := range replacedMappings {
+		if matchKeys(pattern, keys) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeTracked merges src into dest like mergeMapRecursive, but with the
+// merged lists and replaced mappings of the configuration merger, recording
+// the layer setting every value beneath path
+func (c *ConfigLayer) mergeTracked(dest, src map[string]interface{}, path string, keys []string, layer string) {
+	for key, srcValue := range src {
+		keyPath := joinConfigPath(path, key)
+		keyKeys := append(append([]string(nil), keys...), key)
+		destValue, exists := dest[key]
+		destMap, destIsMap := destValue.(map[string]interface{})
+		srcMap, srcIsMap := srcValue.(map[string]interface{})
+
+		if destIsMap && srcIsMap && !isReplacedMapping(keyKeys) {
+			c.mergeTracked(destMap, srcMap, keyPath, keyKeys, layer)
+			continue
+		}
+
+		if srcIsMap {
+			copied := deepCopyMap(srcMap)
+			set := make(map[string][]ValueSource)
+			recordSources(copied, keyPath, layer, set)
+			// What was there and the mapping leaves out is gone
+			c.forgetBeneath(keyPath, set)
+			for leaf, sources := range set {
+				c.Sources[leaf] = append(c.Sources[leaf], sources...)
+			}
+			dest[key] = copied
+			continue
+		}
+		if exists && destIsMap {
+			// A mapping replaced by a value ends the history of what was there
+			c.forgetBeneath(keyPath, nil)
+		}
+
+		delete(c.lists, keyPath)
+		dest[key] = srcValue
+		if merge, ok := mergedListAt(keyKeys); ok {
+			destList, destIsList := destValue.([]interface{})
+			srcList, srcIsList := srcValue.([]interface{})
+			if destIsList && srcIsList {
+				dest[key] = merge.union(destList, srcList)
+				c.lists[keyPath] = dest[key]
+			}
+		}
+		c.Sources[keyPath] = append(c.Sources[keyPath], ValueSource{Layer: layer, Value: srcValue})
+	}
+}
+
+// forgetBeneath drops the history of the values at or beneath a path, but
+// for those in keep
+func (c *ConfigLayer) forgetBeneath(path string, keep map[string][]ValueSource) {
+	for leaf := range c.Sources {
+		if _, kept := keep[leaf]; kept {
+			continue
+		}
+		if leaf == path || strings.HasPrefix(leaf, path+".") || strings.HasPrefix(leaf, path+"[") {
+			delete(c.Sources, leaf)
+		}
+	}
+	for leaf := range c.lists {
+		if leaf == path || strings.HasPrefix(leaf, path+".") || strings.HasPrefix(leaf, path+"[") {
+			delete(c.lists, leaf)
+		}
+	}
+}
+
+// recordSources records a layer as the source of every value of a map
+func recordSources(values map[string]interface{}, path, layer string, sources map[string][]ValueSource) {
+	for key, value := range values {
+		keyPath := joinConfigPath(path, key)
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			recordSources(nested, keyPath, layer, sources)
+			continue
+		}
+		sources[keyPath] = append(sources[keyPath], ValueSource{Layer: layer, Value: value})
+	}
+}
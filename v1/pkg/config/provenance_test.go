@@ -0,0 +1,176 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHierarchicalConfig_Explain(t *testing.T) {
+	h := NewHierarchicalConfig()
+	h.SetDefaults(map[string]interface{}{
+		"converter": map[string]interface{}{"minUppercaseChars": 3},
+	})
+	h.SetGlobals(map[string]interface{}{
+		"converter": map[string]interface{}{"minUppercaseChars": 4, "skipJavaProperties": true},
+		"mappings": map[string]interface{}{
+			"cleaner": map[string]interface{}{"key_patterns": []interface{}{"^a$"}},
+		},
+	})
+	h.SetClusterConfig("prod01", map[string]interface{}{
+		"converter": map[string]interface{}{"minUppercaseChars": 5},
+	})
+	h.SetServiceConfig("heimdall", map[string]interface{}{
+		"converter": map[string]interface{}{"minUppercaseChars": 6},
+		"mappings": map[string]interface{}{
+			"cleaner": map[string]interface{}{"key_patterns": []interface{}{"^b$"}},
+		},
+	})
+
+	effective := h.GetEffectiveConfig("prod01", "", "", "heimdall")
+
+	explanations := effective.Explain("converter.minUppercaseChars")
+	require.Len(t, explanations, 1)
+	assert.Equal(t, Explanation{
+		Path:  "converter.minUppercaseChars",
+		Value: 6,
+		Layer: "service:heimdall",
+		Overrode: []ValueSource{
+			{Layer: "cluster:prod01", Value: 5},
+			{Layer: "globals", Value: 4},
+			{Layer: "defaults", Value: 3},
+		},
+	}, explanations[0])
+
+	explanations = effective.Explain("mappings.cleaner.key_patterns")
+	require.Len(t, explanations, 1)
+	assert.Equal(t, []interface{}{"^b$"}, explanations[0].Value)
+	assert.Equal(t, []ValueSource{{Layer: "globals", Value: []interface{}{"^a$"}}}, explanations[0].Overrode)
+
+	explanations = effective.Explain("converter")
+	require.Len(t, explanations, 2)
+	assert.Equal(t, "converter.minUppercaseChars", explanations[0].Path)
+	assert.Equal(t, "converter.skipJavaProperties", explanations[1].Path)
+	assert.Equal(t, "globals", explanations[1].Layer)
+	assert.Empty(t, explanations[1].Overrode)
+
+	assert.Empty(t, effective.Explain("converter.min"))
+
+	// The layers are left as they were
+	assert.Equal(t, []interface{}{"^a$"}, h.globals.Values["mappings"].(map[string]interface{})["cleaner"].(map[string]interface{})["key_patterns"])
+	assert.Len(t, h.GetEffectiveConfig("", "", "", "").Explain("converter.minUppercaseChars")[0].Overrode, 1)
+}
+
+func TestConfig_Hierarchy(t *testing.T) {
+	cfg := &Config{
+		Accounts: map[string]Account{
+			"prod": {Clusters: map[string]Cluster{
+				"prod01": {
+					Target:     "eks-prod",
+					Namespaces: map[string]Namespace{"viafoura": {Enabled: true}},
+				},
+			}},
+		},
+		Globals: Globals{
+			Migration: Migration{HelmValuesFilename: "values.yaml", BaseValuesPath: "**/values.yaml"},
+			Converter: ConverterConfig{MinUppercaseChars: 3},
+			Mappings: &Mappings{Cleaner: &Cleaner{
+				Enabled:      true,
+				PathPatterns: []string{"values.yaml"},
+				KeyPatterns:  []string{"^a$"},
+			}},
+			Secrets: &Secrets{
+				Patterns: []string{"password", "token"},
+				UUIDs:    []UUIDPattern{{Pattern: "^[0-9a-f-]{36}$", Sensitive: true}},
+			},
+		},
+		Services: map[string]Service{
+			"heimdall": {
+				Enabled: true,
+				Mappings: &Mappings{Cleaner: &Cleaner{
+					KeyPatterns: []string{"^b$"},
+				}},
+				Secrets: &Secrets{
+					Patterns: []string{"apikey", "token"},
+					UUIDs:    []UUIDPattern{{Pattern: "^[0-9a-f-]{36}$"}},
+				},
+				Converter: &ConverterConfig{PreserveKeys: []string{"db_host"}},
+			},
+		},
+	}
+
+	h, err := cfg.Hierarchy()
+	require.NoError(t, err)
+	require.Equal(t, "prod", cfg.GetClusterEnvironment("prod01"))
+	effective := h.GetEffectiveConfig("prod01", "prod", "viafoura", "heimdall")
+
+	explain := func(path string) Explanation {
+		explanations := effective.Explain(path)
+		require.Len(t, explanations, 1, path)
+		return explanations[0]
+	}
+
+	// Globals equal to the defaults are left to the defaults layer
+	assert.Equal(t, "defaults", explain("migration.helmValuesFilename").Layer)
+	assert.Equal(t, "defaults", explain("converter.minUppercaseChars").Layer)
+	assert.Equal(t, "globals", explain("migration.baseValuesPath").Layer)
+
+	keyPatterns := explain("mappings.cleaner.key_patterns")
+	assert.Equal(t, "service:heimdall", keyPatterns.Layer)
+	assert.Equal(t, []interface{}{"^b$"}, keyPatterns.Value)
+	assert.Equal(t, []ValueSource{{Layer: "globals", Value: []interface{}{"^a$"}}}, keyPatterns.Overrode)
+
+	// A service cleaner replaces the global one as a whole
+	assert.Empty(t, effective.Explain("mappings.cleaner.path_patterns"))
+	assert.Equal(t, "service:heimdall", explain("mappings.cleaner.enabled").Layer)
+
+	// Secret lists hold the items of both, the service ones first
+	patterns := explain("secrets.patterns")
+	assert.Equal(t, "service:heimdall", patterns.Layer)
+	assert.Equal(t, []interface{}{"apikey", "token", "password"}, patterns.Value)
+	assert.Equal(t, []ValueSource{{Layer: "globals", Value: []interface{}{"password", "token"}}}, patterns.Merged)
+	assert.Empty(t, patterns.Overrode)
+	assert.Len(t, explain("secrets.uuids").Value, 1)
+
+	// The service converter adds to the preserved keys but leaves the rest
+	assert.Equal(t, "defaults", explain("converter.minUppercaseChars").Layer)
+	assert.Equal(t, "service:heimdall", explain("converter.preserveKeys").Layer)
+
+	merged, _ := cfg.GetMergedServiceConfig("heimdall")
+	assert.Equal(t, []string{"apikey", "token", "password"}, merged.Secrets.Patterns)
+
+	assert.Equal(t, "cluster:prod01", explain("cluster.target").Layer)
+	assert.Equal(t, "env:prod01/prod", explain("environment").Layer)
+	assert.Equal(t, "namespace:prod01/prod/viafoura", explain("namespace.enabled").Layer)
+	assert.Equal(t, "service:heimdall", explain("enabled").Layer)
+}
+
+func TestConfig_GetMergedServiceConfigLeavesConfigUnchanged(t *testing.T) {
+	cfg := &Config{
+		Globals: Globals{
+			Secrets: &Secrets{Patterns: []string{"password"}, MinConfidence: "medium"},
+			AutoInject: map[string]AutoInjectFile{
+				"values.yaml": {Keys: []AutoInjectKey{{Key: "a", Condition: "always"}}},
+			},
+		},
+		Services: map[string]Service{
+			"heimdall": {
+				Secrets: &Secrets{Keys: []string{"clientId"}},
+				AutoInject: map[string]AutoInjectFile{
+					"values.yaml": {Keys: []AutoInjectKey{{Key: "b", Condition: "always"}}},
+				},
+			},
+		},
+	}
+
+	merged, _ := cfg.GetMergedServiceConfig("heimdall")
+	assert.Equal(t, []string{"password"}, merged.Secrets.Patterns)
+	assert.Equal(t, "medium", merged.Secrets.MinConfidence)
+	assert.Len(t, merged.AutoInject["values.yaml"].Keys, 2)
+
+	service := cfg.Services["heimdall"]
+	assert.Empty(t, service.Secrets.Patterns)
+	assert.Empty(t, service.Secrets.MinConfidence)
+	assert.Len(t, service.AutoInject["values.yaml"].Keys, 1)
+}
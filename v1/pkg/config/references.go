@@ -0,0 +1,46 @@
+package config
+
+import "path"
+
+// ReferenceImport configures the optional stage that copies the Secrets and
+// ConfigMaps referenced by a release into the migrated chart's secrets and
+// configMap values, so the new chart no longer depends on them
+type ReferenceImport struct {
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// Secrets and ConfigMaps are allow-lists of object names; globs such as
+	// "heimdall-*" are accepted and an empty list imports nothing of that kind
+	Secrets    []string `yaml:"secrets,omitempty"`
+	ConfigMaps []string `yaml:"configMaps,omitempty"`
+}
+
+// IsEnabled reports whether referenced objects should be imported; the stage is off unless enabled
+func (r *ReferenceImport) IsEnabled() bool {
+	return r != nil && r.Enabled != nil && *r.Enabled
+}
+
+// Allows reports whether an object of kind Secret or ConfigMap may be imported
+func (r *ReferenceImport) Allows(kind, name string) bool {
+	if r == nil {
+		return false
+	}
+
+	patterns := r.ConfigMaps
+	if kind == "Secret" {
+		patterns = r.Secrets
+	}
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// GetReferenceImport returns the reference import settings of a service:
+// its own when set, otherwise the global ones
+func (c *Config) GetReferenceImport(serviceName string) *ReferenceImport {
+	if service, ok := c.Services[serviceName]; ok && service.ImportReferences != nil {
+		return service.ImportReferences
+	}
+	return c.Globals.ImportReferences
+}
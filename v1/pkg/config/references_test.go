@@ -0,0 +1,47 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReferenceImport(t *testing.T) {
+	enabled, disabled := true, false
+
+	t.Run("disabled unless enabled", func(t *testing.T) {
+		assert.False(t, (*ReferenceImport)(nil).IsEnabled())
+		assert.False(t, (&ReferenceImport{}).IsEnabled())
+		assert.False(t, (&ReferenceImport{Enabled: &disabled}).IsEnabled())
+		assert.True(t, (&ReferenceImport{Enabled: &enabled}).IsEnabled())
+	})
+
+	t.Run("allow-list per kind", func(t *testing.T) {
+		ri := &ReferenceImport{
+			Secrets:    []string{"heimdall-*"},
+			ConfigMaps: []string{"shared-env"},
+		}
+
+		assert.True(t, ri.Allows("Secret", "heimdall-api"))
+		assert.False(t, ri.Allows("Secret", "shared-env"))
+		assert.True(t, ri.Allows("ConfigMap", "shared-env"))
+		assert.False(t, ri.Allows("ConfigMap", "heimdall-api"))
+		assert.False(t, (*ReferenceImport)(nil).Allows("Secret", "heimdall-api"))
+	})
+
+	t.Run("service settings replace globals", func(t *testing.T) {
+		global := &ReferenceImport{Enabled: &enabled, Secrets: []string{"*"}}
+		service := &ReferenceImport{Enabled: &disabled}
+		cfg := &Config{
+			Globals: Globals{ImportReferences: global},
+			Services: map[string]Service{
+				"heimdall": {ImportReferences: service},
+				"auth":     {},
+			},
+		}
+
+		assert.Same(t, service, cfg.GetReferenceImport("heimdall"))
+		assert.Same(t, global, cfg.GetReferenceImport("auth"))
+		assert.Same(t, global, cfg.GetReferenceImport("unknown"))
+	})
+}
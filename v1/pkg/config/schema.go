@@ -0,0 +1,28 @@
+package config
+
+// defaultMaxEnumValues is the most distinct values a key may take to become an enum
+const defaultMaxEnumValues = 5
+
+// ValuesSchema configures the values.schema.json generated for migrated charts
+type ValuesSchema struct {
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// MaxEnumValues is the most distinct string values a key may take to be
+	// inferred as an enum; a negative number disables enums
+	MaxEnumValues *int `yaml:"maxEnumValues,omitempty"`
+}
+
+// IsEnabled reports whether a schema is generated; it is unless disabled
+func (s *ValuesSchema) IsEnabled() bool {
+	return s == nil || s.Enabled == nil || *s.Enabled
+}
+
+// EnumLimit returns the most distinct values of an inferred enum, 0 when enums are disabled
+func (s *ValuesSchema) EnumLimit() int {
+	if s == nil || s.MaxEnumValues == nil {
+		return defaultMaxEnumValues
+	}
+	if *s.MaxEnumValues < 0 {
+		return 0
+	}
+	return *s.MaxEnumValues
+}
@@ -10,6 +10,12 @@ type Secrets struct {
 	Values      []ValuePattern   `yaml:"values,omitempty"`
 	Description string           `yaml:"description,omitempty"`
 
+	// Exclusions are keys never treated as secrets, e.g. rejected review candidates
+	Exclusions []string `yaml:"exclusions,omitempty"`
+	// MinConfidence is the lowest confidence (low, medium, high) moved automatically;
+	// weaker matches are left in place as review candidates. Defaults to low.
+	MinConfidence string `yaml:"minConfidence,omitempty"`
+
 	// Merging contains merge strategy configuration for specific target files
 	Merging map[string]*MergeStrategy `yaml:"merging,omitempty"`
 }
@@ -111,3 +117,57 @@ type MergeStrategy struct {
 	// Supports {service}, {cluster}, {namespace} placeholders
 	MergeOrder []string `yaml:"mergeOrder,omitempty"`
 }
+
+// RecordSecretDecisions adds reviewed keys to a service's secrets configuration:
+// accepted keys become exact keys and rejected keys become exclusions. A key
+// decided again the other way is moved between the lists. It reports whether
+// the configuration changed.
+func (c *Config) RecordSecretDecisions(serviceName string, accepted, rejected []string) bool {
+	if len(accepted) == 0 && len(rejected) == 0 {
+		return false
+	}
+	if c.Services == nil {
+		c.Services = make(map[string]Service)
+	}
+
+	service := c.Services[serviceName]
+	if service.Secrets == nil {
+		service.Secrets = &Secrets{}
+	}
+	secrets := service.Secrets
+
+	changed := false
+	for _, key := range accepted {
+		if !contains(secrets.Keys, key) {
+			secrets.Keys = append(secrets.Keys, key)
+			changed = true
+		}
+		if remaining := removeString(secrets.Exclusions, key); len(remaining) != len(secrets.Exclusions) {
+			secrets.Exclusions = remaining
+			changed = true
+		}
+	}
+	for _, key := range rejected {
+		if !contains(secrets.Exclusions, key) {
+			secrets.Exclusions = append(secrets.Exclusions, key)
+			changed = true
+		}
+		if remaining := removeString(secrets.Keys, key); len(remaining) != len(secrets.Keys) {
+			secrets.Keys = remaining
+			changed = true
+		}
+	}
+
+	c.Services[serviceName] = service
+	return changed
+}
+
+func removeString(values []string, value string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestSecretsIsEnabled(t *testing.T) {
@@ -132,4 +134,22 @@ func TestSecretsGetStatusString(t *testing.T) {
 
 func boolPtr(b bool) *bool {
 	return &b
-}
\ No newline at end of file
+}
+func TestRecordSecretDecisions(t *testing.T) {
+	cfg := &Config{
+		Services: map[string]Service{
+			"heimdall": {Enabled: true, Secrets: &Secrets{Keys: []string{"token"}, Exclusions: []string{"password"}}},
+		},
+	}
+
+	assert.False(t, cfg.RecordSecretDecisions("heimdall", nil, nil))
+	assert.False(t, cfg.RecordSecretDecisions("heimdall", []string{"token"}, []string{"password"}), "already recorded")
+
+	assert.True(t, cfg.RecordSecretDecisions("heimdall", []string{"password"}, []string{"tenant"}))
+	assert.Equal(t, []string{"token", "password"}, cfg.Services["heimdall"].Secrets.Keys)
+	assert.Equal(t, []string{"tenant"}, cfg.Services["heimdall"].Secrets.Exclusions)
+	assert.True(t, cfg.Services["heimdall"].Enabled)
+
+	assert.True(t, cfg.RecordSecretDecisions("auth", nil, []string{"uuid"}))
+	assert.Equal(t, []string{"uuid"}, cfg.Services["auth"].Secrets.Exclusions)
+}
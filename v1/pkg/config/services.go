@@ -9,10 +9,17 @@ type Service struct {
 	ServiceType          string                    `yaml:"serviceType,omitempty"`
 	ServiceTypeCapitalized string                  `yaml:"serviceTypeCapitalized,omitempty"`
 	GitRepo              string                    `yaml:"gitRepo,omitempty"`
+	ParameterStore       string                    `yaml:"parameterStore,omitempty"` // name of the service in AWS Parameter Store
 	AutoInject           map[string]AutoInjectFile `yaml:"autoInject,omitempty"`
 	Mappings             *Mappings                 `yaml:"mappings,omitempty"`
 	Migration            Migration                 `yaml:"migration,omitempty"`
 	Secrets              *Secrets                  `yaml:"secrets,omitempty"`
+	ImportReferences     *ReferenceImport          `yaml:"importReferences,omitempty"`
+	Converter            *ConverterConfig          `yaml:"converter,omitempty"`
+
+	// enabledSet tells that the file the service was decoded from sets
+	// enabled, so merging it over another file only then changes Enabled
+	enabledSet bool
 }
 
 // Migration represents migration-specific configuration
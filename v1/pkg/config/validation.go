@@ -0,0 +1,432 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+
+	"helm-charts-migrator/v1/pkg/errkind"
+)
+
+// mergeKey is the YAML merge key, whose value is checked where it is anchored
+const mergeKey = "<<"
+
+// syntaxLinePattern finds the line of a YAML syntax error and its message
+var syntaxLinePattern = regexp.MustCompile(`line (\d+): (.*)`)
+
+// ValidationError is a problem found in a configuration file, with its location
+type ValidationError struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string // path of the offending key, e.g. globals.secrets.patterns[2]
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	location := e.File
+	switch {
+	case e.Column > 0:
+		location = fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)
+	case e.Line > 0:
+		location = fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	if e.Path == "" {
+		return fmt.Sprintf("%s: %s", location, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, e.Path, e.Message)
+}
+
+// ValidationErrors lists the problems found in a configuration, in file order
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// position is where a value is written in a configuration file
+type position struct {
+	line, column int
+}
+
+// configValidator collects the problems of one configuration file
+type configValidator struct {
+	file       string
+	positions  map[string]position
+	errors     ValidationErrors
+	baseCharts bool // check that base chart paths exist
+}
+
+// ValidateFile decodes a configuration file strictly and checks its values.
+// Keys that match no configuration field, values of the wrong type, unknown
+// injection conditions, regular expressions that do not compile, base chart
+// paths that do not exist and cluster targets used twice are all reported with
+// their file:line:column. The configuration is returned along with the
+// problems; an error is returned only when the file cannot be read.
+func ValidateFile(path string) (*Config, ValidationErrors, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	cfg, problems := ValidateData(path, data)
+	return cfg, problems, nil
+}
+
+// ValidateData decodes and checks configuration data read from file. Relative
+// base chart paths are resolved against the working directory.
+func ValidateData(file string, data []byte) (*Config, ValidationErrors) {
+	return (&configLoader{baseCharts: true}).validateData(file, data)
+}
+
+// validateData decodes and checks configuration data read from file, checking
+// base chart paths when the loader does
+func (c *configLoader) validateData(file string, data []byte) (*Config, ValidationErrors) {
+	v := c.newValidator(file)
+	var cfg Config
+	if !v.decode(data, &cfg, "") {
+		return nil, v.result()
+	}
+	v.markEnabledServices(&cfg)
+	v.checkConfig(&cfg)
+	return &cfg, v.result()
+}
+
+// ValidatePath loads a configuration file, with the files it includes, a
+// configuration directory or the ConfigMaps of a cluster://<context>/<namespace>
+// and checks it. The problems of every file are returned together; an error is
+// returned only when a file cannot be read.
+func ValidatePath(path string) (*Config, ValidationErrors, error) {
+	loader := newConfigLoader()
+	loader.baseCharts = true
+	return validatePath(loader, path)
+}
+
+// validatePath loads and checks the configuration a --config path names
+func validatePath(loader *configLoader, path string) (*Config, ValidationErrors, error) {
+	cfg, err := loadPath(loader, path)
+	var problems ValidationErrors
+	if errors.As(err, &problems) {
+		return nil, problems, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Targets are checked per file; this catches the ones set in different files
+	v := newConfigValidator(path)
+	v.checkClusterTargets(cfg)
+	return cfg, v.result(), nil
+}
+
+// ValidateBaseCharts checks that the base chart paths of a loaded
+// configuration exist, for the commands that copy them. Problems name the path
+// only, as the configuration may come from several files.
+func ValidateBaseCharts(file string, cfg *Config) ValidationErrors {
+	v := newConfigValidator(file)
+	v.checkBaseChart(cfg.Globals.Migration.BaseChartPath, "globals.migration.baseChartPath")
+	for _, name := range sortedKeys(cfg.Services) {
+		v.checkBaseChart(cfg.Services[name].Migration.BaseChartPath,
+			joinConfigPath("services", name)+".migration.baseChartPath")
+	}
+	return v.result()
+}
+
+// markEnabledServices records which services of a decoded configuration set
+// enabled, as false is also what a service that leaves it out decodes to
+func (v *configValidator) markEnabledServices(cfg *Config) {
+	for name, service := range cfg.Services {
+		_, service.enabledSet = v.positions[joinConfigPath(joinConfigPath("services", name), "enabled")]
+		cfg.Services[name] = service
+	}
+}
+
+// validateOverridden checks a configuration again after overrides were
+// applied. Overrides have no position in a file, so problems name the path only.
+func validateOverridden(cfg *Config) ValidationErrors {
+	v := newConfigValidator("overrides")
+	v.checkConfig(cfg)
+	v.checkClusterTargets(cfg)
+	return v.result()
+}
+
+// loadPath loads the configuration a --config path names
+func loadPath(loader ConfigLoader, path string) (*Config, error) {
+	if IsClusterSource(path) {
+		kubeContext, namespace, err := ParseClusterSource(path)
+		if err != nil {
+			return nil, errkind.Wrap(errkind.Configuration, err)
+		}
+		return loader.LoadFromCluster(kubeContext, namespace)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if info.IsDir() {
+		return loader.LoadFromDirectory(path)
+	}
+	return loader.LoadFromFile(path)
+}
+
+// newValidator creates a validator for one file loaded by c
+func (c *configLoader) newValidator(file string) *configValidator {
+	v := newConfigValidator(file)
+	v.baseCharts = c.baseCharts
+	return v
+}
+
+// newConfigValidator creates a validator for one file
+func newConfigValidator(file string) *configValidator {
+	return &configValidator{
+		file:      file,
+		positions: make(map[string]position),
+	}
+}
+
+// decode checks data against the type of out, which is the configuration or
+// the section of it found at path, and decodes it. It returns false when the
+// data is not YAML.
+func (v *configValidator) decode(data []byte, out interface{}, path string) bool {
+	tree, err := yaml.UnmarshalYAML(data)
+	if err != nil {
+		v.syntaxError(err)
+		return false
+	}
+	problems := len(v.errors)
+	for _, doc := range tree.Documents {
+		v.walk(doc.Root, reflect.TypeOf(out).Elem(), path)
+	}
+
+	if err := yaml.Unmarshal(data, out); err != nil && len(v.errors) == problems {
+		// Anything the walk did not catch is still reported
+		v.syntaxError(err)
+	}
+	return true
+}
+
+// result returns the problems found, in file order
+func (v *configValidator) result() ValidationErrors {
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		if v.errors[i].Line != v.errors[j].Line {
+			return v.errors[i].Line < v.errors[j].Line
+		}
+		return v.errors[i].Column < v.errors[j].Column
+	})
+	return v.errors
+}
+
+// syntaxError records an error of the YAML parser, which only knows the line
+func (v *configValidator) syntaxError(err error) {
+	line, message := 0, err.Error()
+	if match := syntaxLinePattern.FindStringSubmatch(message); match != nil {
+		line, _ = strconv.Atoi(match[1])
+		message = match[2]
+	}
+	v.errors = append(v.errors, ValidationError{File: v.file, Line: line, Message: message})
+}
+
+// add records a problem at the position of a path
+func (v *configValidator) add(path, format string, args ...interface{}) {
+	pos := v.positions[path]
+	v.errors = append(v.errors, ValidationError{
+		File:    v.file,
+		Line:    pos.line,
+		Column:  pos.column,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// walk checks a node against the Go type it decodes into, recording the
+// position of every path on the way
+func (v *configValidator) walk(node *yaml.Node, t reflect.Type, path string) {
+	if node == nil {
+		return
+	}
+	if node.Kind == yaml.DocumentNode {
+		for _, child := range node.Children {
+			v.walk(child, t, path)
+		}
+		return
+	}
+	if path != "" {
+		if _, ok := v.positions[path]; !ok {
+			v.positions[path] = position{node.Line, node.Column}
+		}
+	}
+	if node.Kind == yaml.AliasNode || node.Tag == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if v.expectKind(node, yaml.MappingNode, "a mapping", path) {
+			v.walkStruct(node, t, path)
+		}
+	case reflect.Map:
+		if v.expectKind(node, yaml.MappingNode, "a mapping", path) {
+			for i := 0; i+1 < len(node.Children); i += 2 {
+				key := fmt.Sprint(node.Children[i].Value)
+				if key == mergeKey {
+					continue
+				}
+				keyPath := joinConfigPath(path, key)
+				v.positions[keyPath] = position{node.Children[i].Line, node.Children[i].Column}
+				v.walk(node.Children[i+1], t.Elem(), keyPath)
+			}
+		}
+	case reflect.Slice:
+		if v.expectKind(node, yaml.SequenceNode, "a list", path) {
+			for i, item := range node.Children {
+				v.walk(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case reflect.Bool:
+		v.expectTag(node, "a boolean", path, "!!bool")
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		v.expectTag(node, "an integer", path, "!!int")
+	case reflect.Float32, reflect.Float64:
+		v.expectTag(node, "a number", path, "!!int", "!!float")
+	case reflect.String:
+		v.expectKind(node, yaml.ScalarNode, "a string", path)
+	}
+}
+
+// walkStruct checks the keys of a mapping against the fields of a struct
+func (v *configValidator) walkStruct(node *yaml.Node, t reflect.Type, path string) {
+	fields := yamlFields(t)
+	for i := 0; i+1 < len(node.Children); i += 2 {
+		keyNode := node.Children[i]
+		key := fmt.Sprint(keyNode.Value)
+		if key == mergeKey {
+			continue
+		}
+		keyPath := joinConfigPath(path, key)
+		v.positions[keyPath] = position{keyNode.Line, keyNode.Column}
+
+		field, ok := fields[key]
+		if !ok {
+			v.add(keyPath, "unknown field %q%s", key, suggestField(key, fields))
+			continue
+		}
+		v.walk(node.Children[i+1], field.Type, keyPath)
+	}
+}
+
+// expectKind reports a node that is not of the kind a field needs
+func (v *configValidator) expectKind(node *yaml.Node, kind yaml.NodeKind, want, path string) bool {
+	if node.Kind == kind {
+		return true
+	}
+	v.positions[path] = position{node.Line, node.Column}
+	v.add(path, "expected %s, got %s", want, describeNode(node))
+	return false
+}
+
+// expectTag reports a scalar whose resolved type is not one a field accepts
+func (v *configValidator) expectTag(node *yaml.Node, want, path string, tags ...string) {
+	if !v.expectKind(node, yaml.ScalarNode, want, path) {
+		return
+	}
+	for _, tag := range tags {
+		if node.Tag == tag {
+			return
+		}
+	}
+	v.positions[path] = position{node.Line, node.Column}
+	v.add(path, "expected %s, got %s", want, describeNode(node))
+}
+
+// describeNode names what a node holds, for error messages
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	return fmt.Sprintf("%q", fmt.Sprint(node.Value))
+}
+
+// yamlFields returns the fields of a struct by YAML key
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// suggestField proposes the known field closest to a misspelt key
+func suggestField(key string, fields map[string]reflect.StructField) string {
+	best, bestDistance := "", 3
+	for name := range fields {
+		distance := editDistance(strings.ToLower(key), strings.ToLower(name))
+		if distance < bestDistance || distance == bestDistance && best != "" && name < best {
+			best, bestDistance = name, distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// joinConfigPath appends a key to a path, quoting keys that hold dots or
+// brackets, such as autoInject["values.yaml"]
+func joinConfigPath(path, key string) string {
+	if strings.ContainsAny(key, ".[]\" ") || key == "" {
+		return fmt.Sprintf("%s[%s]", path, strconv.Quote(key))
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+)
+
+// checkConfig runs the semantic checks on a decoded configuration
+func (v *configValidator) checkConfig(cfg *Config) {
+	v.checkAutoInject(cfg.Globals.AutoInject, "globals.autoInject")
+	v.checkSecrets(cfg.Globals.Secrets, "globals.secrets")
+	v.checkMappings(cfg.Globals.Mappings, "globals.mappings")
+	v.checkSOPS(cfg.Globals.SOPS, "globals.sops")
+	if v.baseCharts {
+		v.checkBaseChart(cfg.Globals.Migration.BaseChartPath, "globals.migration.baseChartPath")
+	}
+
+	for _, name := range sortedKeys(cfg.Services) {
+		service := cfg.Services[name]
+		path := joinConfigPath("services", name)
+		v.checkAutoInject(service.AutoInject, path+".autoInject")
+		v.checkSecrets(service.Secrets, path+".secrets")
+		v.checkMappings(service.Mappings, path+".mappings")
+		if v.baseCharts {
+			v.checkBaseChart(service.Migration.BaseChartPath, path+".migration.baseChartPath")
+		}
+	}
+
+	v.checkClusterTargets(cfg)
+}
+
+// checkAutoInject checks the key and condition of every injection rule
+func (v *configValidator) checkAutoInject(autoInject map[string]AutoInjectFile, path string) {
+	for _, pattern := range sortedKeys(autoInject) {
+		for i, rule := range autoInject[pattern].Keys {
+			rulePath := fmt.Sprintf("%s.keys[%d]", joinConfigPath(path, pattern), i)
+			if _, err := rule.Path(); err != nil {
+				v.add(rulePath+".key", "%v", err)
+			}
+			if rule.Condition == "" {
+				v.add(rulePath, "condition is required (%s, %s, %s or %s)",
+					ConditionIfExists, ConditionIfNotExists, ConditionAlways, ConditionDisabled)
+				continue
+			}
+			if !InjectionCondition(rule.Condition).IsValid() {
+				v.add(rulePath+".condition", "unknown condition %q, expected %s, %s, %s or %s", rule.Condition,
+					ConditionIfExists, ConditionIfNotExists, ConditionAlways, ConditionDisabled)
+			}
+		}
+	}
+}
+
+// checkSecrets checks that the secret detection patterns compile
+func (v *configValidator) checkSecrets(secrets *Secrets, path string) {
+	if secrets == nil {
+		return
+	}
+	for i, pattern := range secrets.Patterns {
+		v.checkRegex(pattern, fmt.Sprintf("%s.patterns[%d]", path, i))
+	}
+	for i, uuid := range secrets.UUIDs {
+		v.checkRegex(uuid.Pattern, fmt.Sprintf("%s.uuids[%d].pattern", path, i))
+	}
+	for i, value := range secrets.Values {
+		v.checkRegex(value.Pattern, fmt.Sprintf("%s.values[%d].pattern", path, i))
+	}
+	if locations := secrets.Locations; locations != nil {
+		for i, pattern := range locations.PathPatterns {
+			v.checkRegex(pattern, fmt.Sprintf("%s.locations.path_patterns[%d]", path, i))
+		}
+		for i, pattern := range locations.Include {
+			v.checkRegex(pattern, fmt.Sprintf("%s.locations.include[%d]", path, i))
+		}
+		for i, pattern := range locations.Exclude {
+			v.checkRegex(pattern, fmt.Sprintf("%s.locations.exclude[%d]", path, i))
+		}
+	}
+}
+
+// checkMappings checks that the normalizer and cleaner patterns compile
+func (v *configValidator) checkMappings(mappings *Mappings, path string) {
+	if mappings == nil {
+		return
+	}
+	if mappings.Normalizer != nil {
+		for _, pattern := range sortedKeys(mappings.Normalizer.Patterns) {
+			v.checkRegex(pattern, joinConfigPath(path+".normalizer.patterns", pattern))
+		}
+	}
+	if mappings.Cleaner != nil {
+		for i, pattern := range mappings.Cleaner.KeyPatterns {
+			v.checkRegex(pattern, fmt.Sprintf("%s.cleaner.key_patterns[%d]", path, i))
+		}
+	}
+}
+
+// checkSOPS checks that the SOPS path expressions compile
+func (v *configValidator) checkSOPS(sops SOPSConfig, path string) {
+	if sops.PathRegex != "" {
+		v.checkRegex(sops.PathRegex, path+".pathRegex")
+	}
+	for i, rule := range sops.CreationRules {
+		if rule.PathRegex != "" {
+			v.checkRegex(rule.PathRegex, fmt.Sprintf("%s.creationRules[%d].pathRegex", path, i))
+		}
+		if rule.EncryptedRegex != "" {
+			v.checkRegex(rule.EncryptedRegex, fmt.Sprintf("%s.creationRules[%d].encryptedRegex", path, i))
+		}
+	}
+}
+
+// checkRegex reports a regular expression that does not compile
+func (v *configValidator) checkRegex(pattern, path string) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		v.add(path, "invalid regular expression %q: %v", pattern, err)
+	}
+}
+
+// checkBaseChart checks that a base chart path is an existing directory.
+// Relative paths are resolved against the working directory, as Paths does.
+func (v *configValidator) checkBaseChart(baseChartPath, path string) {
+	if baseChartPath == "" {
+		return
+	}
+	info, err := os.Stat(baseChartPath)
+	switch {
+	case os.IsNotExist(err):
+		v.add(path, "base chart %s does not exist", baseChartPath)
+	case err != nil:
+		v.add(path, "failed to read base chart %s: %v", baseChartPath, err)
+	case !info.IsDir():
+		v.add(path, "base chart %s is not a directory", baseChartPath)
+	}
+}
+
+// checkClusterTargets reports clusters migrating into a target already used by
+// another cluster
+func (v *configValidator) checkClusterTargets(cfg *Config) {
+	owners := make(map[string]string)
+	for _, accountName := range sortedKeys(cfg.Accounts) {
+		clusters := cfg.Accounts[accountName].Clusters
+		for _, clusterName := range sortedKeys(clusters) {
+			target := clusters[clusterName].Target
+			if target == "" {
+				continue
+			}
+			path := joinConfigPath(joinConfigPath(joinConfigPath("accounts", accountName)+".clusters", clusterName), "target")
+			if owner, ok := owners[target]; ok {
+				v.add(path, "target %q is already used by %s", target, owner)
+				continue
+			}
+			owners[target] = path
+		}
+	}
+}
+
+// sortedKeys returns the keys of a map in order, so problems are reported
+// deterministically
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateData(t *testing.T) {
+	// Base chart paths are relative to the working directory, not to the file
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "base-chart"), 0755))
+	t.Chdir(dir)
+	file := filepath.Join(t.TempDir(), "config.yaml")
+
+	t.Run("valid config", func(t *testing.T) {
+		cfg, problems := ValidateData(file, []byte(`
+accounts:
+  prod:
+    clusters:
+      prod01:
+        enabled: true
+        target: eks-prod
+      prod02:
+        target: eks-prod-2
+globals:
+  performance:
+    maxConcurrentServices: 5
+  autoInject:
+    "values.yaml":
+      keys:
+        - key: a
+          condition: ifNotExists
+  migration:
+    baseChartPath: base-chart
+services:
+  heimdall:
+    enabled: true
+    parameterStore: heimdall
+`))
+		assert.Empty(t, problems)
+		require.NotNil(t, cfg)
+		assert.True(t, cfg.Services["heimdall"].Enabled)
+		assert.Equal(t, 5, cfg.Globals.Performance.MaxConcurrentServices)
+	})
+
+	t.Run("problems are located", func(t *testing.T) {
+		_, problems := ValidateData(file, []byte(`accounts:
+  prod:
+    clusters:
+      prod01:
+        enabled: yes-please
+        target: eks-prod
+      prod02:
+        target: eks-prod
+globals:
+  secrets:
+    patterns: ["(unclosed"]
+    locations:
+      path_pattern: ["secrets.*"]
+  mappings:
+    normalizer:
+      patterns:
+        "a[": b
+  autoInject:
+    "values.yaml":
+      keys:
+        - key: a
+          condition: sometimes
+  migration:
+    baseChartPath: missing-chart
+services:
+  heimdall:
+    secrets: [1]
+`))
+		messages := make([]string, len(problems))
+		for i, problem := range problems {
+			messages[i] = problem.Error()
+		}
+		assert.Equal(t, []string{
+			file + `:5:18: accounts.prod.clusters.prod01.enabled: expected a boolean, got "yes-please"`,
+			file + `:8:9: accounts.prod.clusters.prod02.target: target "eks-prod" is already used by accounts.prod.clusters.prod01.target`,
+			file + `:11:16: globals.secrets.patterns[0]: invalid regular expression "(unclosed": error parsing regexp: missing closing ): ` + "`(unclosed`",
+			file + `:13:7: globals.secrets.locations.path_pattern: unknown field "path_pattern", did you mean "path_patterns"?`,
+			file + `:17:9: globals.mappings.normalizer.patterns["a["]: invalid regular expression "a[": error parsing regexp: missing closing ]: ` + "`[`",
+			file + `:22:11: globals.autoInject["values.yaml"].keys[0].condition: unknown condition "sometimes", expected ifExists, ifNotExists, always or disabled`,
+			file + `:24:5: globals.migration.baseChartPath: base chart missing-chart does not exist`,
+			file + `:27:14: services.heimdall.secrets: expected a mapping, got a list`,
+		}, messages)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		cfg, problems := ValidateData(file, []byte("globals:\n  bad: [\n"))
+		assert.Nil(t, cfg)
+		require.Len(t, problems, 1)
+		assert.Equal(t, 2, problems[0].Line)
+		assert.Contains(t, problems[0].Error(), file+":2: ")
+	})
+}
+
+func TestValidateFile(t *testing.T) {
+	_, _, err := ValidateFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestValidateBaseCharts(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.MkdirAll("base-chart", 0755))
+	require.NoError(t, os.WriteFile("config.yaml", []byte(`globals:
+  migration:
+    baseChartPath: base-chart
+services:
+  heimdall:
+    migration:
+      baseChartPath: missing-chart
+`), 0644))
+
+	// Only the commands copying base charts check them
+	cfg, err := LoadFileConfig("config.yaml")
+	require.NoError(t, err)
+
+	problems := ValidateBaseCharts("config.yaml", cfg)
+	require.Len(t, problems, 1)
+	assert.Equal(t, "config.yaml: services.heimdall.migration.baseChartPath: base chart missing-chart does not exist",
+		problems[0].Error())
+
+	_, problems, err = ValidatePath("config.yaml")
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	assert.Equal(t, "config.yaml:7:7: services.heimdall.migration.baseChartPath: base chart missing-chart does not exist",
+		problems[0].Error())
+}
+
+func TestSuggestField(t *testing.T) {
+	fields := yamlFields(reflect.TypeOf(SecretLocations{}))
+	assert.Equal(t, `, did you mean "path_patterns"?`, suggestField("path_pattern", fields))
+	assert.Equal(t, `, did you mean "include"?`, suggestField("Includes", fields))
+	assert.Empty(t, suggestField("somethingElse", fields))
+}
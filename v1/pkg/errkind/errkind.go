@@ -0,0 +1,296 @@
+// Package errkind provides the typed error taxonomy used across the migrator.
+//
+// Errors are classified into a small set of kinds that drive retry decisions,
+// the migration report and the CLI exit code. Errors can be tagged explicitly
+// with Wrap/New, or classified from well-known error types (net, url,
+// Kubernetes apierrors, AWS API errors) with Classify. Classify covers both;
+// errors.Is with the sentinels only matches tagged errors.
+package errkind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Kind identifies a class of errors
+type Kind int
+
+const (
+	// Unknown is used for errors that match no other kind
+	Unknown Kind = iota
+	// TransientNetwork covers timeouts, refused/reset connections and DNS failures
+	TransientNetwork
+	// KubernetesThrottling covers API server throttling and temporary unavailability
+	KubernetesThrottling
+	// KMSThrottling covers AWS KMS request throttling and transient KMS failures
+	KMSThrottling
+	// Validation covers invalid input data (values files, charts, secrets)
+	Validation
+	// Configuration covers invalid or incomplete migrator configuration
+	Configuration
+	// NotFound covers missing files, releases and Kubernetes objects
+	NotFound
+)
+
+var kindNames = map[Kind]string{
+	Unknown:              "unknown",
+	TransientNetwork:     "transient-network",
+	KubernetesThrottling: "kubernetes-throttling",
+	KMSThrottling:        "kms-throttling",
+	Validation:           "validation",
+	Configuration:        "configuration",
+	NotFound:             "not-found",
+}
+
+// String returns the kebab-case name of the kind
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return kindNames[Unknown]
+}
+
+// MarshalText implements encoding.TextMarshaler so kinds render by name in reports
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler so kinds can be configured by name
+func (k *Kind) UnmarshalText(text []byte) error {
+	name := strings.ToLower(strings.TrimSpace(string(text)))
+	for kind, kindName := range kindNames {
+		if kindName == name {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown error kind %q", name)
+}
+
+// Retryable reports whether errors of this kind are worth retrying
+func (k Kind) Retryable() bool {
+	switch k {
+	case TransientNetwork, KubernetesThrottling, KMSThrottling:
+		return true
+	default:
+		return false
+	}
+}
+
+// Exit codes returned by the CLI for each kind of error
+const (
+	ExitOK                   = 0
+	ExitUnknown              = 1
+	ExitConfiguration        = 2
+	ExitValidation           = 3
+	ExitNotFound             = 4
+	ExitTransientNetwork     = 5
+	ExitKubernetesThrottling = 6
+	ExitKMSThrottling        = 7
+)
+
+// ExitCode returns the CLI exit code for the kind
+func (k Kind) ExitCode() int {
+	switch k {
+	case Configuration:
+		return ExitConfiguration
+	case Validation:
+		return ExitValidation
+	case NotFound:
+		return ExitNotFound
+	case TransientNetwork:
+		return ExitTransientNetwork
+	case KubernetesThrottling:
+		return ExitKubernetesThrottling
+	case KMSThrottling:
+		return ExitKMSThrottling
+	default:
+		return ExitUnknown
+	}
+}
+
+// Error is an error tagged with a Kind
+type Error struct {
+	Kind Kind
+	Err  error
+}
+
+// Sentinel errors for use with errors.Is. They only match errors tagged with
+// New or Wrap; errors.Is cannot see the kind Classify derives from an untagged
+// error such as fs.ErrNotExist, so use Classify to decide on the kind of any
+// error.
+var (
+	ErrTransientNetwork     = &Error{Kind: TransientNetwork}
+	ErrKubernetesThrottling = &Error{Kind: KubernetesThrottling}
+	ErrKMSThrottling        = &Error{Kind: KMSThrottling}
+	ErrValidation           = &Error{Kind: Validation}
+	ErrConfiguration        = &Error{Kind: Configuration}
+	ErrNotFound             = &Error{Kind: NotFound}
+)
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Kind.String() + " error"
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any *Error of the same kind, so errors.Is(err, ErrNotFound) works
+// regardless of the wrapped message. Untagged errors never match, see Classify.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind
+}
+
+// New creates an error of the given kind from a formatted message; %w is supported
+func New(kind Kind, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+// Wrap tags err with a kind; it returns nil if err is nil
+func Wrap(kind Kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// Classify returns the kind of err. Explicitly tagged errors win; otherwise
+// the kind is derived from well-known error types in the chain.
+func Classify(err error) Kind {
+	if err == nil {
+		return Unknown
+	}
+
+	var tagged *Error
+	if errors.As(err, &tagged) {
+		return tagged.Kind
+	}
+
+	if kind, ok := classifyKubernetes(err); ok {
+		return kind
+	}
+
+	if kind, ok := classifyAWS(err); ok {
+		return kind
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return TransientNetwork
+	case errors.Is(err, fs.ErrNotExist):
+		return NotFound
+	case errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ETIMEDOUT):
+		return TransientNetwork
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return TransientNetwork
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return TransientNetwork
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return TransientNetwork
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return TransientNetwork
+	}
+
+	return Unknown
+}
+
+// IsRetryable reports whether err is of a retryable kind
+func IsRetryable(err error) bool {
+	return Classify(err).Retryable()
+}
+
+// ExitCode returns the CLI exit code for err
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	return Classify(err).ExitCode()
+}
+
+// classifyKubernetes maps Kubernetes API status errors onto kinds
+func classifyKubernetes(err error) (Kind, bool) {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return Unknown, false
+	}
+
+	switch {
+	case apierrors.IsTooManyRequests(err),
+		apierrors.IsServerTimeout(err),
+		apierrors.IsTimeout(err),
+		apierrors.IsServiceUnavailable(err),
+		apierrors.IsInternalError(err):
+		return KubernetesThrottling, true
+	case apierrors.IsNotFound(err):
+		return NotFound, true
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return Validation, true
+	case apierrors.IsUnauthorized(err), apierrors.IsForbidden(err):
+		return Configuration, true
+	}
+	return Unknown, false
+}
+
+// apiError matches AWS SDK (smithy) API errors without depending on the SDK
+type apiError interface {
+	ErrorCode() string
+}
+
+// kmsThrottlingCodes are the AWS KMS error codes worth retrying
+var kmsThrottlingCodes = []string{
+	"ThrottlingException",
+	"KMSInternalException",
+	"DependencyTimeoutException",
+	"LimitExceededException",
+}
+
+// classifyAWS maps AWS KMS errors onto kinds. SOPS flattens key service
+// errors into strings, so the well-known KMS codes are also matched in the
+// message as a last resort.
+func classifyAWS(err error) (Kind, bool) {
+	code := ""
+	var awsErr apiError
+	if errors.As(err, &awsErr) {
+		code = awsErr.ErrorCode()
+	}
+
+	message := err.Error()
+	for _, throttling := range kmsThrottlingCodes {
+		if code == throttling || strings.Contains(message, throttling) {
+			return KMSThrottling, true
+		}
+	}
+
+	switch code {
+	case "NotFoundException":
+		return NotFound, true
+	case "AccessDeniedException", "InvalidKeyUsageException", "DisabledException":
+		return Configuration, true
+	}
+	return Unknown, false
+}
@@ -0,0 +1,98 @@
+package errkind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeAPIError struct {
+	code string
+}
+
+func (e *fakeAPIError) Error() string     { return "api error " + e.code }
+func (e *fakeAPIError) ErrorCode() string { return e.code }
+
+func TestClassify(t *testing.T) {
+	gr := schema.GroupResource{Resource: "secrets"}
+
+	tests := []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{"nil", nil, Unknown},
+		{"plain", errors.New("boom"), Unknown},
+		{"tagged", New(Validation, "bad values"), Validation},
+		{"tagged and wrapped", fmt.Errorf("outer: %w", Wrap(Configuration, errors.New("inner"))), Configuration},
+		{"deadline", fmt.Errorf("call: %w", context.DeadlineExceeded), TransientNetwork},
+		{"connection refused", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), TransientNetwork},
+		{"dns", &net.DNSError{Err: "no such host", Name: "kms.local"}, TransientNetwork},
+		{"missing file", fmt.Errorf("read: %w", os.ErrNotExist), NotFound},
+		{"k8s throttled", apierrors.NewTooManyRequests("slow down", 1), KubernetesThrottling},
+		{"k8s unavailable", apierrors.NewServiceUnavailable("down"), KubernetesThrottling},
+		{"k8s not found", fmt.Errorf("get: %w", apierrors.NewNotFound(gr, "db")), NotFound},
+		{"k8s forbidden", apierrors.NewForbidden(gr, "db", errors.New("rbac")), Configuration},
+		{"kms throttling", fmt.Errorf("encrypt: %w", &fakeAPIError{code: "ThrottlingException"}), KMSThrottling},
+		{"kms flattened", errors.New("failed to encrypt data key: ThrottlingException: Rate exceeded"), KMSThrottling},
+		{"kms access denied", &fakeAPIError{code: "AccessDeniedException"}, Configuration},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Classify(tt.err))
+		})
+	}
+}
+
+func TestErrorsIsAndAs(t *testing.T) {
+	err := fmt.Errorf("loading: %w", New(NotFound, "service %s not found", "api"))
+
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.False(t, errors.Is(err, ErrValidation))
+	assert.Equal(t, "loading: service api not found", err.Error())
+
+	var kindErr *Error
+	assert.True(t, errors.As(err, &kindErr))
+	assert.Equal(t, NotFound, kindErr.Kind)
+
+	// Only Classify sees the kind of an untagged error
+	missing := fmt.Errorf("reading values: %w", &os.PathError{Op: "open", Path: "values.yaml", Err: os.ErrNotExist})
+	assert.Equal(t, NotFound, Classify(missing))
+	assert.False(t, errors.Is(missing, ErrNotFound))
+	assert.True(t, errors.Is(Wrap(NotFound, missing), ErrNotFound))
+
+	cause := errors.New("root cause")
+	assert.True(t, errors.Is(Wrap(Validation, cause), cause))
+	assert.Nil(t, Wrap(Validation, nil))
+}
+
+func TestKindProperties(t *testing.T) {
+	assert.True(t, TransientNetwork.Retryable())
+	assert.True(t, KubernetesThrottling.Retryable())
+	assert.True(t, KMSThrottling.Retryable())
+	assert.False(t, Validation.Retryable())
+	assert.False(t, Configuration.Retryable())
+	assert.False(t, NotFound.Retryable())
+
+	assert.Equal(t, ExitOK, ExitCode(nil))
+	assert.Equal(t, ExitUnknown, ExitCode(errors.New("boom")))
+	assert.Equal(t, ExitConfiguration, ExitCode(New(Configuration, "bad")))
+	assert.Equal(t, ExitValidation, ExitCode(New(Validation, "bad")))
+	assert.Equal(t, ExitNotFound, ExitCode(New(NotFound, "gone")))
+
+	var kind Kind
+	assert.NoError(t, kind.UnmarshalText([]byte("kms-throttling")))
+	assert.Equal(t, KMSThrottling, kind)
+	assert.Error(t, kind.UnmarshalText([]byte("nope")))
+	text, _ := NotFound.MarshalText()
+	assert.Equal(t, "not-found", string(text))
+}
@@ -0,0 +1,286 @@
+// Package journal implements the durable, append-only run journal used to
+// resume long migrations.
+//
+// Every run gets a run ID and a directory under the runs directory. The
+// journal file in that directory records one JSON line per finished task.
+// Entries carry the hash of the configuration they were produced with, so a
+// resumed run only trusts entries written under the same configuration.
+package journal
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"helm-charts-migrator/v1/pkg/errkind"
+	"helm-charts-migrator/v1/pkg/logger"
+)
+
+// FileName is the name of the journal file inside a run directory
+const FileName = "journal.jsonl"
+
+// Status is the outcome of a journaled task
+type Status string
+
+const (
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+// Entry is a single journal line
+type Entry struct {
+	Time       time.Time `json:"time"`
+	RunID      string    `json:"runId"`
+	ConfigHash string    `json:"configHash"`
+	TaskID     string    `json:"taskId"`
+	Service    string    `json:"service,omitempty"`
+	Cluster    string    `json:"cluster,omitempty"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Status     Status    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Journal is an append-only record of finished tasks for one run
+type Journal struct {
+	runID      string
+	configHash string
+	path       string
+	mu         sync.Mutex
+	file       *os.File
+	status     map[string]Status
+	stale      int
+	log        *logger.NamedLogger
+}
+
+// NewRunID generates a sortable, unique run ID
+func NewRunID() string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return time.Now().UTC().Format("20060102-150405")
+	}
+	return fmt.Sprintf("%s-%s", time.Now().UTC().Format("20060102-150405"), hex.EncodeToString(suffix))
+}
+
+// RunDir returns the directory holding the journal and artifacts of a run
+func RunDir(runsDir, runID string) string {
+	return filepath.Join(runsDir, runID)
+}
+
+// Exists reports whether a journal exists for the run
+func Exists(runsDir, runID string) bool {
+	_, err := os.Stat(filepath.Join(RunDir(runsDir, runID), FileName))
+	return err == nil
+}
+
+// HashConfig returns a stable hash of any configuration value
+func HashConfig(cfg interface{}) (string, error) {
+	// encoding/json sorts map keys, which keeps the hash stable across runs
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config for hashing: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ServiceKey returns the task ID recorded when a whole service has been migrated
+func ServiceKey(service string) string {
+	return "service/" + service
+}
+
+// NamespaceKey returns the task ID recorded when a service namespace has been migrated
+func NamespaceKey(service, cluster, namespace string) string {
+	return fmt.Sprintf("namespace/%s/%s/%s", service, cluster, namespace)
+}
+
+// Open opens (or creates) the journal of a run and replays its existing
+// entries. Entries written under a different config hash are ignored.
+func Open(runsDir, runID, configHash string) (*Journal, error) {
+	dir := RunDir(runsDir, runID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+
+	j := &Journal{
+		runID:      runID,
+		configHash: configHash,
+		path:       filepath.Join(dir, FileName),
+		status:     make(map[string]Status),
+		log:        logger.WithName("journal"),
+	}
+
+	if err := j.replay(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	j.file = file
+
+	if err := j.terminateTornLine(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	j.log.InfoS("Opened run journal",
+		"runID", runID,
+		"path", j.path,
+		"completed", j.Completed(),
+		"stale", j.stale)
+
+	return j, nil
+}
+
+// replay loads the entries already present in the journal file
+func (j *Journal) replay() error {
+	file, err := os.Open(j.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// A crash can leave a torn last line; everything before it is still valid
+			j.log.Warning("Skipping unreadable journal entry", "path", j.path, "line", line, "error", err)
+			continue
+		}
+
+		if entry.ConfigHash != j.configHash {
+			j.stale++
+			continue
+		}
+		j.status[entry.TaskID] = entry.Status
+	}
+
+	if err := scanner.Err(); err != nil {
+		return errkind.New(errkind.Validation, "failed to read journal %s: %w", j.path, err)
+	}
+	return nil
+}
+
+// terminateTornLine makes sure new entries start on a fresh line after a
+// crash left a partial last line
+func (j *Journal) terminateTornLine() error {
+	data, err := os.ReadFile(j.path)
+	if err != nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+	if len(data) == 0 || data[len(data)-1] == '\n' {
+		return nil
+	}
+	if _, err := j.file.Write([]byte{'\n'}); err != nil {
+		return fmt.Errorf("failed to repair journal: %w", err)
+	}
+	return nil
+}
+
+// Record appends an entry to the journal and syncs it to disk
+func (j *Journal) Record(entry Entry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return fmt.Errorf("journal %s is closed", j.path)
+	}
+
+	entry.Time = time.Now().UTC()
+	entry.RunID = j.runID
+	entry.ConfigHash = j.configHash
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	if _, err := j.file.Write(data); err != nil {
+		return fmt.Errorf("failed to write journal entry: %w", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+
+	j.status[entry.TaskID] = entry.Status
+	return nil
+}
+
+// RecordTask records the outcome of a task by ID
+func (j *Journal) RecordTask(taskID string, taskErr error) error {
+	entry := Entry{TaskID: taskID, Status: StatusCompleted}
+	if taskErr != nil {
+		entry.Status = StatusFailed
+		entry.Error = taskErr.Error()
+	}
+	return j.Record(entry)
+}
+
+// IsCompleted reports whether the task completed under the current config
+func (j *Journal) IsCompleted(taskID string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status[taskID] == StatusCompleted
+}
+
+// Completed returns the number of tasks completed under the current config
+func (j *Journal) Completed() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	count := 0
+	for _, status := range j.status {
+		if status == StatusCompleted {
+			count++
+		}
+	}
+	return count
+}
+
+// RunID returns the ID of the run
+func (j *Journal) RunID() string {
+	return j.runID
+}
+
+// Path returns the journal file path
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Dir returns the run directory
+func (j *Journal) Dir() string {
+	return filepath.Dir(j.path)
+}
+
+// Close closes the journal file
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
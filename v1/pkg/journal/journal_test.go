@@ -0,0 +1,95 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal_RecordAndResume(t *testing.T) {
+	runsDir := t.TempDir()
+	runID := NewRunID()
+	assert.False(t, Exists(runsDir, runID))
+
+	j, err := Open(runsDir, runID, "hash-a")
+	require.NoError(t, err)
+	assert.True(t, Exists(runsDir, runID))
+
+	require.NoError(t, j.Record(Entry{TaskID: ServiceKey("heimdall"), Service: "heimdall", Status: StatusCompleted}))
+	require.NoError(t, j.RecordTask(NamespaceKey("auth", "dev01", "vf-dev2"), errors.New("boom")))
+	require.NoError(t, j.RecordTask(NamespaceKey("auth", "dev01", "vf-test2"), nil))
+	require.NoError(t, j.Close())
+
+	// Resuming with the same config hash trusts the completed entries only
+	resumed, err := Open(runsDir, runID, "hash-a")
+	require.NoError(t, err)
+	defer resumed.Close()
+	assert.True(t, resumed.IsCompleted(ServiceKey("heimdall")))
+	assert.True(t, resumed.IsCompleted(NamespaceKey("auth", "dev01", "vf-test2")))
+	assert.False(t, resumed.IsCompleted(NamespaceKey("auth", "dev01", "vf-dev2")))
+	assert.Equal(t, 2, resumed.Completed())
+
+	// A later success overrides an earlier failure
+	require.NoError(t, resumed.RecordTask(NamespaceKey("auth", "dev01", "vf-dev2"), nil))
+	assert.True(t, resumed.IsCompleted(NamespaceKey("auth", "dev01", "vf-dev2")))
+}
+
+func TestJournal_ConfigChangeInvalidatesEntries(t *testing.T) {
+	runsDir := t.TempDir()
+
+	j, err := Open(runsDir, "run-1", "hash-a")
+	require.NoError(t, err)
+	require.NoError(t, j.RecordTask(ServiceKey("heimdall"), nil))
+	require.NoError(t, j.Close())
+
+	changed, err := Open(runsDir, "run-1", "hash-b")
+	require.NoError(t, err)
+	defer changed.Close()
+	assert.False(t, changed.IsCompleted(ServiceKey("heimdall")))
+	assert.Equal(t, 1, changed.stale)
+}
+
+func TestJournal_IgnoresTornLines(t *testing.T) {
+	runsDir := t.TempDir()
+
+	j, err := Open(runsDir, "run-1", "hash-a")
+	require.NoError(t, err)
+	require.NoError(t, j.RecordTask(ServiceKey("heimdall"), nil))
+	require.NoError(t, j.Close())
+
+	// Simulate a crash in the middle of a write
+	f, err := os.OpenFile(filepath.Join(RunDir(runsDir, "run-1"), FileName), os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"taskId":"service/au`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	resumed, err := Open(runsDir, "run-1", "hash-a")
+	require.NoError(t, err)
+	assert.True(t, resumed.IsCompleted(ServiceKey("heimdall")))
+
+	// New entries must not be glued to the torn line
+	require.NoError(t, resumed.RecordTask(ServiceKey("auth"), nil))
+	require.NoError(t, resumed.Close())
+
+	reopened, err := Open(runsDir, "run-1", "hash-a")
+	require.NoError(t, err)
+	defer reopened.Close()
+	assert.True(t, reopened.IsCompleted(ServiceKey("auth")))
+}
+
+func TestHashConfig(t *testing.T) {
+	a, err := HashConfig(map[string]interface{}{"b": 1, "a": []string{"x"}})
+	require.NoError(t, err)
+	b, err := HashConfig(map[string]interface{}{"a": []string{"x"}, "b": 1})
+	require.NoError(t, err)
+	c, err := HashConfig(map[string]interface{}{"a": []string{"y"}, "b": 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+}
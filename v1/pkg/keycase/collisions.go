@@ -0,0 +1,206 @@
+package keycase
+
+import (
+	"fmt"
+	"strings"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+)
+
+// CollisionPolicy decides what happens when keys of one map, such as db_host
+// and dbHost, convert to the same key
+type CollisionPolicy string
+
+const (
+	// CollisionFail reports every collision as an error
+	CollisionFail CollisionPolicy = "fail"
+	// CollisionKeepFirst keeps the first key, in document order for YAML
+	// documents and in sorted order for plain maps
+	CollisionKeepFirst CollisionPolicy = "keep-first"
+	// CollisionKeepCamel keeps the key already in the converted form, or the
+	// first key when none is
+	CollisionKeepCamel CollisionPolicy = "keep-camel"
+	// CollisionMerge merges colliding maps, the key chosen by CollisionKeepCamel
+	// taking precedence; other values are resolved as CollisionKeepCamel does
+	CollisionMerge CollisionPolicy = "merge"
+)
+
+// ParseCollisionPolicy parses a collision policy; an empty name is CollisionFail
+func ParseCollisionPolicy(name string) (CollisionPolicy, error) {
+	switch policy := CollisionPolicy(strings.ToLower(name)); policy {
+	case "":
+		return CollisionFail, nil
+	case CollisionFail, CollisionKeepFirst, CollisionKeepCamel, CollisionMerge:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid collision policy %q, expected fail, keep-first, keep-camel or merge", name)
+	}
+}
+
+// Collision records keys of one map that convert to the same key
+type Collision struct {
+	Path   string          `yaml:"path"`   // converted path of the key
+	Keys   []string        `yaml:"keys"`   // colliding keys, in the order they were visited
+	Kept   string          `yaml:"kept"`   // key whose value was kept or took precedence
+	Merged bool            `yaml:"merged"` // whether the other values were merged beneath it
+	Policy CollisionPolicy `yaml:"policy"`
+}
+
+func (c Collision) String() string {
+	return fmt.Sprintf("%s (from %s)", c.Path, strings.Join(c.Keys, ", "))
+}
+
+// CollisionError is returned under CollisionFail with every collision found
+type CollisionError struct {
+	Collisions []Collision
+}
+
+func (e *CollisionError) Error() string {
+	descriptions := make([]string, len(e.Collisions))
+	for i, collision := range e.Collisions {
+		descriptions[i] = collision.String()
+	}
+	return fmt.Sprintf("keys convert to the same name: %s; set converter.collisionPolicy to keep-first, keep-camel or merge to resolve them",
+		strings.Join(descriptions, "; "))
+}
+
+// keyGroup is a set of keys of one map converting to the same target key
+type keyGroup struct {
+	target string
+	keys   []string
+}
+
+// groupKeys groups keys by the key they convert to, in the order given.
+// Skipped keys keep their name.
+func (c *Converter) groupKeys(keys []string, skipped map[string]bool) []keyGroup {
+	var groups []keyGroup
+	index := make(map[string]int)
+	for _, key := range keys {
+		target := key
+		if !skipped[key] {
+			target = c.targetKey(key)
+		}
+		if i, ok := index[target]; ok {
+			groups[i].keys = append(groups[i].keys, key)
+			continue
+		}
+		index[target] = len(groups)
+		groups = append(groups, keyGroup{target: target, keys: []string{key}})
+	}
+	return groups
+}
+
+// resolve returns the keys of a group whose values are kept. The first one
+// takes precedence; any others are merged beneath it.
+func (c *Converter) resolve(group keyGroup, path string, mergeable bool, result *Result) []string {
+	if len(group.keys) == 1 {
+		return group.keys
+	}
+
+	policy := c.policy()
+	kept := group.keys[0]
+	if policy == CollisionKeepCamel || policy == CollisionMerge {
+		for _, key := range group.keys {
+			if key == group.target {
+				kept = key
+				break
+			}
+		}
+	}
+
+	collision := Collision{Path: path, Keys: group.keys, Kept: kept, Policy: policy}
+	keys := []string{kept}
+	if policy == CollisionMerge && mergeable {
+		collision.Merged = true
+		for _, key := range group.keys {
+			if key != kept {
+				keys = append(keys, key)
+			}
+		}
+	}
+	result.Collisions = append(result.Collisions, collision)
+	return keys
+}
+
+// policy returns the collision policy, failing by default
+func (c *Converter) policy() CollisionPolicy {
+	if c.CollisionPolicy == "" {
+		return CollisionFail
+	}
+	return c.CollisionPolicy
+}
+
+// collisionError returns the collisions of a result as an error under CollisionFail
+func (c *Converter) collisionError(result *Result) error {
+	if c.policy() != CollisionFail || len(result.Collisions) == 0 {
+		return nil
+	}
+	return &CollisionError{Collisions: result.Collisions}
+}
+
+// allMaps reports whether the values of keys are all maps
+func allMaps(m map[string]interface{}, keys []string) bool {
+	for _, key := range keys {
+		switch m[key].(type) {
+		case map[string]interface{}, map[interface{}]interface{}:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// allMappingNodes reports whether the values of keys are all mapping nodes
+func allMappingNodes(values map[string]*yaml.Node, keys []string) bool {
+	for _, key := range keys {
+		if values[key].Kind != yaml.MappingNode {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeMaps adds the entries of src missing from dst, merging nested maps
+func mergeMaps(dst, src map[string]interface{}) {
+	for key, value := range src {
+		existing, ok := dst[key]
+		if !ok {
+			dst[key] = value
+			continue
+		}
+		existingMap, dstIsMap := existing.(map[string]interface{})
+		valueMap, srcIsMap := value.(map[string]interface{})
+		if dstIsMap && srcIsMap {
+			mergeMaps(existingMap, valueMap)
+		}
+	}
+}
+
+// mergeMappingNodes adds the pairs of src missing from dst, merging nested mappings
+func mergeMappingNodes(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Children); i += 2 {
+		keyNode, valueNode := src.Children[i], src.Children[i+1]
+		existing := dst.GetMapValue(fmt.Sprintf("%v", keyNode.Value))
+		if existing == nil {
+			keyNode.Parent, valueNode.Parent = dst, dst
+			dst.Children = append(dst.Children, keyNode, valueNode)
+			continue
+		}
+		if existing.Kind == yaml.MappingNode && valueNode.Kind == yaml.MappingNode {
+			mergeMappingNodes(existing, valueNode)
+		}
+	}
+}
+
+// joinPath appends a key to a dot-separated path
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// indexPath appends a sequence index to a path
+func indexPath(path string, index int) string {
+	return fmt.Sprintf("%s[%d]", path, index)
+}
@@ -0,0 +1,167 @@
+package keycase
+
+import (
+	"path/filepath"
+	"testing"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collidingValues() map[string]interface{} {
+	return map[string]interface{}{
+		"db_host": "legacy.db",
+		"dbHost":  "camel.db",
+		"cache_settings": map[string]interface{}{
+			"ttl_seconds": 30,
+			"size":        10,
+		},
+		"cacheSettings": map[string]interface{}{
+			"size": 20,
+		},
+		"items": []interface{}{
+			map[string]interface{}{"item_name": "a"},
+		},
+	}
+}
+
+func convertWithPolicy(t *testing.T, policy CollisionPolicy) (map[string]interface{}, *Result) {
+	t.Helper()
+	converter := NewConverter()
+	converter.CollisionPolicy = policy
+	converted, result, err := converter.Convert(collidingValues())
+	require.NoError(t, err)
+	return converted.(map[string]interface{}), result
+}
+
+func TestConvert_CollisionFail(t *testing.T) {
+	_, result, err := NewConverter().Convert(collidingValues())
+
+	var collisionErr *CollisionError
+	require.ErrorAs(t, err, &collisionErr)
+	assert.Len(t, collisionErr.Collisions, 2)
+	assert.Contains(t, err.Error(), "dbHost (from dbHost, db_host)")
+	assert.Contains(t, err.Error(), "cacheSettings (from cacheSettings, cache_settings)")
+	assert.Len(t, result.Collisions, 2)
+}
+
+func TestConvert_CollisionKeepFirst(t *testing.T) {
+	converted, result := convertWithPolicy(t, CollisionKeepFirst)
+
+	// Plain maps are visited in sorted order, so the camelCase keys come first
+	assert.Equal(t, "camel.db", converted["dbHost"])
+	assert.Equal(t, map[string]interface{}{"size": 20}, converted["cacheSettings"])
+	require.Len(t, result.Collisions, 2)
+	assert.Equal(t, "cacheSettings", result.Collisions[0].Kept)
+	assert.False(t, result.Collisions[0].Merged)
+}
+
+func TestConvert_CollisionKeepCamel(t *testing.T) {
+	converter := NewConverter()
+	converter.CollisionPolicy = CollisionKeepCamel
+	converted, result, err := converter.Convert(map[string]interface{}{
+		"Db-Host": "kebab.db",
+		"db_host": "snake.db",
+	})
+	require.NoError(t, err)
+
+	// Neither key is in camelCase, so the first one is kept
+	assert.Equal(t, map[string]interface{}{"dbHost": "kebab.db"}, converted)
+	require.Len(t, result.Collisions, 1)
+	assert.Equal(t, Collision{
+		Path:   "dbHost",
+		Keys:   []string{"Db-Host", "db_host"},
+		Kept:   "Db-Host",
+		Policy: CollisionKeepCamel,
+	}, result.Collisions[0])
+}
+
+func TestConvert_CollisionMerge(t *testing.T) {
+	converted, result := convertWithPolicy(t, CollisionMerge)
+
+	assert.Equal(t, map[string]interface{}{"size": 20, "ttlSeconds": 30}, converted["cacheSettings"])
+	assert.Equal(t, "camel.db", converted["dbHost"], "scalars cannot be merged, the camelCase key wins")
+
+	require.Len(t, result.Collisions, 2)
+	assert.True(t, result.Collisions[0].Merged)
+	assert.False(t, result.Collisions[1].Merged)
+	assert.Equal(t, "cacheSettings", result.Renames["cache_settings"])
+	assert.Equal(t, "cacheSettings.ttlSeconds", result.Renames["cache_settings.ttl_seconds"])
+}
+
+func TestConvert_RenameMap(t *testing.T) {
+	_, result := convertWithPolicy(t, CollisionKeepCamel)
+
+	// Dropped keys and unchanged paths are not listed
+	assert.Equal(t, RenameMap{"items[0].item_name": "items[0].itemName"}, result.Renames)
+}
+
+func TestConvertTree_Collisions(t *testing.T) {
+	input := `# settings
+db_host: legacy.db # snake
+dbHost: camel.db
+cache_settings:
+  ttl_seconds: 30
+cacheSettings:
+  size: 20 # kept
+`
+	tests := []struct {
+		policy   CollisionPolicy
+		expected string
+	}{
+		{CollisionKeepFirst, "# settings\ndbHost: legacy.db # snake\ncacheSettings:\n  ttlSeconds: 30\n"},
+		{CollisionKeepCamel, "# settings\ndbHost: camel.db\ncacheSettings:\n  size: 20 # kept\n"},
+		{CollisionMerge, "# settings\ndbHost: camel.db\ncacheSettings:\n  size: 20 # kept\n  ttlSeconds: 30\n"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			tree, err := yaml.UnmarshalYAML([]byte(input))
+			require.NoError(t, err)
+
+			converter := NewConverter()
+			converter.CollisionPolicy = tt.policy
+			result, err := converter.ConvertTree(tree)
+			require.NoError(t, err)
+			assert.Len(t, result.Collisions, 2)
+
+			output, err := tree.ToYAML()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(output))
+		})
+	}
+
+	_, err := NewConverter().ConvertDocument([]byte(input))
+	assert.ErrorContains(t, err, "dbHost (from db_host, dbHost)", "documents keep their key order")
+}
+
+func TestParseCollisionPolicy(t *testing.T) {
+	policy, err := ParseCollisionPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, CollisionFail, policy)
+
+	policy, err = ParseCollisionPolicy("Keep-Camel")
+	require.NoError(t, err)
+	assert.Equal(t, CollisionKeepCamel, policy)
+
+	_, err = ParseCollisionPolicy("last-wins")
+	assert.ErrorContains(t, err, `invalid collision policy "last-wins"`)
+}
+
+func TestResult_SaveAndMerge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), RenamesFilename)
+
+	saved, err := LoadResult(path)
+	require.NoError(t, err)
+	assert.True(t, saved.IsEmpty())
+
+	collision := Collision{Path: "dbHost", Keys: []string{"db_host", "dbHost"}, Kept: "dbHost", Policy: CollisionKeepCamel}
+	saved.Merge(&Result{Renames: RenameMap{"db_host": "dbHost"}, Collisions: []Collision{collision}})
+	saved.Merge(&Result{Renames: RenameMap{"image_tag": "imageTag"}, Collisions: []Collision{collision}})
+	require.NoError(t, saved.Save(path))
+
+	loaded, err := LoadResult(path)
+	require.NoError(t, err)
+	assert.Equal(t, RenameMap{"db_host": "dbHost", "image_tag": "imageTag"}, loaded.Renames)
+	assert.Equal(t, []Collision{collision}, loaded.Collisions, "the same collision is listed once")
+}
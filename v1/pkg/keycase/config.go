@@ -0,0 +1,73 @@
+package keycase
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"helm-charts-migrator/v1/pkg/config"
+)
+
+// indexPattern matches the sequence indexes of a key path
+var indexPattern = regexp.MustCompile(`\[\d+\]`)
+
+// NewFromConfig creates a converter from converter settings, such as the ones
+// returned by config.Config.GetConverterConfig for a service
+func NewFromConfig(cfg config.ConverterConfig) (*Converter, error) {
+	converter := NewConverter()
+	converter.SkipJavaProperties = cfg.SkipJavaProperties
+	converter.SkipUppercaseKeys = cfg.SkipUppercaseKeys
+	if cfg.MinUppercaseChars > 0 {
+		converter.MinUppercaseChars = cfg.MinUppercaseChars
+	}
+	for _, key := range cfg.PreserveKeys {
+		converter.PreserveSpecialKeys[key] = true
+	}
+
+	policy, err := ParseCollisionPolicy(cfg.CollisionPolicy)
+	if err != nil {
+		return nil, err
+	}
+	converter.CollisionPolicy = policy
+
+	style, err := ParseStyle(cfg.TargetStyle)
+	if err != nil {
+		return nil, err
+	}
+	converter.TargetStyle = style
+
+	for _, pattern := range cfg.SkipPaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid skip path %q: %w", pattern, err)
+		}
+	}
+	converter.SkipPaths = cfg.SkipPaths
+
+	return converter, nil
+}
+
+// skipPath reports whether a key is left as is, with everything beneath it,
+// because its old or converted path matches a skip path. Sequence indexes are
+// ignored, so "containers.env" matches "containers[0].env".
+func (c *Converter) skipPath(oldPath, newPath string) bool {
+	if len(c.SkipPaths) == 0 {
+		return false
+	}
+
+	oldPath = indexPattern.ReplaceAllString(oldPath, "")
+	newPath = indexPattern.ReplaceAllString(newPath, "")
+	for _, pattern := range c.SkipPaths {
+		if matchPath(pattern, oldPath) || matchPath(pattern, newPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath matches a dot-separated path against a glob, "*" matching one key
+func matchPath(pattern, keyPath string) bool {
+	// Dots become slashes so "*" stops at a key
+	matched, err := path.Match(strings.ReplaceAll(pattern, ".", "/"), strings.ReplaceAll(keyPath, ".", "/"))
+	return err == nil && matched
+}
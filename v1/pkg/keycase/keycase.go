@@ -1,14 +1,19 @@
-// Package keycase provides utilities for converting YAML keys to camelCase
+// Package keycase provides utilities for converting YAML keys to camelCase, or
+// to another configured style
 package keycase
 
 import (
 	"regexp"
+	"sort"
 	"strings"
 	"unicode"
 
 	yaml "github.com/elioetibr/golang-yaml-advanced"
 )
 
+// mergeKey is the YAML merge key, which must never be renamed
+const mergeKey = "<<"
+
 // Converter handles key case conversion with configurable rules
 type Converter struct {
 	// SkipJavaProperties skips keys that look like Java properties (contain dots)
@@ -19,6 +24,13 @@ type Converter struct {
 	MinUppercaseChars int
 	// PreserveSpecialKeys is a map of keys to always preserve
 	PreserveSpecialKeys map[string]bool
+	// CollisionPolicy decides what happens when keys of a map convert to the same key
+	CollisionPolicy CollisionPolicy
+	// TargetStyle is the case keys are converted to, camelCase by default
+	TargetStyle Style
+	// SkipPaths are globs of key paths, such as "configMap.*", left as is
+	// with everything beneath them
+	SkipPaths []string
 }
 
 // NewConverter creates a new converter with sensible defaults
@@ -28,25 +40,151 @@ func NewConverter() *Converter {
 		SkipUppercaseKeys:   true,
 		MinUppercaseChars:   3,
 		PreserveSpecialKeys: make(map[string]bool),
+		CollisionPolicy:     CollisionFail,
+		TargetStyle:         StyleCamel,
 	}
 }
 
-// ConvertDocument converts all keys in a YAML document to camelCase
+// ConvertDocument converts all keys in a YAML document to camelCase, keeping
+// its comments, anchors, key order and scalar styles
 func (c *Converter) ConvertDocument(data []byte) ([]byte, error) {
-	var doc interface{}
-	if err := yaml.Unmarshal(data, &doc); err != nil {
+	tree, err := yaml.UnmarshalYAML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.ConvertTree(tree); err != nil {
 		return nil, err
 	}
+	return tree.ToYAML()
+}
+
+// ConvertTree renames the keys of every document in the tree in place
+func (c *Converter) ConvertTree(tree *yaml.NodeTree) (*Result, error) {
+	result := NewResult()
+	for _, doc := range tree.Documents {
+		c.convertNode(doc.Root, "", "", result)
+	}
+	return result, c.collisionError(result)
+}
+
+// ConvertNode renames the mapping keys under a node in place. Only the key
+// values change, so comments, anchors, order and styles are left untouched;
+// keys dropped by the collision policy are removed with their values.
+func (c *Converter) ConvertNode(node *yaml.Node) (*Result, error) {
+	result := NewResult()
+	c.convertNode(node, "", "", result)
+	return result, c.collisionError(result)
+}
+
+// convertNode converts a node whose path was oldPath and is now newPath
+func (c *Converter) convertNode(node *yaml.Node, oldPath, newPath string, result *Result) {
+	if node == nil {
+		return
+	}
+
+	// Aliases have no children of their own; the anchored node is converted
+	// where it is defined
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Children {
+			c.convertNode(child, oldPath, newPath, result)
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Children {
+			c.convertNode(item, indexPath(oldPath, i), indexPath(newPath, i), result)
+		}
+	case yaml.MappingNode:
+		c.convertMappingNode(node, oldPath, newPath, result)
+	}
+}
+
+// convertMappingNode renames the keys of a mapping node and resolves collisions
+func (c *Converter) convertMappingNode(node *yaml.Node, oldPath, newPath string, result *Result) {
+	var keys []string
+	keyNodes := make(map[string]*yaml.Node)
+	values := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(node.Children); i += 2 {
+		keyNode := node.Children[i]
+		key, ok := keyNode.Value.(string)
+		if !ok || keyNode.Kind != yaml.ScalarNode {
+			continue
+		}
+		if key == mergeKey {
+			// The explicit tag would be written out as "!!merge <<"
+			keyNode.Tag = ""
+			continue
+		}
+		keys = append(keys, key)
+		keyNodes[key] = keyNode
+		values[key] = node.Children[i+1]
+	}
+
+	skipped := c.skippedKeys(keys, oldPath, newPath)
+	dropped := make(map[*yaml.Node]bool)
+	for _, group := range c.groupKeys(keys, skipped) {
+		target := joinPath(newPath, group.target)
+		kept := c.resolve(group, target, allMappingNodes(values, group.keys), result)
+
+		for i, key := range kept {
+			oldKeyPath := joinPath(oldPath, key)
+			result.addRename(oldKeyPath, target)
+			if !skipped[key] {
+				c.convertNode(values[key], oldKeyPath, target, result)
+			}
+			if i > 0 {
+				mergeMappingNodes(values[kept[0]], values[key])
+			}
+		}
+		keptNode := keyNodes[kept[0]]
+		for _, key := range group.keys {
+			if key != kept[0] {
+				// Comments above a dropped key, such as a file header, move to the kept one
+				keptNode.HeadComment = append(keyNodes[key].HeadComment, keptNode.HeadComment...)
+				dropped[keyNodes[key]] = true
+			}
+		}
+		keptNode.Value = group.target
+	}
+
+	if len(dropped) == 0 {
+		return
+	}
+	children := make([]*yaml.Node, 0, len(node.Children))
+	for i := 0; i+1 < len(node.Children); i += 2 {
+		if !dropped[node.Children[i]] {
+			children = append(children, node.Children[i], node.Children[i+1])
+		}
+	}
+	node.Children = children
+}
 
-	converted := c.ConvertValue(doc)
-	return yaml.Marshal(converted)
+// Convert converts all keys in a value to camelCase and describes the renames
+// and collisions. Under CollisionFail collisions are returned as a *CollisionError.
+func (c *Converter) Convert(value interface{}) (interface{}, *Result, error) {
+	result := NewResult()
+	converted := c.convertValue(value, "", "", result)
+	return converted, result, c.collisionError(result)
 }
 
 // ConvertValue recursively converts all keys in a value to camelCase
-func (c *Converter) ConvertValue(value interface{}) interface{} {
+func (c *Converter) ConvertValue(value interface{}) (interface{}, error) {
+	converted, _, err := c.Convert(value)
+	return converted, err
+}
+
+// ConvertMap converts all keys in a map to camelCase
+func (c *Converter) ConvertMap(m map[string]interface{}) (map[string]interface{}, error) {
+	result := NewResult()
+	converted := c.convertMap(m, "", "", result)
+	return converted, c.collisionError(result)
+}
+
+// convertValue converts a value whose path was oldPath and is now newPath
+func (c *Converter) convertValue(value interface{}, oldPath, newPath string, result *Result) interface{} {
 	switch v := value.(type) {
 	case map[string]interface{}:
-		return c.ConvertMap(v)
+		return c.convertMap(v, oldPath, newPath, result)
 	case map[interface{}]interface{}:
 		// Convert to map[string]interface{} first
 		stringMap := make(map[string]interface{})
@@ -55,34 +193,73 @@ func (c *Converter) ConvertValue(value interface{}) interface{} {
 				stringMap[strKey] = val
 			}
 		}
-		return c.ConvertMap(stringMap)
+		return c.convertMap(stringMap, oldPath, newPath, result)
 	case []interface{}:
-		result := make([]interface{}, len(v))
+		converted := make([]interface{}, len(v))
 		for i, item := range v {
-			result[i] = c.ConvertValue(item)
+			converted[i] = c.convertValue(item, indexPath(oldPath, i), indexPath(newPath, i), result)
 		}
-		return result
+		return converted
 	default:
 		return value
 	}
 }
 
-// ConvertMap converts all keys in a map to camelCase
-func (c *Converter) ConvertMap(m map[string]interface{}) map[string]interface{} {
-	result := make(map[string]interface{})
-
-	for key, value := range m {
-		// Convert the key if it should be converted
-		newKey := key
-		if c.shouldConvert(key) {
-			newKey = c.convertKey(key)
+// convertMap converts the keys of a map and resolves collisions. Maps have no
+// key order, so keys are visited sorted to keep "first" deterministic.
+func (c *Converter) convertMap(m map[string]interface{}, oldPath, newPath string, result *Result) map[string]interface{} {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	skipped := c.skippedKeys(keys, oldPath, newPath)
+	converted := make(map[string]interface{}, len(m))
+	for _, group := range c.groupKeys(keys, skipped) {
+		target := joinPath(newPath, group.target)
+		kept := c.resolve(group, target, allMaps(m, group.keys), result)
+
+		var value interface{}
+		for i, key := range kept {
+			oldKeyPath := joinPath(oldPath, key)
+			result.addRename(oldKeyPath, target)
+			convertedValue := m[key]
+			if !skipped[key] {
+				convertedValue = c.convertValue(m[key], oldKeyPath, target, result)
+			}
+			if i == 0 {
+				value = convertedValue
+				continue
+			}
+			mergeMaps(value.(map[string]interface{}), convertedValue.(map[string]interface{}))
 		}
+		converted[group.target] = value
+	}
 
-		// Recursively convert the value
-		result[newKey] = c.ConvertValue(value)
+	return converted
+}
+
+// targetKey returns the key a key converts to
+func (c *Converter) targetKey(key string) string {
+	if !c.shouldConvert(key) {
+		return key
 	}
+	if c.style() != StyleCamel {
+		return formatKey(key, c.style())
+	}
+	return c.convertKey(key)
+}
 
-	return result
+// skippedKeys returns the keys of a map matching a skip path
+func (c *Converter) skippedKeys(keys []string, oldPath, newPath string) map[string]bool {
+	skipped := make(map[string]bool)
+	for _, key := range keys {
+		if c.skipPath(joinPath(oldPath, key), joinPath(newPath, c.targetKey(key))) {
+			skipped[key] = true
+		}
+	}
+	return skipped
 }
 
 // shouldConvert determines if a key should be converted
@@ -114,7 +291,7 @@ func (c *Converter) shouldConvert(key string) bool {
 	}
 
 	// Skip if already in camelCase
-	if isCamelCase(key) {
+	if c.style() == StyleCamel && isCamelCase(key) {
 		return false
 	}
 
@@ -269,10 +446,10 @@ func (c *Converter) convertMapWithStats(m map[string]interface{}, stats *Stats)
 		} else if looksLikeUUID(key) {
 			stats.UUIDKeys++
 			stats.SkippedKeys++
-		} else if isCamelCase(key) {
+		} else if c.style() == StyleCamel && isCamelCase(key) {
 			stats.AlreadyCamelCase++
 		} else {
-			newKey = c.convertKey(key)
+			newKey = c.targetKey(key)
 			stats.ConvertedKeys++
 		}
 
@@ -0,0 +1,69 @@
+package keycase
+
+import (
+	"testing"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertDocument_PreservesLayout(t *testing.T) {
+	input := `# Legacy values
+zeta_key: 1 # first key
+defaults: &defaults
+  max_connections: 10
+alpha-key:
+  <<: *defaults
+  nested_value: "quoted" # keeps its style
+  other_value: 'single'
+  spring.datasource_url: jdbc
+  AWS_REGION: us-east-1
+list_items:
+  - item_name: a
+  - plain
+`
+	expected := `# Legacy values
+zetaKey: 1 # first key
+defaults: &defaults
+  maxConnections: 10
+alphaKey:
+  <<: *defaults
+  nestedValue: "quoted" # keeps its style
+  otherValue: 'single'
+  spring.datasource_url: jdbc
+  AWS_REGION: us-east-1
+listItems:
+  - itemName: a
+  - plain
+`
+
+	output, err := NewConverter().ConvertDocument([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(output))
+}
+
+func TestConvertTree_MultipleDocuments(t *testing.T) {
+	tree, err := yaml.UnmarshalYAML([]byte("first_doc: 1\n---\nsecond_doc: 2\n"))
+	require.NoError(t, err)
+
+	_, err = NewConverter().ConvertTree(tree)
+	require.NoError(t, err)
+
+	require.Len(t, tree.Documents, 2)
+	root := tree.Documents[1].Root.Children[0]
+	assert.NotNil(t, root.GetMapValue("secondDoc"))
+	assert.Nil(t, root.GetMapValue("second_doc"))
+}
+
+func TestConvertNode_LeavesValuesAlone(t *testing.T) {
+	tree, err := yaml.UnmarshalYAML([]byte("image_tag: snake_case_value\nlist:\n  - snake_item\n"))
+	require.NoError(t, err)
+
+	_, err = NewConverter().ConvertNode(tree.Documents[0].Root)
+	require.NoError(t, err)
+
+	output, err := tree.ToYAML()
+	require.NoError(t, err)
+	assert.Equal(t, "imageTag: snake_case_value\nlist:\n  - snake_item\n", string(output))
+}
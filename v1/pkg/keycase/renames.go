@@ -0,0 +1,102 @@
+package keycase
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+)
+
+// RenamesFilename is the file a service's rename map is saved to, next to its Chart.yaml
+const RenamesFilename = "key-renames.yaml"
+
+// RenameMap maps the old path of every key whose path changed to its new path.
+// Paths are dot-separated, with [i] for sequence items.
+type RenameMap map[string]string
+
+// Result describes a conversion: the paths renamed and the collisions resolved
+type Result struct {
+	Renames    RenameMap   `yaml:"renames,omitempty"`
+	Collisions []Collision `yaml:"collisions,omitempty"`
+}
+
+// NewResult creates an empty result
+func NewResult() *Result {
+	return &Result{Renames: make(RenameMap)}
+}
+
+// addRename records a path change
+func (r *Result) addRename(oldPath, newPath string) {
+	if oldPath != newPath {
+		r.Renames[oldPath] = newPath
+	}
+}
+
+// IsEmpty reports whether nothing was renamed and no collision was found
+func (r *Result) IsEmpty() bool {
+	return len(r.Renames) == 0 && len(r.Collisions) == 0
+}
+
+// Merge adds the renames and collisions of another result, such as the one of
+// another environment of the same service
+func (r *Result) Merge(other *Result) {
+	if r.Renames == nil {
+		r.Renames = make(RenameMap)
+	}
+	for oldPath, newPath := range other.Renames {
+		r.Renames[oldPath] = newPath
+	}
+
+	for _, collision := range other.Collisions {
+		if !r.hasCollision(collision) {
+			r.Collisions = append(r.Collisions, collision)
+		}
+	}
+}
+
+// hasCollision reports whether the same keys already collided at the same path
+func (r *Result) hasCollision(collision Collision) bool {
+	for _, existing := range r.Collisions {
+		if existing.Path == collision.Path && strings.Join(existing.Keys, "\x00") == strings.Join(collision.Keys, "\x00") {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadResult reads a saved rename map; a missing file is an empty result
+func LoadResult(path string) (*Result, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewResult(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rename map: %w", err)
+	}
+
+	result := NewResult()
+	if err := yaml.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("failed to parse rename map %s: %w", path, err)
+	}
+	if result.Renames == nil {
+		result.Renames = make(RenameMap)
+	}
+	return result, nil
+}
+
+// Save writes the rename map
+func (r *Result) Save(path string) error {
+	data, err := yaml.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rename map: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write rename map: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package keycase
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Style is the case keys are converted to
+type Style string
+
+const (
+	// StyleCamel converts keys to camelCase, the Helm convention
+	StyleCamel Style = "camelCase"
+	// StyleSnake converts keys to snake_case
+	StyleSnake Style = "snake_case"
+	// StyleKebab converts keys to kebab-case
+	StyleKebab Style = "kebab-case"
+	// StylePascal converts keys to PascalCase
+	StylePascal Style = "PascalCase"
+)
+
+// ParseStyle parses a target style; an empty name is StyleCamel
+func ParseStyle(name string) (Style, error) {
+	switch strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name)) {
+	case "", "camelcase", "camel":
+		return StyleCamel, nil
+	case "snakecase", "snake":
+		return StyleSnake, nil
+	case "kebabcase", "kebab":
+		return StyleKebab, nil
+	case "pascalcase", "pascal":
+		return StylePascal, nil
+	default:
+		return "", fmt.Errorf("invalid target style %q, expected camelCase, snake_case, kebab-case or PascalCase", name)
+	}
+}
+
+// style returns the target style, camelCase by default
+func (c *Converter) style() Style {
+	if c.TargetStyle == "" {
+		return StyleCamel
+	}
+	return c.TargetStyle
+}
+
+// formatKey converts a key to a style other than camelCase
+func formatKey(key string, style Style) string {
+	words := splitWords(key)
+	if len(words) == 0 {
+		return key
+	}
+
+	switch style {
+	case StyleSnake:
+		return strings.ToLower(strings.Join(words, "_"))
+	case StyleKebab:
+		return strings.ToLower(strings.Join(words, "-"))
+	case StylePascal:
+		var b strings.Builder
+		for _, word := range words {
+			b.WriteString(capitalize(strings.ToLower(word)))
+		}
+		return b.String()
+	default:
+		return key
+	}
+}
+
+// splitWords splits a key into words at underscores, hyphens, spaces and case
+// changes; an acronym stays one word, so "apiURLPath" is api, URL, Path
+func splitWords(key string) []string {
+	var words []string
+	var current []rune
+	runes := []rune(key)
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = nil
+		}
+	}
+
+	for i, r := range runes {
+		if r == '_' || r == '-' || unicode.IsSpace(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && len(current) > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+
+	return words
+}
+
+// capitalize upper-cases the first letter of a word
+func capitalize(word string) string {
+	runes := []rune(word)
+	if len(runes) == 0 {
+		return word
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
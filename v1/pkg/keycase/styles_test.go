@@ -0,0 +1,136 @@
+package keycase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm-charts-migrator/v1/pkg/config"
+)
+
+func TestParseStyle(t *testing.T) {
+	tests := map[string]Style{
+		"":           StyleCamel,
+		"camelCase":  StyleCamel,
+		"snake_case": StyleSnake,
+		"kebab-case": StyleKebab,
+		"PascalCase": StylePascal,
+		"pascal":     StylePascal,
+	}
+	for name, expected := range tests {
+		style, err := ParseStyle(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, style, name)
+	}
+
+	_, err := ParseStyle("SCREAMING")
+	assert.Error(t, err)
+}
+
+func TestConvertMap_TargetStyles(t *testing.T) {
+	input := map[string]interface{}{
+		"db_host":    "localhost",
+		"maxRetries": 3,
+		"api-URLPath": map[string]interface{}{
+			"read_timeout": 5,
+		},
+	}
+
+	tests := []struct {
+		style    Style
+		expected map[string]interface{}
+	}{
+		{StyleSnake, map[string]interface{}{
+			"db_host":      "localhost",
+			"max_retries":  3,
+			"api_url_path": map[string]interface{}{"read_timeout": 5},
+		}},
+		{StyleKebab, map[string]interface{}{
+			"db-host":      "localhost",
+			"max-retries":  3,
+			"api-url-path": map[string]interface{}{"read-timeout": 5},
+		}},
+		{StylePascal, map[string]interface{}{
+			"DbHost":     "localhost",
+			"MaxRetries": 3,
+			"ApiUrlPath": map[string]interface{}{"ReadTimeout": 5},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.style), func(t *testing.T) {
+			converter := NewConverter()
+			converter.TargetStyle = tt.style
+
+			output, err := converter.ConvertMap(input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, output)
+		})
+	}
+}
+
+func TestConvertTree_SkipPaths(t *testing.T) {
+	input := `config_map:
+  LOG_LEVEL: debug
+  feature_flag: "on"
+env:
+  spring_profile: prod
+containers:
+  - env:
+      some_var: x
+    image_tag: latest
+`
+	expected := `configMap:
+  LOG_LEVEL: debug
+  feature_flag: "on"
+env:
+  spring_profile: prod
+containers:
+  - env:
+      some_var: x
+    imageTag: latest
+`
+
+	converter := NewConverter()
+	converter.SkipPaths = []string{"configMap.*", "env", "containers.env"}
+
+	output, err := converter.ConvertDocument([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(output))
+}
+
+func TestNewFromConfig(t *testing.T) {
+	converter, err := NewFromConfig(config.ConverterConfig{
+		SkipJavaProperties: true,
+		CollisionPolicy:    "merge",
+		TargetStyle:        "snake_case",
+		PreserveKeys:       []string{"keepThisKey"},
+		SkipPaths:          []string{"annotations.*"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, converter.MinUppercaseChars)
+	assert.Equal(t, CollisionMerge, converter.CollisionPolicy)
+
+	output, renames, err := converter.Convert(map[string]interface{}{
+		"keepThisKey": 1,
+		"otherKey":    2,
+		"annotations": map[string]interface{}{
+			"prometheusScrape": map[string]interface{}{"innerKey": true},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"keepThisKey": 1,
+		"other_key":   2,
+		"annotations": map[string]interface{}{
+			"prometheusScrape": map[string]interface{}{"innerKey": true},
+		},
+	}, output)
+	assert.Equal(t, RenameMap{"otherKey": "other_key"}, renames.Renames)
+
+	_, err = NewFromConfig(config.ConverterConfig{SkipPaths: []string{"[invalid"}})
+	assert.Error(t, err)
+	_, err = NewFromConfig(config.ConverterConfig{TargetStyle: "upper"})
+	assert.Error(t, err)
+}
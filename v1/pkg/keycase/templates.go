@@ -0,0 +1,327 @@
+package keycase
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+	"unicode"
+)
+
+// valuesIdent is the field holding the chart values in a template
+const valuesIdent = "Values"
+
+// TemplateReference is a reference to the chart values in a template, such as
+// .Values.Database_Url, $.Values.db or index .Values "Database_Url"
+type TemplateReference struct {
+	File     string `yaml:"file"`
+	Line     int    `yaml:"line"`
+	Path     string `yaml:"path"`              // referenced path, as written
+	NewPath  string `yaml:"newPath,omitempty"` // path after the renames, when resolved
+	Resolved bool   `yaml:"resolved"`
+}
+
+func (r TemplateReference) String() string {
+	return fmt.Sprintf("%s:%d: .Values.%s", r.File, r.Line, r.Path)
+}
+
+// templateEdit replaces the text between start and end
+type templateEdit struct {
+	start, end  int
+	replacement string
+}
+
+// valuesSegment is one key of a reference and where it is written. A key
+// written as a string argument of index has its own position; keys of a
+// selector share the selector's.
+type valuesSegment struct {
+	key    string
+	quoted string // the string argument as written, for index arguments
+	pos    int
+}
+
+// templateRewriter collects the references and edits of one template
+type templateRewriter struct {
+	converter  *Converter
+	name       string
+	text       string
+	renames    RenameMap
+	edits      []templateEdit
+	references []TemplateReference
+}
+
+// RewriteTemplate rewrites the values references of a Go template using a
+// rename map. References into renamed keys are rewritten; references into keys
+// that would be converted but have no rename are left as they are and returned
+// unresolved. References already in the target style are neither changed nor
+// returned.
+func (c *Converter) RewriteTemplate(name string, content []byte, renames RenameMap) ([]byte, []TemplateReference, error) {
+	text := string(content)
+	tree := parse.New(name)
+	tree.Mode = parse.SkipFuncCheck
+	trees := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(text, "", "", trees); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+
+	rewriter := &templateRewriter{converter: c, name: name, text: text, renames: renames}
+	names := make([]string, 0, len(trees))
+	for treeName := range trees {
+		names = append(names, treeName)
+	}
+	sort.Strings(names)
+	for _, treeName := range names {
+		rewriter.walk(trees[treeName].Root)
+	}
+
+	sort.SliceStable(rewriter.references, func(i, j int) bool {
+		return rewriter.references[i].Line < rewriter.references[j].Line
+	})
+	return []byte(rewriter.apply()), rewriter.references, nil
+}
+
+// walk visits the nodes of a template
+func (r *templateRewriter) walk(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			r.walk(child)
+		}
+	case *parse.ActionNode:
+		r.walk(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			r.walk(cmd)
+		}
+	case *parse.CommandNode:
+		r.walkCommand(n)
+	case *parse.IfNode:
+		r.walkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		r.walkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		r.walkBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		r.walk(n.Pipe)
+	case *parse.ChainNode:
+		r.walk(n.Node)
+	case *parse.FieldNode:
+		r.reference(n.Ident, int(n.Pos), nil)
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 {
+			r.reference(n.Ident[1:], int(n.Pos), nil)
+		}
+	}
+}
+
+// walkBranch visits an if, range or with
+func (r *templateRewriter) walkBranch(branch *parse.BranchNode) {
+	r.walk(branch.Pipe)
+	r.walk(branch.List)
+	r.walk(branch.ElseList)
+}
+
+// walkCommand visits a command, reading index .Values "a" "b" as one reference
+func (r *templateRewriter) walkCommand(cmd *parse.CommandNode) {
+	args := cmd.Args
+	if len(args) > 2 {
+		if ident, ok := args[0].(*parse.IdentifierNode); ok && ident.Ident == "index" {
+			if idents, pos, ok := valuesIdents(args[1]); ok {
+				var keys []valuesSegment
+				rest := 2
+				for ; rest < len(args); rest++ {
+					str, ok := args[rest].(*parse.StringNode)
+					if !ok {
+						break
+					}
+					keys = append(keys, valuesSegment{key: str.Text, quoted: str.Quoted, pos: int(str.Pos)})
+				}
+				r.reference(idents, pos, keys)
+				for _, arg := range args[rest:] {
+					r.walk(arg)
+				}
+				return
+			}
+		}
+	}
+
+	for _, arg := range args {
+		r.walk(arg)
+	}
+}
+
+// valuesIdents returns the selector of a .Values or $.Values node
+func valuesIdents(node parse.Node) ([]string, int, bool) {
+	switch n := node.(type) {
+	case *parse.FieldNode:
+		if len(n.Ident) > 0 && n.Ident[0] == valuesIdent {
+			return n.Ident, int(n.Pos), true
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[1] == valuesIdent {
+			return n.Ident[1:], int(n.Pos), true
+		}
+	}
+	return nil, 0, false
+}
+
+// reference resolves a selector such as [Values, Database_Url] followed by the
+// string arguments of index, recording an edit when a key was renamed
+func (r *templateRewriter) reference(idents []string, pos int, indexKeys []valuesSegment) {
+	if len(idents) == 0 || idents[0] != valuesIdent {
+		return
+	}
+	if len(idents) == 1 && len(indexKeys) == 0 {
+		return
+	}
+
+	// The node position is not always the start of the selector, so it is
+	// looked up around it
+	selector := "." + strings.Join(idents, ".")
+	start, found := locate(r.text, selector, pos)
+	if !found {
+		return
+	}
+
+	segments := make([]valuesSegment, 0, len(idents)-1+len(indexKeys))
+	for _, ident := range idents[1:] {
+		segments = append(segments, valuesSegment{key: ident, pos: start})
+	}
+	segments = append(segments, indexKeys...)
+
+	oldKeys := make([]string, len(segments))
+	for i, segment := range segments {
+		oldKeys[i] = segment.key
+	}
+	reference := TemplateReference{
+		File: r.name,
+		Line: 1 + strings.Count(r.text[:start], "\n"),
+		Path: strings.Join(oldKeys, "."),
+	}
+
+	// Selector keys must stay identifiers, which kebab-case keys are not
+	selectorKeys := len(idents) - 1
+	newKeys, resolved := r.converter.resolveReference(oldKeys, r.renames)
+	for i := 0; resolved && i < selectorKeys; i++ {
+		resolved = isIdentifier(newKeys[i])
+	}
+	if !resolved {
+		r.references = append(r.references, reference)
+		return
+	}
+	reference.Resolved = true
+	reference.NewPath = strings.Join(newKeys, ".")
+	if reference.NewPath == reference.Path {
+		return
+	}
+	r.references = append(r.references, reference)
+
+	// Keys of the selector are rewritten together, index arguments one by one
+	if selectorKeys > 0 {
+		r.edits = append(r.edits, templateEdit{
+			start:       start,
+			end:         start + len(selector),
+			replacement: "." + valuesIdent + "." + strings.Join(newKeys[:selectorKeys], "."),
+		})
+	}
+	for i, segment := range indexKeys {
+		newKey := newKeys[selectorKeys+i]
+		if newKey == segment.key {
+			continue
+		}
+		replacement := strconv.Quote(newKey)
+		if strings.HasPrefix(segment.quoted, "`") {
+			replacement = "`" + newKey + "`"
+		}
+		r.edits = append(r.edits, templateEdit{
+			start:       segment.pos,
+			end:         segment.pos + len(segment.quoted),
+			replacement: replacement,
+		})
+	}
+}
+
+// apply returns the template text with the edits made
+func (r *templateRewriter) apply() string {
+	if len(r.edits) == 0 {
+		return r.text
+	}
+	sort.Slice(r.edits, func(i, j int) bool { return r.edits[i].start < r.edits[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, edit := range r.edits {
+		if edit.start < last {
+			continue
+		}
+		b.WriteString(r.text[last:edit.start])
+		b.WriteString(edit.replacement)
+		last = edit.end
+	}
+	b.WriteString(r.text[last:])
+	return b.String()
+}
+
+// locate finds the selector written over a position, allowing for a leading
+// variable such as $, and makes sure it is not part of a longer selector
+func locate(text, selector string, pos int) (int, bool) {
+	for start := pos - len(selector) + 1; start <= pos+1; start++ {
+		if start < 0 || !strings.HasPrefix(text[start:], selector) {
+			continue
+		}
+		end := start + len(selector)
+		if end == len(text) || !isSelectorChar(text[end]) {
+			return start, true
+		}
+	}
+	return 0, false
+}
+
+// isIdentifier reports whether a key can be written in a field selector
+func isIdentifier(key string) bool {
+	for i, r := range key {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return key != ""
+}
+
+// isSelectorChar reports whether a byte can continue a field selector
+func isSelectorChar(b byte) bool {
+	return b == '.' || b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= 0x80
+}
+
+// resolveReference returns the keys a reference points to after the renames.
+// A reference is resolved when every key was renamed, sits beneath a skipped
+// path, or would not be converted anyway.
+func (c *Converter) resolveReference(keys []string, renames RenameMap) ([]string, bool) {
+	newKeys := make([]string, 0, len(keys))
+	oldPath, newPath := "", ""
+	for i, key := range keys {
+		oldKeyPath := joinPath(oldPath, key)
+		newKey := key
+		if renamed, ok := renames[oldKeyPath]; ok {
+			newKey = strings.TrimPrefix(renamed, newPath+".")
+			if newPath == "" {
+				newKey = renamed
+			}
+		} else if c.skipPath(oldKeyPath, joinPath(newPath, key)) {
+			// Skipped keys keep their names, and so does everything beneath them
+			return append(newKeys, keys[i:]...), true
+		} else if c.targetKey(key) != key {
+			return nil, false
+		}
+
+		newKeys = append(newKeys, newKey)
+		oldPath, newPath = oldKeyPath, joinPath(newPath, newKey)
+	}
+	return newKeys, true
+}
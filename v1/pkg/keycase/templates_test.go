@@ -0,0 +1,91 @@
+package keycase
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteTemplate(t *testing.T) {
+	renames := RenameMap{
+		"Database_Url":         "databaseUrl",
+		"Database_Pool":        "databasePool",
+		"Database_Pool.Max":    "databasePool.max",
+		"feature_flags":        "featureFlags",
+		"feature_flags.new_ui": "featureFlags.newUi",
+	}
+	input := `{{- define "app.db" -}}
+url: {{ .Values.Database_Url | quote }}
+max: {{ $.Values.Database_Pool.Max }}
+{{- end }}
+{{- $root := . }}
+{{- if index .Values "feature_flags" "new_ui" }}
+ui: {{ index $root.Values.feature_flags ` + "`new_ui`" + ` }}
+{{- end }}
+{{- with .Values.image }}
+tag: {{ .tag }}
+{{- end }}
+legacy: {{ .Values.Legacy_Setting }}
+same: {{ .Values.Database_Url }}{{ .Values.Database_Url }}
+`
+	expected := `{{- define "app.db" -}}
+url: {{ .Values.databaseUrl | quote }}
+max: {{ $.Values.databasePool.max }}
+{{- end }}
+{{- $root := . }}
+{{- if index .Values "featureFlags" "newUi" }}
+ui: {{ index $root.Values.featureFlags ` + "`newUi`" + ` }}
+{{- end }}
+{{- with .Values.image }}
+tag: {{ .tag }}
+{{- end }}
+legacy: {{ .Values.Legacy_Setting }}
+same: {{ .Values.databaseUrl }}{{ .Values.databaseUrl }}
+`
+
+	output, references, err := NewConverter().RewriteTemplate("templates/app.yaml", []byte(input), renames)
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(output))
+
+	var unresolved []string
+	rewritten := make(map[string]string)
+	for _, reference := range references {
+		if !reference.Resolved {
+			unresolved = append(unresolved, reference.String())
+			continue
+		}
+		rewritten[reference.Path] = reference.NewPath
+	}
+	assert.Equal(t, []string{"templates/app.yaml:12: .Values.Legacy_Setting"}, unresolved)
+	assert.Equal(t, map[string]string{
+		"Database_Url":         "databaseUrl",
+		"Database_Pool.Max":    "databasePool.max",
+		"feature_flags.new_ui": "featureFlags.newUi",
+	}, rewritten)
+}
+
+func TestRewriteTemplate_SkippedPathsAndStyles(t *testing.T) {
+	converter := NewConverter()
+	converter.SkipPaths = []string{"configMap.*"}
+	output, references, err := converter.RewriteTemplate("t", []byte(`{{ .Values.configMap.LOG_level }}`), RenameMap{})
+	require.NoError(t, err)
+	assert.Equal(t, `{{ .Values.configMap.LOG_level }}`, string(output))
+	assert.Empty(t, references)
+
+	// kebab-case keys cannot be written as selectors, only as index arguments
+	converter = NewConverter()
+	converter.TargetStyle = StyleKebab
+	renames := RenameMap{"dbHost": "db-host"}
+	output, references, err = converter.RewriteTemplate("t", []byte(`{{ .Values.dbHost }} {{ index .Values "dbHost" }}`), renames)
+	require.NoError(t, err)
+	assert.Equal(t, `{{ .Values.dbHost }} {{ index .Values "db-host" }}`, string(output))
+	require.Len(t, references, 2)
+	assert.False(t, references[0].Resolved)
+	assert.True(t, references[1].Resolved)
+}
+
+func TestRewriteTemplate_ParseError(t *testing.T) {
+	_, _, err := NewConverter().RewriteTemplate("broken", []byte(`{{ if .Values.x }}`), RenameMap{})
+	assert.Error(t, err)
+}
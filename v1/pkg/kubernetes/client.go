@@ -84,6 +84,16 @@ func NewClient(opts ClientOptions) (*Client, error) {
 	}, nil
 }
 
+// NewClientForClientset wraps an existing clientset, such as one created by
+// KubernetesService.GetClient or a fake clientset in tests
+func NewClientForClientset(clientset kubernetes.Interface, context string) *Client {
+	return &Client{
+		clientset: clientset,
+		context:   context,
+		log:       logger.WithName("k8s-client"),
+	}
+}
+
 func (c *Client) GetClientset() kubernetes.Interface {
 	return c.clientset
 }
@@ -168,7 +178,17 @@ func (c *Client) GetServices(ctx context.Context, namespace string) ([]*corev1.S
 }
 
 func (c *Client) GetConfigMaps(ctx context.Context, namespace string) ([]*corev1.ConfigMap, error) {
-	configMapList, err := c.clientset.CoreV1().ConfigMaps(namespace).List(ctx, v1.ListOptions{})
+	return c.GetConfigMapsWithSelector(ctx, namespace, "")
+}
+
+// GetConfigMapsWithSelector lists the ConfigMaps of a namespace matching a label selector
+func (c *Client) GetConfigMapsWithSelector(ctx context.Context, namespace string, labelSelector string) ([]*corev1.ConfigMap, error) {
+	listOptions := v1.ListOptions{}
+	if labelSelector != "" {
+		listOptions.LabelSelector = labelSelector
+	}
+
+	configMapList, err := c.clientset.CoreV1().ConfigMaps(namespace).List(ctx, listOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list configmaps: %w", err)
 	}
@@ -458,6 +458,34 @@ func TestClient_GetConfigMaps(t *testing.T) {
 	}
 }
 
+func TestClient_GetConfigMapsWithSelector(t *testing.T) {
+	fakeClientset := fake.NewSimpleClientset(
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "labelled",
+				Namespace: "default",
+				Labels:    map[string]string{"app": "migrator"},
+			},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "other",
+				Namespace: "default",
+			},
+		},
+	)
+
+	client := NewClientForClientset(fakeClientset, "test")
+
+	configMaps, err := client.GetConfigMapsWithSelector(context.Background(), "default", "app=migrator")
+	if err != nil {
+		t.Fatalf("GetConfigMapsWithSelector() error = %v", err)
+	}
+	if len(configMaps) != 1 || configMaps[0].Name != "labelled" {
+		t.Errorf("GetConfigMapsWithSelector() returned %d configmaps, want only labelled", len(configMaps))
+	}
+}
+
 func TestClient_GetSecrets(t *testing.T) {
 	// Create test secrets
 	testSecrets := []runtime.Object{
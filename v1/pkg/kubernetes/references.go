@@ -0,0 +1,309 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+
+	"helm-charts-migrator/v1/pkg/errkind"
+)
+
+// References are the names of the Secrets and ConfigMaps used by the
+// workloads of a release, sorted and without duplicates
+type References struct {
+	Secrets    []string
+	ConfigMaps []string
+	// Keys lists, by Kind/name, the keys used of the objects referenced only
+	// through secretKeyRef or configMapKeyRef; other objects are used whole
+	Keys map[string][]string
+}
+
+// IsEmpty reports whether no object is referenced
+func (r References) IsEmpty() bool {
+	return len(r.Secrets) == 0 && len(r.ConfigMaps) == 0
+}
+
+// Filter keeps the references accepted by allow, called with "Secret" or "ConfigMap"
+func (r References) Filter(allow func(kind, name string) bool) References {
+	var filtered References
+	keep := func(kind, name string) bool {
+		if !allow(kind, name) {
+			return false
+		}
+		if keys, ok := r.Keys[kind+"/"+name]; ok {
+			if filtered.Keys == nil {
+				filtered.Keys = make(map[string][]string)
+			}
+			filtered.Keys[kind+"/"+name] = keys
+		}
+		return true
+	}
+	for _, name := range r.Secrets {
+		if keep("Secret", name) {
+			filtered.Secrets = append(filtered.Secrets, name)
+		}
+	}
+	for _, name := range r.ConfigMaps {
+		if keep("ConfigMap", name) {
+			filtered.ConfigMaps = append(filtered.ConfigMaps, name)
+		}
+	}
+	return filtered
+}
+
+// referenceSet collects the referenced objects of one kind with the keys used
+// of each; nil keys mark an object used whole
+type referenceSet map[string]map[string]bool
+
+// addObject records an object used whole
+func (s referenceSet) addObject(name string) {
+	if name != "" {
+		s[name] = nil
+	}
+}
+
+// addKey records a single key used of an object
+func (s referenceSet) addKey(name, key string) {
+	if name == "" {
+		return
+	}
+	keys, seen := s[name]
+	if seen && keys == nil {
+		return
+	}
+	if keys == nil {
+		keys = make(map[string]bool)
+		s[name] = keys
+	}
+	keys[key] = true
+}
+
+// names returns the object names, sorted
+func (s referenceSet) names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// addKeys adds the keys of the objects not used whole to keys, by Kind/name
+func (s referenceSet) addKeys(kind string, keys map[string][]string) {
+	for name, used := range s {
+		if used == nil {
+			continue
+		}
+		list := make([]string, 0, len(used))
+		for key := range used {
+			list = append(list, key)
+		}
+		sort.Strings(list)
+		keys[kind+"/"+name] = list
+	}
+}
+
+// workloadDocument holds the pod templates of the workload kinds a chart renders
+type workloadDocument struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Template    corev1.PodTemplateSpec `json:"template"`
+		JobTemplate struct {
+			Spec struct {
+				Template corev1.PodTemplateSpec `json:"template"`
+			} `json:"spec"`
+		} `json:"jobTemplate"`
+	} `json:"spec"`
+}
+
+// FindReferences lists the Secrets and ConfigMaps referenced by the workloads
+// of a rendered manifest through env valueFrom, envFrom and volumes
+func FindReferences(manifest string) (References, error) {
+	secrets := make(referenceSet)
+	configMaps := make(referenceSet)
+
+	decoder := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 4096)
+	for {
+		var doc workloadDocument
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return References{}, fmt.Errorf("failed to parse manifest: %w", err)
+		}
+
+		var spec corev1.PodSpec
+		switch doc.Kind {
+		case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "Rollout":
+			spec = doc.Spec.Template.Spec
+		case "CronJob":
+			spec = doc.Spec.JobTemplate.Spec.Template.Spec
+		default:
+			continue
+		}
+		collectPodReferences(spec, secrets, configMaps)
+	}
+
+	refs := References{Secrets: secrets.names(), ConfigMaps: configMaps.names(), Keys: make(map[string][]string)}
+	secrets.addKeys("Secret", refs.Keys)
+	configMaps.addKeys("ConfigMap", refs.Keys)
+	return refs, nil
+}
+
+// collectPodReferences adds the objects referenced by a pod spec
+func collectPodReferences(spec corev1.PodSpec, secrets, configMaps referenceSet) {
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				secrets.addKey(ref.Name, ref.Key)
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				configMaps.addKey(ref.Name, ref.Key)
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if ref := envFrom.SecretRef; ref != nil {
+				secrets.addObject(ref.Name)
+			}
+			if ref := envFrom.ConfigMapRef; ref != nil {
+				configMaps.addObject(ref.Name)
+			}
+		}
+	}
+
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil {
+			secrets.addObject(volume.Secret.SecretName)
+		}
+		if volume.ConfigMap != nil {
+			configMaps.addObject(volume.ConfigMap.Name)
+		}
+		if volume.Projected == nil {
+			continue
+		}
+		for _, source := range volume.Projected.Sources {
+			if source.Secret != nil {
+				secrets.addObject(source.Secret.Name)
+			}
+			if source.ConfigMap != nil {
+				configMaps.addObject(source.ConfigMap.Name)
+			}
+		}
+	}
+}
+
+// ImportedReferences is the data of the referenced objects, keyed by data key
+type ImportedReferences struct {
+	Secrets   map[string]string
+	ConfigMap map[string]string
+	// Missing lists referenced objects that do not exist, as Kind/name, and
+	// referenced keys they do not define, as "Kind/name key"
+	Missing []string
+}
+
+// ImportReferences reads the data of the referenced objects in a namespace.
+// Only the referenced keys are read of objects used through key refs. The
+// data of all objects of a kind shares one key space, so a key defined with
+// different values by several objects is an error.
+func (c *Client) ImportReferences(ctx context.Context, namespace string, refs References) (*ImportedReferences, error) {
+	imported := &ImportedReferences{
+		Secrets:   make(map[string]string),
+		ConfigMap: make(map[string]string),
+	}
+
+	if len(refs.Secrets) > 0 {
+		secrets, err := c.GetSecrets(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		byName := make(map[string]*corev1.Secret, len(secrets))
+		for _, secret := range secrets {
+			byName[secret.Name] = secret
+		}
+		for _, name := range refs.Secrets {
+			secret, ok := byName[name]
+			if !ok {
+				imported.Missing = append(imported.Missing, "Secret/"+name)
+				continue
+			}
+			data := make(map[string]string, len(secret.Data)+len(secret.StringData))
+			for key, value := range secret.Data {
+				data[key] = string(value)
+			}
+			for key, value := range secret.StringData {
+				data[key] = value
+			}
+			if err := imported.add(imported.Secrets, "Secret/"+name, data, refs.Keys); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(refs.ConfigMaps) > 0 {
+		configMaps, err := c.GetConfigMaps(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		byName := make(map[string]*corev1.ConfigMap, len(configMaps))
+		for _, configMap := range configMaps {
+			byName[configMap.Name] = configMap
+		}
+		for _, name := range refs.ConfigMaps {
+			configMap, ok := byName[name]
+			if !ok {
+				imported.Missing = append(imported.Missing, "ConfigMap/"+name)
+				continue
+			}
+			if len(configMap.BinaryData) > 0 {
+				c.log.InfoS("Skipping binary data of ConfigMap", "name", name, "namespace", namespace, "keys", len(configMap.BinaryData))
+			}
+			if err := imported.add(imported.ConfigMap, "ConfigMap/"+name, configMap.Data, refs.Keys); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	c.log.V(1).InfoS("Imported referenced objects",
+		"namespace", namespace,
+		"secretKeys", len(imported.Secrets),
+		"configMapKeys", len(imported.ConfigMap),
+		"missing", len(imported.Missing))
+	return imported, nil
+}
+
+// add copies the data of an object into target, limited to its referenced
+// keys when it is only used through key refs
+func (i *ImportedReferences) add(target map[string]string, object string, data map[string]string, refKeys map[string][]string) error {
+	keys, limited := refKeys[object]
+	if !limited {
+		keys = make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+
+	for _, key := range keys {
+		value, ok := data[key]
+		if !ok {
+			i.Missing = append(i.Missing, fmt.Sprintf("%s key %s", object, key))
+			continue
+		}
+		if existing, ok := target[key]; ok && existing != value {
+			return errkind.New(errkind.Validation,
+				"key %s of %s conflicts with the value imported from another object", key, object)
+		}
+		target[key] = value
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"helm-charts-migrator/v1/pkg/errkind"
+)
+
+const referencesManifest = `---
+# Source: heimdall/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: heimdall
+spec:
+  ports:
+    - port: 80
+---
+# Source: heimdall/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: heimdall
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          envFrom:
+            - secretRef:
+                name: heimdall-db
+      containers:
+        - name: heimdall
+          env:
+            - name: PLAIN
+              value: "1"
+            - name: API_KEY
+              valueFrom:
+                secretKeyRef:
+                  name: heimdall-api
+                  key: apiKey
+            - name: LOG_LEVEL
+              valueFrom:
+                configMapKeyRef:
+                  name: heimdall-env
+                  key: logLevel
+          envFrom:
+            - configMapRef:
+                name: shared-env
+      volumes:
+        - name: config
+          configMap:
+            name: heimdall-config
+        - name: certs
+          secret:
+            secretName: heimdall-tls
+        - name: projected
+          projected:
+            sources:
+              - secret:
+                  name: heimdall-api
+              - configMap:
+                  name: heimdall-extra
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: heimdall-cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: cleanup
+              envFrom:
+                - secretRef:
+                    name: cleanup-token
+`
+
+func TestFindReferences(t *testing.T) {
+	refs, err := FindReferences(referencesManifest)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"cleanup-token", "heimdall-api", "heimdall-db", "heimdall-tls"}, refs.Secrets)
+	assert.Equal(t, []string{"heimdall-config", "heimdall-env", "heimdall-extra", "shared-env"}, refs.ConfigMaps)
+	// heimdall-api is also mounted whole through the projected volume
+	assert.Equal(t, map[string][]string{"ConfigMap/heimdall-env": {"logLevel"}}, refs.Keys)
+
+	empty, err := FindReferences("")
+	require.NoError(t, err)
+	assert.True(t, empty.IsEmpty())
+
+	_, err = FindReferences("kind: [")
+	assert.Error(t, err)
+}
+
+func TestReferencesFilter(t *testing.T) {
+	refs := References{
+		Secrets:    []string{"a", "b"},
+		ConfigMaps: []string{"a", "c"},
+		Keys:       map[string][]string{"Secret/a": {"x"}, "Secret/b": {"y"}},
+	}
+
+	filtered := refs.Filter(func(kind, name string) bool {
+		return kind == "ConfigMap" || name == "b"
+	})
+
+	assert.Equal(t, []string{"b"}, filtered.Secrets)
+	assert.Equal(t, []string{"a", "c"}, filtered.ConfigMaps)
+	assert.Equal(t, map[string][]string{"Secret/b": {"y"}}, filtered.Keys)
+}
+
+func TestClient_ImportReferences(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "heimdall-api", Namespace: "vf-dev3"},
+			Data:       map[string][]byte{"apiKey": []byte("key-1"), "shared": []byte("same")},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "heimdall-db", Namespace: "vf-dev3"},
+			Data:       map[string][]byte{"password": []byte("db-pass"), "shared": []byte("same")},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "heimdall-keys", Namespace: "vf-dev3"},
+			Data:       map[string][]byte{"used": []byte("u"), "unused": []byte("x")},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "heimdall-api", Namespace: "other"},
+			Data:       map[string][]byte{"apiKey": []byte("wrong-namespace")},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "heimdall-config", Namespace: "vf-dev3"},
+			Data:       map[string]string{"application.properties": "server.port=8080"},
+		},
+	)
+	client := NewClientForClientset(clientset, "dev01")
+
+	imported, err := client.ImportReferences(context.Background(), "vf-dev3", References{
+		Secrets:    []string{"heimdall-api", "heimdall-db", "heimdall-keys", "heimdall-missing"},
+		ConfigMaps: []string{"heimdall-config"},
+		Keys:       map[string][]string{"Secret/heimdall-keys": {"gone", "used"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"apiKey":   "key-1",
+		"password": "db-pass",
+		"shared":   "same",
+		"used":     "u",
+	}, imported.Secrets)
+	assert.Equal(t, map[string]string{"application.properties": "server.port=8080"}, imported.ConfigMap)
+	assert.Equal(t, []string{"Secret/heimdall-keys key gone", "Secret/heimdall-missing"}, imported.Missing)
+}
+
+func TestClient_ImportReferencesConflictingKeys(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "vf-dev3"},
+			Data:       map[string]string{"LOG_LEVEL": "info"},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "vf-dev3"},
+			Data:       map[string]string{"LOG_LEVEL": "debug"},
+		},
+	)
+	client := NewClientForClientset(clientset, "dev01")
+
+	_, err := client.ImportReferences(context.Background(), "vf-dev3", References{ConfigMaps: []string{"a", "b"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "key LOG_LEVEL of ConfigMap/b conflicts")
+	assert.True(t, errors.Is(err, errkind.ErrValidation))
+}
+
+func TestClient_ImportReferencesNothingReferenced(t *testing.T) {
+	client := NewClientForClientset(fake.NewSimpleClientset(), "dev01")
+
+	imported, err := client.ImportReferences(context.Background(), "default", References{})
+	require.NoError(t, err)
+	assert.Empty(t, imported.Secrets)
+	assert.Empty(t, imported.ConfigMap)
+	assert.Empty(t, imported.Missing)
+}
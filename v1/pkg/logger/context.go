@@ -0,0 +1,38 @@
+package logger
+
+import "context"
+
+type contextKey struct{}
+
+// NewContext returns a context carrying keysAndValues (e.g. the service,
+// cluster or task being processed) on top of any already carried by ctx
+func NewContext(ctx context.Context, keysAndValues ...interface{}) context.Context {
+	carried := ValuesFromContext(ctx)
+	values := make([]interface{}, 0, len(carried)+len(keysAndValues))
+	values = append(values, carried...)
+	values = append(values, keysAndValues...)
+	return context.WithValue(ctx, contextKey{}, values)
+}
+
+// ValuesFromContext returns the key/value pairs carried by ctx
+func ValuesFromContext(ctx context.Context) []interface{} {
+	if ctx == nil {
+		return nil
+	}
+	values, _ := ctx.Value(contextKey{}).([]interface{})
+	return values
+}
+
+// WithContext returns a copy of the logger tagged with the values carried by ctx
+func (l *NamedLogger) WithContext(ctx context.Context) *NamedLogger {
+	values := ValuesFromContext(ctx)
+	if len(values) == 0 {
+		return l
+	}
+	return l.WithValues(values...)
+}
+
+// FromContext returns a named logger tagged with the values carried by ctx
+func FromContext(ctx context.Context, name string) *NamedLogger {
+	return WithName(name).WithContext(ctx)
+}
@@ -12,31 +12,31 @@ func InitFlags(fs *flag.FlagSet) {
 }
 
 func Info(msg string, keysAndValues ...interface{}) {
-	klog.InfoS(msg, keysAndValues...)
+	emit(severityInfo, 0, "", msg, nil, nil, keysAndValues)
 }
 
 func InfoS(msg string, keysAndValues ...interface{}) {
-	klog.InfoS(msg, keysAndValues...)
+	emit(severityInfo, 0, "", msg, nil, nil, keysAndValues)
 }
 
-func V(level int) klog.Verbose {
-	return klog.V(klog.Level(level))
+func V(level int) Verbose {
+	return newVerbose(level, "", nil)
 }
 
 func Error(err error, msg string, keysAndValues ...interface{}) {
-	klog.ErrorS(err, msg, keysAndValues...)
+	emit(severityError, 0, "", msg, err, nil, keysAndValues)
 }
 
 func ErrorS(err error, msg string, keysAndValues ...interface{}) {
-	klog.ErrorS(err, msg, keysAndValues...)
+	emit(severityError, 0, "", msg, err, nil, keysAndValues)
 }
 
 func Warning(msg string, keysAndValues ...interface{}) {
-	klog.InfoS("WARNING: "+msg, keysAndValues...)
+	emit(severityWarning, 0, "", msg, nil, nil, keysAndValues)
 }
 
 func WarningS(msg string, keysAndValues ...interface{}) {
-	klog.InfoS("WARNING: "+msg, keysAndValues...)
+	emit(severityWarning, 0, "", msg, nil, nil, keysAndValues)
 }
 
 func Fatal(msg string, keysAndValues ...interface{}) {
@@ -49,32 +49,84 @@ func FatalS(msg string, keysAndValues ...interface{}) {
 
 func Flush() {
 	klog.Flush()
+	flushRunLog()
 }
 
+// NamedLogger prefixes every line with its component name and carries
+// key/value pairs (e.g. the task a worker is running) added to every line
 type NamedLogger struct {
-	name string
+	name   string
+	values []interface{}
 }
 
 func WithName(name string) *NamedLogger {
 	return &NamedLogger{name: name}
 }
 
+// WithValues returns a copy of the logger that adds keysAndValues to every line
+func (l *NamedLogger) WithValues(keysAndValues ...interface{}) *NamedLogger {
+	values := make([]interface{}, 0, len(l.values)+len(keysAndValues))
+	values = append(values, l.values...)
+	values = append(values, keysAndValues...)
+	return &NamedLogger{name: l.name, values: values}
+}
+
 func (l *NamedLogger) Info(msg string, keysAndValues ...interface{}) {
-	klog.InfoS(fmt.Sprintf("[%s] %s", l.name, msg), keysAndValues...)
+	emit(severityInfo, 0, l.name, msg, nil, l.values, keysAndValues)
 }
 
 func (l *NamedLogger) InfoS(msg string, keysAndValues ...interface{}) {
-	klog.InfoS(fmt.Sprintf("[%s] %s", l.name, msg), keysAndValues...)
+	emit(severityInfo, 0, l.name, msg, nil, l.values, keysAndValues)
 }
 
 func (l *NamedLogger) Error(err error, msg string, keysAndValues ...interface{}) {
-	klog.ErrorS(err, fmt.Sprintf("[%s] %s", l.name, msg), keysAndValues...)
+	emit(severityError, 0, l.name, msg, err, l.values, keysAndValues)
 }
 
 func (l *NamedLogger) Warning(msg string, keysAndValues ...interface{}) {
-	klog.InfoS(fmt.Sprintf("[%s] WARNING: %s", l.name, msg), keysAndValues...)
+	emit(severityWarning, 0, l.name, msg, nil, l.values, keysAndValues)
+}
+
+func (l *NamedLogger) V(level int) Verbose {
+	return newVerbose(level, l.name, l.values)
+}
+
+// Verbose is returned by V and only logs when the -v level is high enough
+type Verbose struct {
+	enabled bool
+	level   int
+	name    string
+	values  []interface{}
+}
+
+func newVerbose(level int, name string, values []interface{}) Verbose {
+	return Verbose{
+		enabled: klog.V(klog.Level(level)).Enabled(),
+		level:   level,
+		name:    name,
+		values:  values,
+	}
+}
+
+// Enabled reports whether this verbosity level is enabled
+func (v Verbose) Enabled() bool {
+	return v.enabled
+}
+
+func (v Verbose) InfoS(msg string, keysAndValues ...interface{}) {
+	if v.enabled {
+		emit(severityInfo, v.level, v.name, msg, nil, v.values, keysAndValues)
+	}
+}
+
+func (v Verbose) Info(args ...interface{}) {
+	if v.enabled {
+		emit(severityInfo, v.level, v.name, fmt.Sprint(args...), nil, v.values, nil)
+	}
 }
 
-func (l *NamedLogger) V(level int) klog.Verbose {
-	return klog.V(klog.Level(level))
+func (v Verbose) Infof(format string, args ...interface{}) {
+	if v.enabled {
+		emit(severityInfo, v.level, v.name, fmt.Sprintf(format, args...), nil, v.values, nil)
+	}
 }
@@ -0,0 +1,275 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Format selects how log lines are rendered
+type Format string
+
+const (
+	// FormatText renders klog-style text lines (the default)
+	FormatText Format = "text"
+	// FormatJSON renders one JSON object per line
+	FormatJSON Format = "json"
+)
+
+// Well-known field names shared by every component so lines can be filtered
+// consistently in both formats
+const (
+	FieldRunID     = "runId"
+	FieldService   = "service"
+	FieldCluster   = "cluster"
+	FieldNamespace = "namespace"
+	FieldStep      = "step"
+	FieldTask      = "task"
+	FieldWorker    = "worker"
+)
+
+type severity string
+
+const (
+	severityInfo    severity = "info"
+	severityWarning severity = "warning"
+	severityError   severity = "error"
+)
+
+// callerDepth skips emit and the public logging method so klog reports the caller
+const callerDepth = 2
+
+var (
+	outputMu sync.Mutex
+	format             = FormatText
+	console  io.Writer = os.Stderr
+	runID    string
+	runLog   *os.File
+	runBuf   *bufio.Writer
+	diverted bool
+	now      = time.Now
+)
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	switch Format(name) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported log format %q (expected %q or %q)", name, FormatText, FormatJSON)
+	}
+}
+
+// SetFormat selects the console log format
+func SetFormat(f Format) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	format = f
+}
+
+// GetFormat returns the console log format
+func GetFormat() Format {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	return format
+}
+
+// SetRunID tags every subsequent line with the run ID
+func SetRunID(id string) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	runID = id
+}
+
+// OpenRunLog mirrors every line, as JSON, into a per-run log file
+func OpenRunLog(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create run log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open run log: %w", err)
+	}
+
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	closeRunLogLocked()
+	runLog = file
+	runBuf = bufio.NewWriter(file)
+	return nil
+}
+
+// RunLogPath returns the path of the open per-run log file, or "" when none is open
+func RunLogPath() string {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	if runLog == nil {
+		return ""
+	}
+	return runLog.Name()
+}
+
+// DivertConsole stops (true) or resumes (false) console output, e.g. while a
+// live view owns the terminal. Lines still reach the run log file.
+func DivertConsole(divert bool) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	diverted = divert
+}
+
+// CloseRunLog flushes and closes the per-run log file
+func CloseRunLog() error {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	return closeRunLogLocked()
+}
+
+func closeRunLogLocked() error {
+	if runLog == nil {
+		return nil
+	}
+	flushErr := runBuf.Flush()
+	closeErr := runLog.Close()
+	runLog, runBuf = nil, nil
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
+}
+
+func flushRunLog() {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	if runBuf != nil {
+		_ = runBuf.Flush()
+	}
+}
+
+// emit renders a line to the console (klog for text, stderr for JSON), unless
+// it is diverted, and to the run log file when one is open
+func emit(sev severity, level int, name, msg string, err error, values, keysAndValues []interface{}) {
+	outputMu.Lock()
+	currentFormat, currentRunID, mirror, toConsole := format, runID, runBuf != nil, !diverted
+	outputMu.Unlock()
+
+	fields := mergeFields(currentRunID, values, keysAndValues)
+
+	if currentFormat == FormatText && toConsole {
+		text := msg
+		if sev == severityWarning {
+			text = "WARNING: " + text
+		}
+		if name != "" {
+			text = fmt.Sprintf("[%s] %s", name, text)
+		}
+		if sev == severityError {
+			klog.ErrorSDepth(callerDepth, err, text, fields...)
+		} else {
+			klog.InfoSDepth(callerDepth, text, fields...)
+		}
+	}
+
+	if (currentFormat != FormatJSON || !toConsole) && !mirror {
+		return
+	}
+
+	line := encodeJSON(sev, level, name, msg, err, fields)
+
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	if currentFormat == FormatJSON && !diverted {
+		_, _ = console.Write(line)
+	}
+	if runBuf != nil {
+		_, _ = runBuf.Write(line)
+		if sev == severityError {
+			_ = runBuf.Flush()
+		}
+	}
+}
+
+// mergeFields combines the run ID, carried values and call-site pairs; a key
+// given at the call site wins over the same key carried by the logger
+func mergeFields(currentRunID string, values, keysAndValues []interface{}) []interface{} {
+	explicit := make(map[string]bool, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		explicit[fmt.Sprint(keysAndValues[i])] = true
+	}
+
+	fields := make([]interface{}, 0, len(values)+len(keysAndValues)+2)
+	if currentRunID != "" && !explicit[FieldRunID] {
+		fields = append(fields, FieldRunID, currentRunID)
+	}
+	for i := 0; i+1 < len(values); i += 2 {
+		if !explicit[fmt.Sprint(values[i])] {
+			fields = append(fields, values[i], values[i+1])
+		}
+	}
+	return append(fields, keysAndValues...)
+}
+
+// encodeJSON renders a line as a JSON object with a stable key order
+func encodeJSON(sev severity, level int, name, msg string, err error, fields []interface{}) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`{"ts":`)
+	writeJSONValue(&buf, now().UTC().Format(time.RFC3339Nano))
+	buf.WriteString(`,"level":`)
+	writeJSONValue(&buf, string(sev))
+	if level > 0 {
+		buf.WriteString(`,"v":`)
+		writeJSONValue(&buf, level)
+	}
+	if name != "" {
+		buf.WriteString(`,"logger":`)
+		writeJSONValue(&buf, name)
+	}
+	buf.WriteString(`,"msg":`)
+	writeJSONValue(&buf, msg)
+	if err != nil {
+		buf.WriteString(`,"error":`)
+		writeJSONValue(&buf, err.Error())
+	}
+
+	for i := 0; i < len(fields); i += 2 {
+		key := fmt.Sprint(fields[i])
+		var value interface{} = "(MISSING)"
+		if i+1 < len(fields) {
+			value = fields[i+1]
+		}
+		buf.WriteByte(',')
+		writeJSONValue(&buf, key)
+		buf.WriteByte(':')
+		writeJSONValue(&buf, value)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+// writeJSONValue encodes a value, rendering errors, durations and other
+// Stringers as text instead of their struct form
+func writeJSONValue(buf *bytes.Buffer, value interface{}) {
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case time.Duration:
+		value = v.String()
+	case fmt.Stringer:
+		value = v.String()
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprintf("%+v", value))
+	}
+	buf.Write(data)
+}
@@ -0,0 +1,202 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureJSON switches to JSON output into a buffer for the duration of a test
+func captureJSON(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	buf := &bytes.Buffer{}
+
+	outputMu.Lock()
+	prevFormat, prevConsole, prevRunID := format, console, runID
+	format, console, runID = FormatJSON, buf, ""
+	outputMu.Unlock()
+
+	t.Cleanup(func() {
+		outputMu.Lock()
+		format, console, runID = prevFormat, prevConsole, prevRunID
+		outputMu.Unlock()
+	})
+	return buf
+}
+
+func decodeLines(t *testing.T, data string) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		if line == "" {
+			continue
+		}
+		var decoded map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &decoded); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		lines = append(lines, decoded)
+	}
+	return lines
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Format
+		wantErr bool
+	}{
+		{"", FormatText, false},
+		{"text", FormatText, false},
+		{"json", FormatJSON, false},
+		{"xml", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseFormat(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestJSONFormat_Fields(t *testing.T) {
+	buf := captureJSON(t)
+	SetRunID("run-123")
+
+	log := WithName("migrator").WithValues(FieldService, "heimdall", FieldCluster, "dev01")
+	log.InfoS("Processed namespace", FieldNamespace, "vf-dev2", "duration", 1500*time.Millisecond)
+	log.Error(errors.New("boom"), "Failed to encrypt secrets", FieldStep, "encrypt-secrets")
+
+	lines := decodeLines(t, buf.String())
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %s", len(lines), buf.String())
+	}
+
+	info := lines[0]
+	expected := map[string]interface{}{
+		"level":        "info",
+		"logger":       "migrator",
+		"msg":          "Processed namespace",
+		FieldRunID:     "run-123",
+		FieldService:   "heimdall",
+		FieldCluster:   "dev01",
+		FieldNamespace: "vf-dev2",
+		"duration":     "1.5s",
+	}
+	for key, want := range expected {
+		if info[key] != want {
+			t.Errorf("field %s = %v, want %v", key, info[key], want)
+		}
+	}
+
+	if lines[1]["level"] != "error" || lines[1]["error"] != "boom" || lines[1][FieldStep] != "encrypt-secrets" {
+		t.Errorf("unexpected error line: %v", lines[1])
+	}
+}
+
+func TestJSONFormat_CallSiteWins(t *testing.T) {
+	buf := captureJSON(t)
+
+	WithName("test").WithValues(FieldService, "carried").InfoS("message", FieldService, "explicit")
+
+	lines := decodeLines(t, buf.String())
+	if len(lines) != 1 || lines[0][FieldService] != "explicit" {
+		t.Errorf("expected explicit service to win, got %s", buf.String())
+	}
+	if strings.Count(buf.String(), `"service"`) != 1 {
+		t.Errorf("expected a single service key, got %s", buf.String())
+	}
+}
+
+func TestContextLogger(t *testing.T) {
+	buf := captureJSON(t)
+
+	ctx := NewContext(context.Background(), FieldTask, "auth-dev01")
+	ctx = NewContext(ctx, FieldStep, "extract")
+
+	FromContext(ctx, "worker").InfoS("Working")
+	WithName("plain").WithContext(context.Background()).InfoS("Untagged")
+
+	lines := decodeLines(t, buf.String())
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	if lines[0][FieldTask] != "auth-dev01" || lines[0][FieldStep] != "extract" {
+		t.Errorf("expected context fields, got %v", lines[0])
+	}
+	if _, ok := lines[1][FieldTask]; ok {
+		t.Errorf("expected no task field, got %v", lines[1])
+	}
+}
+
+func TestRunLog(t *testing.T) {
+	captureJSON(t)
+	path := filepath.Join(t.TempDir(), "run", "migration.log")
+
+	if err := OpenRunLog(path); err != nil {
+		t.Fatalf("OpenRunLog() error = %v", err)
+	}
+	WithName("test").InfoS("Written to run log", FieldService, "heimdall")
+	Warning("Global warning")
+	if err := CloseRunLog(); err != nil {
+		t.Fatalf("CloseRunLog() error = %v", err)
+	}
+	WithName("test").InfoS("Not written after close")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read run log: %v", err)
+	}
+	lines := decodeLines(t, string(data))
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines in run log, got %d: %s", len(lines), data)
+	}
+	if lines[1]["level"] != "warning" {
+		t.Errorf("expected warning level, got %v", lines[1]["level"])
+	}
+}
+
+func TestDivertConsole(t *testing.T) {
+	buf := captureJSON(t)
+	path := filepath.Join(t.TempDir(), "migration.log")
+
+	if err := OpenRunLog(path); err != nil {
+		t.Fatalf("OpenRunLog() error = %v", err)
+	}
+	if RunLogPath() != path {
+		t.Errorf("RunLogPath() = %q, want %q", RunLogPath(), path)
+	}
+
+	DivertConsole(true)
+	WithName("test").InfoS("Only in run log")
+	DivertConsole(false)
+	WithName("test").InfoS("On console again")
+	if err := CloseRunLog(); err != nil {
+		t.Fatalf("CloseRunLog() error = %v", err)
+	}
+
+	console := decodeLines(t, buf.String())
+	if len(console) != 1 || console[0]["msg"] != "On console again" {
+		t.Errorf("expected only the undiverted line on the console, got %s", buf.String())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read run log: %v", err)
+	}
+	if lines := decodeLines(t, string(data)); len(lines) != 2 {
+		t.Errorf("expected both lines in run log, got %d: %s", len(lines), data)
+	}
+	if RunLogPath() != "" {
+		t.Errorf("RunLogPath() = %q after close, want empty", RunLogPath())
+	}
+}
@@ -3,12 +3,26 @@ package migration
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/errkind"
+	"helm-charts-migrator/v1/pkg/journal"
 	"helm-charts-migrator/v1/pkg/logger"
+	"helm-charts-migrator/v1/pkg/progress"
 	"helm-charts-migrator/v1/pkg/services"
 )
 
+const (
+	// DefaultRunsDir is where run journals and logs are kept when no runs directory is given
+	DefaultRunsDir = ".runs"
+	// RunLogFileName is the JSON log file written in each run directory
+	RunLogFileName = "migration.log"
+	// ReportsDirName holds the transformation report of each service in a run directory
+	ReportsDirName = "reports"
+)
+
 // MigratorOptions contains configuration options for migration
 type MigratorOptions struct {
 	ConfigPath   string
@@ -23,8 +37,13 @@ type MigratorOptions struct {
 	Cluster    string
 	Namespaces []string
 	Services   []string
-	AwsProfile string
-	NoSOPS     bool // Skip SOPS encryption when true
+	AwsProfile string // --aws-profile, used when set or when globals.sops.awsProfile is empty
+	// AwsProfileSet tells that --aws-profile was given, so it wins over the config
+	AwsProfileSet bool
+	NoSOPS        bool // Skip SOPS encryption when true
+	// Run journal options
+	RunsDir string // Directory holding one sub-directory per run (journal, logs, reports)
+	Resume  string // Run ID to resume; completed services/namespaces are skipped
 }
 
 // MigratorFactory creates migrators with proper dependencies - Factory Pattern
@@ -57,6 +76,9 @@ func (f *MigratorFactory) CreateMigrator(opts MigratorOptions) (*Migrator, error
 	
 	// Create SOPS service
 	sopsConfig := &f.config.Globals.SOPS
+	if opts.AwsProfile != "" && (opts.AwsProfileSet || sopsConfig.AwsProfile == "") {
+		sopsConfig.AwsProfile = opts.AwsProfile
+	}
 	// Set defaults if needed
 	if sopsConfig.ParallelWorkers == 0 {
 		sopsConfig.ParallelWorkers = 5
@@ -107,10 +129,38 @@ func RunMigrationWithFactory(opts MigratorOptions) error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	if problems := config.ValidateBaseCharts(opts.ConfigPath, cfg); len(problems) > 0 {
+		return errkind.New(errkind.Configuration, "invalid config:\n%w", problems)
+	}
 
 	// Initialize paths in config
 	cfg.SetPaths(opts.SourcePath, opts.TargetPath, opts.CacheDir)
 
+	// Hash the configuration before CLI filters are applied so that resuming
+	// with a different --services/--cluster selection keeps earlier entries
+	runJournal, err := openRunJournal(cfg, opts)
+	if err != nil {
+		return err
+	}
+	if runJournal != nil {
+		defer runJournal.Close()
+	}
+
+	// Tag every line with the run ID and keep a copy in the run directory,
+	// dry runs included, even though only real runs are journaled
+	runDir := journal.RunDir(runsDirOf(opts), journal.NewRunID())
+	if runJournal != nil {
+		runDir = runJournal.Dir()
+	}
+	logger.SetRunID(filepath.Base(runDir))
+	runLogPath := filepath.Join(runDir, RunLogFileName)
+	if err := logger.OpenRunLog(runLogPath); err != nil {
+		log.Error(err, "Failed to open run log", "path", runLogPath)
+	} else {
+		defer logger.CloseRunLog()
+		log.InfoS("Writing run log", "path", runLogPath)
+	}
+
 	// Create factory and migrator
 	factory := NewMigratorFactory(cfg)
 	migrator, err := factory.CreateMigrator(opts)
@@ -152,12 +202,73 @@ func RunMigrationWithFactory(opts MigratorOptions) error {
 		}
 	}
 
+	if runJournal != nil {
+		migrator.SetJournal(runJournal)
+	}
+	migrator.SetReportDir(filepath.Join(runDir, ReportsDirName))
+
+	if cfg.Globals.Performance.ShowProgress {
+		tracker := progress.NewTracker()
+		tracker.WatchPool(migrator.MetricsSnapshot)
+		migrator.SetProgress(tracker)
+		renderer := progress.NewRenderer(tracker, os.Stderr)
+		renderer.Start()
+		defer renderer.Stop()
+	}
+
 	// Run migration
 	ctx := context.Background()
 	if err := migrator.Run(ctx); err != nil {
+		if runJournal != nil {
+			log.InfoS("Migration can be resumed", "runID", runJournal.RunID(), "command", "migrate --resume "+runJournal.RunID())
+		}
 		return fmt.Errorf("migration failed: %w", err)
 	}
 
 	log.Info("Migration completed successfully")
 	return nil
 }
+
+// openRunJournal opens the journal for a new run, or for the run being resumed.
+// Dry runs are not journaled.
+func openRunJournal(cfg *config.Config, opts MigratorOptions) (*journal.Journal, error) {
+	log := logger.WithName("migration")
+	if opts.DryRun {
+		if opts.Resume != "" {
+			log.Warning("Ignoring --resume in dry-run mode", "runID", opts.Resume)
+		}
+		return nil, nil
+	}
+
+	runsDir := runsDirOf(opts)
+
+	configHash, err := journal.HashConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	runID := opts.Resume
+	if runID != "" {
+		if !journal.Exists(runsDir, runID) {
+			return nil, errkind.New(errkind.NotFound, "no journal found for run %s in %s", runID, runsDir)
+		}
+		log.InfoS("Resuming migration run", "runID", runID)
+	} else {
+		runID = journal.NewRunID()
+		log.InfoS("Starting migration run", "runID", runID)
+	}
+
+	j, err := journal.Open(runsDir, runID, configHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run journal: %w", err)
+	}
+	return j, nil
+}
+
+// runsDirOf returns the directory holding one sub-directory per run
+func runsDirOf(opts MigratorOptions) string {
+	if opts.RunsDir == "" {
+		return DefaultRunsDir
+	}
+	return opts.RunsDir
+}
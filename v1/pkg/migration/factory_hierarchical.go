@@ -3,7 +3,6 @@ package migration
 import (
 	"context"
 	"fmt"
-	"path/filepath"
 
 	"helm-charts-migrator/v1/pkg/config"
 	"helm-charts-migrator/v1/pkg/logger"
@@ -204,42 +203,19 @@ func (f *HierarchicalMigratorFactory) CreateParallelMigrator(
 	return pm, nil
 }
 
-// LoadHierarchicalConfig loads configuration from a directory structure
+// LoadHierarchicalConfig loads configuration from a directory structure, with
+// globals/, clusters/ and services/ holding a file per section, and rebuilds
+// the hierarchy from it
 func (f *HierarchicalMigratorFactory) LoadHierarchicalConfig(configDir string) error {
-	// Load defaults
-	defaultsPath := filepath.Join(configDir, "defaults.yaml")
-	if defaults, err := f.loader.LoadFromFile(defaultsPath); err == nil {
-		f.hierarchy.SetDefaults(f.configToMap(defaults.Globals))
-		f.log.V(2).InfoS("Loaded defaults", "path", defaultsPath)
-	}
-	
-	// Load globals
-	globalsPath := filepath.Join(configDir, "globals.yaml")
-	if globals, err := f.loader.LoadFromFile(globalsPath); err == nil {
-		f.hierarchy.SetGlobals(f.configToMap(globals.Globals))
-		f.log.V(2).InfoS("Loaded globals", "path", globalsPath)
-	}
-	
-	// Load cluster configs
-	clustersDir := filepath.Join(configDir, "clusters")
-	if clusters, err := f.loader.LoadFromDirectory(clustersDir); err == nil {
-		for _, account := range clusters.Accounts {
-			for name, cluster := range account.Clusters {
-				f.hierarchy.SetClusterConfig(name, f.clusterToMap(cluster))
-				f.log.V(2).InfoS("Loaded cluster config", "cluster", name)
-			}
-		}
-	}
-	
-	// Load service configs
-	servicesDir := filepath.Join(configDir, "services")
-	if services, err := f.loader.LoadFromDirectory(servicesDir); err == nil {
-		for name, service := range services.Services {
-			f.hierarchy.SetServiceConfig(name, f.serviceToMap(service))
-			f.log.V(2).InfoS("Loaded service config", "service", name)
-		}
+	cfg, err := f.loader.LoadFromDirectory(configDir)
+	if err != nil {
+		return fmt.Errorf("failed to load hierarchical config: %w", err)
 	}
-	
+
+	f.baseConfig = cfg
+	f.hierarchy = config.NewHierarchicalConfig()
+	f.initializeHierarchy()
+
 	f.log.InfoS("Loaded hierarchical configuration", "configDir", configDir)
 	return nil
 }
@@ -264,6 +240,12 @@ func (f *HierarchicalMigratorFactory) configToMap(globals config.Globals) map[st
 			"awsProfile":      globals.SOPS.AwsProfile,
 			"parallelWorkers": globals.SOPS.ParallelWorkers,
 			"timeout":         globals.SOPS.Timeout,
+			"configFile":      globals.SOPS.ConfigFile,
+			"pathRegex":       globals.SOPS.PathRegex,
+			"skipUnchanged":   globals.SOPS.SkipUnchanged,
+			"ageKeyFile":      globals.SOPS.AgeKeyFile,
+			"gnupgHome":       globals.SOPS.GnuPGHome,
+			"creationRules":   globals.SOPS.CreationRules,
 		},
 	}
 }
@@ -380,6 +362,24 @@ func (f *HierarchicalMigratorFactory) mapToGlobals(m map[string]interface{}) con
 		if val, ok := sops["timeout"].(int); ok {
 			globals.SOPS.Timeout = val
 		}
+		if val, ok := sops["configFile"].(string); ok {
+			globals.SOPS.ConfigFile = val
+		}
+		if val, ok := sops["pathRegex"].(string); ok {
+			globals.SOPS.PathRegex = val
+		}
+		if val, ok := sops["skipUnchanged"].(bool); ok {
+			globals.SOPS.SkipUnchanged = val
+		}
+		if val, ok := sops["ageKeyFile"].(string); ok {
+			globals.SOPS.AgeKeyFile = val
+		}
+		if val, ok := sops["gnupgHome"].(string); ok {
+			globals.SOPS.GnuPGHome = val
+		}
+		if val, ok := sops["creationRules"].([]config.SOPSCreationRule); ok {
+			globals.SOPS.CreationRules = val
+		}
 	}
 	
 	return globals
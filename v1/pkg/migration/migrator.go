@@ -14,8 +14,13 @@ import (
 
 	"helm-charts-migrator/v1/pkg/adapters"
 	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/errkind"
+	"helm-charts-migrator/v1/pkg/journal"
 	"helm-charts-migrator/v1/pkg/logger"
+	"helm-charts-migrator/v1/pkg/progress"
+	"helm-charts-migrator/v1/pkg/schema"
 	"helm-charts-migrator/v1/pkg/services"
+	"helm-charts-migrator/v1/pkg/workers"
 )
 
 // Migrator orchestrates the migration process using injected services
@@ -29,8 +34,15 @@ type Migrator struct {
 	sops        services.SOPSService
 	chartCopier adapters.ChartCopier
 	extractor   adapters.ValuesExtractor
+	templates   adapters.TemplateRewriter
 	fileManager adapters.FileManager
 	pipeline    *adapters.TransformationPipeline
+	journal     *journal.Journal
+	reports     map[string]services.ReportService // transformation report of each service
+	reportDir   string
+	reportsMu   sync.Mutex
+	progress    progress.Observer
+	metrics     *workers.Metrics
 	log         *logger.NamedLogger
 	dryRun      bool
 	noSOPS      bool
@@ -51,9 +63,13 @@ func NewMigrator(
 	// Create adapter components
 	chartCopier := adapters.NewChartCopier(cfg, file)
 	extractor := adapters.NewValuesExtractor(cfg, transform)
+	templates := adapters.NewTemplateRewriter(cfg)
 	fileManager := adapters.NewFileManager(file)
 	pipeline := adapters.NewTransformationPipeline(cfg, file, transform)
 
+	metrics := workers.NewMetrics(maxConcurrentServices(cfg))
+	metrics.Start()
+
 	return &Migrator{
 		config:      cfg,
 		kubernetes:  kubernetes,
@@ -64,80 +80,180 @@ func NewMigrator(
 		sops:        sops,
 		chartCopier: chartCopier,
 		extractor:   extractor,
+		templates:   templates,
 		fileManager: fileManager,
 		pipeline:    pipeline,
+		reports:     make(map[string]services.ReportService),
+		metrics:     metrics,
 		log:         logger.WithName("migrator"),
 		dryRun:      dryRun,
 		noSOPS:      noSOPS,
 	}
 }
 
+// SetJournal enables run journaling; completed services and namespaces
+// already recorded in the journal are skipped
+func (m *Migrator) SetJournal(j *journal.Journal) {
+	m.journal = j
+}
+
+// SetReportDir saves the transformation report of each migrated service in dir
+func (m *Migrator) SetReportDir(dir string) {
+	m.reportDir = dir
+}
+
+// SetProgress reports service and step events to a progress observer
+func (m *Migrator) SetProgress(observer progress.Observer) {
+	m.progress = observer
+}
+
+// MetricsSnapshot returns the service worker metrics: queued services, busy
+// workers and finished services
+func (m *Migrator) MetricsSnapshot() workers.MetricsSnapshot {
+	return m.metrics.Snapshot()
+}
+
 // MigrateServices migrates multiple services across clusters
 func (m *Migrator) MigrateServices(ctx context.Context, services []string, clusters []ClusterInfo) error {
 	if m.dryRun {
 		m.log.InfoS("DRY RUN mode - no changes will be made")
 	}
 
-	// Check performance configuration
-	maxWorkers := 1
-	if m.config.Globals.Performance.MaxConcurrentServices > 0 {
-		maxWorkers = m.config.Globals.Performance.MaxConcurrentServices
+	if m.progress != nil {
+		m.progress.ServicesQueued(services)
 	}
 
+	queued := int32(len(services))
+	for range services {
+		m.metrics.RecordTaskStart()
+	}
+	m.metrics.RecordQueueDepth(queued)
+
+	maxWorkers := maxConcurrentServices(m.config)
 	if maxWorkers > 1 {
-		return m.processServicesParallel(ctx, services, clusters, maxWorkers)
+		return m.processServicesParallel(ctx, services, clusters, maxWorkers, &queued)
 	}
 
-	// Sequential processing
+	// Sequential processing; a failed service does not stop the others, as in
+	// parallel processing
+	var (
+		failedCount int
+		firstErr    error
+	)
 	for _, serviceName := range services {
-		if err := m.MigrateService(ctx, serviceName, clusters); err != nil {
+		if err := m.migrateQueuedService(ctx, serviceName, clusters, &queued); err != nil {
 			m.log.Error(err, "Failed to migrate service", "service", serviceName)
-			return err
+			failedCount++
+			if firstErr == nil {
+				firstErr = err
+			}
 		}
 	}
 
+	if failedCount > 0 {
+		// Keep the first failure in the chain so its error kind drives the exit code
+		return fmt.Errorf("%d services failed to migrate: %w", failedCount, firstErr)
+	}
 	return nil
 }
 
 // MigrateService migrates a single service across all clusters
 func (m *Migrator) MigrateService(ctx context.Context, serviceName string, clusters []ClusterInfo) error {
-	m.log.InfoS("Starting service migration", "service", serviceName, "clusters", len(clusters))
+	ctx = logger.NewContext(ctx, logger.FieldService, serviceName)
+	log := m.log.WithContext(ctx)
+
+	log.InfoS("Starting service migration", "service", serviceName, "clusters", len(clusters))
 	startTime := time.Now()
+	m.reportServiceStarted(serviceName)
 
 	// Get service configuration
 	serviceConfig := m.getServiceConfig(serviceName)
 	if serviceConfig != nil && !serviceConfig.Enabled {
-		m.log.InfoS("Service disabled in configuration, skipping", "service", serviceName)
+		log.InfoS("Service disabled in configuration, skipping", "service", serviceName)
+		m.reportServiceFinished(serviceName, nil)
 		return nil
 	}
 
+	if m.isJournaled(journal.ServiceKey(serviceName)) {
+		log.InfoS("Service already migrated in this run, skipping", "service", serviceName)
+		m.reportServiceFinished(serviceName, nil)
+		return nil
+	}
+	m.report(serviceName).StartReport(serviceName)
+
 	// Step 1: Copy base chart
+	m.reportStep(serviceName, "copy-base-chart", "", "")
 	if err := m.copyBaseChart(serviceName, serviceConfig); err != nil {
+		m.recordJournal(journal.Entry{TaskID: journal.ServiceKey(serviceName), Service: serviceName}, err)
+		m.reportServiceFinished(serviceName, err)
 		return fmt.Errorf("failed to copy base chart: %w", err)
 	}
 
 	// Step 2: Process Cluster (Helm Charts and Manifests)
+	var stepErr error
 	for _, cluster := range clusters {
 		if err := m.processCluster(ctx, serviceName, cluster, serviceConfig); err != nil {
-			m.log.Error(err, "Failed to process cluster", "cluster", cluster.Name)
+			log.Error(err, "Failed to process cluster", "cluster", cluster.Name)
+			stepErr = err
 			// Continue with other clusters
 		}
 	}
 
 	// Step 3: Transform values files
+	m.reportStep(serviceName, "transform", "", "")
 	if err := m.pipeline.TransformService(serviceName); err != nil {
-		m.log.Error(err, "Failed to transform service", "service", serviceName)
+		log.Error(err, "Failed to transform service", "service", serviceName, logger.FieldStep, "transform")
+		stepErr = err
+	}
+
+	// Step 4: Rewrite the values references of the chart templates
+	if !m.dryRun && m.config.Globals.Pipeline.IsStepEnabled(config.StepRewriteTemplates) {
+		m.reportStep(serviceName, "rewrite-templates", "", "")
+		if err := m.rewriteTemplates(serviceName); err != nil {
+			log.Error(err, "Failed to rewrite templates", "service", serviceName, logger.FieldStep, "rewrite-templates")
+			stepErr = err
+		}
 	}
 
-	// Step 4: Encrypt secrets if not disabled
+	// Step 5: Generate the values schema
+	if !m.dryRun && m.config.Globals.Pipeline.IsStepEnabled(config.StepGenerateValuesSchema) &&
+		m.config.Globals.ValuesSchema.IsEnabled() {
+		m.reportStep(serviceName, "generate-schema", "", "")
+		if err := m.generateValuesSchema(serviceName); err != nil {
+			log.Error(err, "Failed to generate values schema", "service", serviceName, logger.FieldStep, "generate-schema")
+			stepErr = err
+		}
+	}
+
+	// Step 6: Encrypt secrets if not disabled
 	if !m.noSOPS && !m.dryRun {
+		m.reportStep(serviceName, "encrypt-secrets", "", "")
 		if err := m.encryptServiceSecrets(serviceName); err != nil {
-			m.log.Error(err, "Failed to encrypt secrets", "service", serviceName)
+			log.Error(err, "Failed to encrypt secrets", "service", serviceName, logger.FieldStep, "encrypt-secrets")
+			stepErr = err
+		}
+	}
+
+	if !m.dryRun && m.reportDir != "" {
+		reportPath := filepath.Join(m.reportDir, serviceName+".md")
+		if err := m.report(serviceName).SaveReport(reportPath); err != nil {
+			log.Error(err, "Failed to save report", "path", reportPath)
 		}
 	}
 
+	m.recordJournal(journal.Entry{TaskID: journal.ServiceKey(serviceName), Service: serviceName}, stepErr)
+	m.reportServiceFinished(serviceName, stepErr)
+
 	duration := time.Since(startTime)
-	m.log.InfoS("Service migration completed",
+	if stepErr != nil {
+		// Failed steps fail the service, so the run can be resumed and exits non-zero
+		log.InfoS("Service migration finished with errors",
+			"service", serviceName,
+			"duration", duration.Round(time.Millisecond))
+		return fmt.Errorf("service %s: %w", serviceName, stepErr)
+	}
+
+	log.InfoS("Service migration completed",
 		"service", serviceName,
 		"duration", duration.Round(time.Millisecond))
 
@@ -146,7 +262,11 @@ func (m *Migrator) MigrateService(ctx context.Context, serviceName string, clust
 
 // processCluster processes a single cluster for a service
 func (m *Migrator) processCluster(ctx context.Context, serviceName string, cluster ClusterInfo, serviceConfig *config.Service) error {
-	m.log.V(1).InfoS("Processing cluster", "service", serviceName, "cluster", cluster.Name)
+	ctx = logger.NewContext(ctx, logger.FieldCluster, cluster.Name, logger.FieldStep, "extract")
+	log := m.log.WithContext(ctx)
+
+	log.V(1).InfoS("Processing cluster", "service", serviceName, "cluster", cluster.Name)
+	m.reportStep(serviceName, "fetch-releases", cluster.Name, "")
 
 	// Get releases from cluster
 	releases, err := m.getReleases(ctx, cluster)
@@ -157,26 +277,105 @@ func (m *Migrator) processCluster(ctx context.Context, serviceName string, clust
 	// Find service release
 	serviceRelease := m.helm.GetReleaseByName(serviceName, releases)
 	if serviceRelease == nil {
-		m.log.InfoS("Service not found in cluster, skipping",
+		log.InfoS("Service not found in cluster, skipping",
 			"service", serviceName,
 			"cluster", cluster.Name)
 		return nil
 	}
 
 	// Extract and save values for each namespace
+	failed := 0
 	for _, ns := range cluster.Namespaces {
-		if err := m.processNamespace(ctx, serviceName, cluster, ns, serviceRelease); err != nil {
-			m.log.Error(err, "Failed to process namespace",
+		key := journal.NamespaceKey(serviceName, cluster.Name, ns.Name)
+		if m.isJournaled(key) {
+			log.V(1).InfoS("Namespace already migrated in this run, skipping",
+				"service", serviceName,
+				"cluster", cluster.Name,
+				"namespace", ns.Name)
+			continue
+		}
+
+		m.reportStep(serviceName, "extract", cluster.Name, ns.Name)
+		err := m.processNamespace(ctx, serviceName, cluster, ns, serviceRelease)
+		if err != nil {
+			failed++
+			log.Error(err, "Failed to process namespace",
 				"namespace", ns.Name,
 				"cluster", cluster.Name)
 		}
+		m.recordJournal(journal.Entry{
+			TaskID:    key,
+			Service:   serviceName,
+			Cluster:   cluster.Name,
+			Namespace: ns.Name,
+		}, err)
 	}
 
+	if failed > 0 {
+		return fmt.Errorf("%d namespaces failed in cluster %s", failed, cluster.Name)
+	}
 	return nil
 }
 
+// reportServiceStarted notifies the progress observer, if any
+func (m *Migrator) reportServiceStarted(serviceName string) {
+	if m.progress != nil {
+		m.progress.ServiceStarted(serviceName)
+	}
+}
+
+// reportStep notifies the progress observer that a service entered a step
+func (m *Migrator) reportStep(serviceName, step, cluster, namespace string) {
+	if m.progress != nil {
+		m.progress.StepStarted(serviceName, step, cluster, namespace)
+	}
+}
+
+// reportServiceFinished notifies the progress observer that a service is done
+func (m *Migrator) reportServiceFinished(serviceName string, err error) {
+	if m.progress != nil {
+		m.progress.ServiceFinished(serviceName, err)
+	}
+}
+
+// report returns the transformation report of a service, creating it on first use
+func (m *Migrator) report(serviceName string) services.ReportService {
+	m.reportsMu.Lock()
+	defer m.reportsMu.Unlock()
+	report, ok := m.reports[serviceName]
+	if !ok {
+		report = services.NewReportService(m.config)
+		m.reports[serviceName] = report
+	}
+	return report
+}
+
+// isJournaled reports whether a task completed earlier in the journaled run
+func (m *Migrator) isJournaled(taskID string) bool {
+	return m.journal != nil && m.journal.IsCompleted(taskID)
+}
+
+// recordJournal records a task outcome; dry runs are never journaled
+func (m *Migrator) recordJournal(entry journal.Entry, err error) {
+	if m.journal == nil || m.dryRun {
+		return
+	}
+
+	entry.Status = journal.StatusCompleted
+	if err != nil {
+		entry.Status = journal.StatusFailed
+		entry.Error = err.Error()
+	}
+	if recErr := m.journal.Record(entry); recErr != nil {
+		m.log.Error(recErr, "Failed to record journal entry", "task", entry.TaskID)
+	}
+}
+
 // processNamespace processes a single namespace
 func (m *Migrator) processNamespace(ctx context.Context, serviceName string, cluster ClusterInfo, ns NamespaceInfo, release *release.Release) error {
+	ctx = logger.NewContext(ctx, logger.FieldNamespace, ns.Name)
+	log := m.log.WithContext(ctx)
+
 	// Build output path using centralized path management
 	paths := config.NewPaths("", "apps", ".cache").
 		ForService(serviceName).
@@ -185,7 +384,7 @@ func (m *Migrator) processNamespace(ctx context.Context, serviceName string, clu
 	outputPath := paths.EnvironmentNamespaceDir()
 
 	if m.dryRun {
-		m.log.InfoS("DRY RUN: Would save values", "path", outputPath)
+		log.InfoS("DRY RUN: Would save values", "path", outputPath)
 		return nil
 	}
 
@@ -209,9 +408,24 @@ func (m *Migrator) processNamespace(ctx context.Context, serviceName string, clu
 		return fmt.Errorf("failed to transform values: %w", err)
 	}
 
-	// Save values
+	// Import referenced Secrets and ConfigMaps when enabled
+	transformedValues, err = m.importReferencedObjects(ctx, serviceName, cluster, ns, release, transformedValues, outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to import referenced objects: %w", err)
+	}
+
+	// Save values, with the keys converted to the service's style
 	valuesPath := filepath.Join(outputPath, "values.yaml")
-	if err := m.file.WriteYAML(valuesPath, transformedValues); err != nil {
+	var valuesDoc interface{} = transformedValues
+	if m.config.Globals.Pipeline.IsStepEnabled(config.StepConvertLegacyKeycase) {
+		tree, renames, err := m.extractor.ConvertValues(serviceName, valuesPath, transformedValues)
+		if err != nil {
+			return fmt.Errorf("failed to convert keys: %w", err)
+		}
+		m.report(serviceName).RecordKeyRenames(valuesPath, renames)
+		valuesDoc = tree
+	}
+	if err := m.file.WriteYAML(valuesPath, valuesDoc); err != nil {
 		return fmt.Errorf("failed to save values: %w", err)
 	}
 
@@ -219,11 +433,11 @@ func (m *Migrator) processNamespace(ctx context.Context, serviceName string, clu
 	if manifest, err := m.helm.ExtractManifest(release); err == nil && manifest != "" {
 		manifestPath := filepath.Join(outputPath, "manifest.yaml")
 		if err := m.file.WriteYAML(manifestPath, manifest); err != nil {
-			m.log.Error(err, "Failed to save manifest", "path", manifestPath)
+			log.Error(err, "Failed to save manifest", "path", manifestPath)
 		}
 	}
 
-	m.log.V(2).InfoS("Processed namespace",
+	log.V(2).InfoS("Processed namespace",
 		"service", serviceName,
 		"cluster", cluster.Name,
 		"namespace", ns.Name,
@@ -288,6 +502,49 @@ func (m *Migrator) copyBaseChart(serviceName string, serviceConfig *config.Servi
 	return m.chartCopier.CopyBaseChartWithService(src, dst, serviceConfig)
 }
 
+// rewriteTemplates rewrites the .Values references of the service's templates
+// with the keys renamed converting its values in this run
+func (m *Migrator) rewriteTemplates(serviceName string) error {
+	serviceDir := config.NewPaths("", "apps", ".cache").ForService(serviceName).ServiceDir()
+	renames := m.extractor.ServiceRenames(serviceDir)
+	references, err := m.templates.RewriteTemplates(serviceName, serviceDir, renames)
+	if err != nil {
+		return err
+	}
+
+	unresolved := 0
+	for _, reference := range references {
+		if !reference.Resolved {
+			unresolved++
+		}
+	}
+	if len(references) > 0 {
+		m.log.InfoS("Rewrote template values references",
+			"service", serviceName,
+			"rewritten", len(references)-unresolved,
+			"unresolved", unresolved)
+	}
+	return nil
+}
+
+// generateValuesSchema infers the service's values.schema.json from its base
+// values and the values of every environment
+func (m *Migrator) generateValuesSchema(serviceName string) error {
+	serviceDir := config.NewPaths("", "apps", ".cache").ForService(serviceName).ServiceDir()
+	layers, err := schema.ServiceLayers(serviceDir)
+	if err != nil {
+		return err
+	}
+
+	inferred := schema.Infer(layers, schema.Options{MaxEnumValues: m.config.Globals.ValuesSchema.EnumLimit()})
+	schemaFile := filepath.Join(serviceDir, schema.Filename)
+	if err := inferred.Save(schemaFile); err != nil {
+		return err
+	}
+	m.log.InfoS("Generated values schema", "service", serviceName, "file", schemaFile, "layers", len(layers))
+	return nil
+}
+
 // encryptServiceSecrets encrypts all secret files for a service
 func (m *Migrator) encryptServiceSecrets(serviceName string) error {
 	paths := config.NewPaths("", "apps", ".cache").ForService(serviceName)
@@ -304,22 +561,19 @@ func (m *Migrator) encryptServiceSecrets(serviceName string) error {
 		return nil
 	}
 
-	// Encrypt in parallel
-	workers := 5
-	if m.config.Globals.SOPS.ParallelWorkers > 0 {
-		workers = m.config.Globals.SOPS.ParallelWorkers
-	}
-
-	return m.sops.EncryptBatch(secretFiles, workers)
+	// Encrypt in parallel with the configured number of workers
+	return m.sops.EncryptBatch(secretFiles, 0)
 }
 
 // processServicesParallel processes services in parallel
-func (m *Migrator) processServicesParallel(ctx context.Context, services []string, clusters []ClusterInfo, maxWorkers int) error {
+func (m *Migrator) processServicesParallel(ctx context.Context, services []string, clusters []ClusterInfo, maxWorkers int, queued *int32) error {
 	var (
 		wg             sync.WaitGroup
 		sem            = make(chan struct{}, maxWorkers)
 		completedCount int32
 		failedCount    int32
+		firstErr       error
+		firstErrOnce   sync.Once
 	)
 
 	totalServices := len(services)
@@ -339,8 +593,9 @@ func (m *Migrator) processServicesParallel(ctx context.Context, services []strin
 			defer func() { <-sem }()
 
 			// Process service
-			if err := m.MigrateService(ctx, svc, clusters); err != nil {
+			if err := m.migrateQueuedService(ctx, svc, clusters, queued); err != nil {
 				atomic.AddInt32(&failedCount, 1)
+				firstErrOnce.Do(func() { firstErr = err })
 				m.log.Error(err, "Failed to migrate service", "service", svc)
 			} else {
 				completed := atomic.AddInt32(&completedCount, 1)
@@ -361,12 +616,38 @@ func (m *Migrator) processServicesParallel(ctx context.Context, services []strin
 		"failed", atomic.LoadInt32(&failedCount))
 
 	if failedCount > 0 {
-		return fmt.Errorf("%d services failed to migrate", failedCount)
+		// Keep the first failure in the chain so its error kind drives the exit code
+		return fmt.Errorf("%d services failed to migrate: %w", failedCount, firstErr)
 	}
 
 	return nil
 }
 
+// migrateQueuedService takes a service off the queue and migrates it,
+// recording the worker metrics the progress view is fed with
+func (m *Migrator) migrateQueuedService(ctx context.Context, serviceName string, clusters []ClusterInfo, queued *int32) error {
+	m.metrics.RecordQueueDepth(atomic.AddInt32(queued, -1))
+	m.metrics.RecordWorkerStart()
+	defer m.metrics.RecordWorkerStop()
+
+	start := time.Now()
+	err := m.MigrateService(ctx, serviceName, clusters)
+	if err != nil {
+		m.metrics.RecordTaskFailed(time.Since(start), err)
+	} else {
+		m.metrics.RecordTaskComplete(time.Since(start))
+	}
+	return err
+}
+
+// maxConcurrentServices returns how many services are migrated at once
+func maxConcurrentServices(cfg *config.Config) int {
+	if cfg.Globals.Performance.MaxConcurrentServices > 0 {
+		return cfg.Globals.Performance.MaxConcurrentServices
+	}
+	return 1
+}
+
 // getServiceConfig returns the configuration for a service
 func (m *Migrator) getServiceConfig(serviceName string) *config.Service {
 	if m.config.Services == nil {
@@ -403,7 +684,7 @@ func (m *Migrator) Run(ctx context.Context) error {
 	// Get enabled enabledServices from config
 	enabledServices := m.getEnabledServices()
 	if len(enabledServices) == 0 {
-		return fmt.Errorf("no enabled enabledServices found in configuration")
+		return errkind.New(errkind.Configuration, "no enabled enabledServices found in configuration")
 	}
 
 	// Get enabled clusters from config
@@ -451,7 +732,7 @@ func (m *Migrator) getEnabledClusters() ([]ClusterInfo, error) {
 	var clusters []ClusterInfo
 
 	if m.config.Accounts == nil || len(m.config.Accounts) == 0 {
-		return nil, fmt.Errorf("no accounts configured")
+		return nil, errkind.New(errkind.Configuration, "no accounts configured")
 	}
 
 	for _, account := range m.config.Accounts {
@@ -488,7 +769,7 @@ func (m *Migrator) getEnabledClusters() ([]ClusterInfo, error) {
 	}
 
 	if len(clusters) == 0 {
-		return nil, fmt.Errorf("no enabled clusters found")
+		return nil, errkind.New(errkind.Configuration, "no enabled clusters found")
 	}
 
 	return clusters, nil
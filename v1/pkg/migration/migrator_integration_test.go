@@ -573,18 +573,14 @@ func (m *MockTransformService) Transform(data map[string]interface{}, config ser
 	return data, nil
 }
 
-func (m *MockTransformService) ConvertKeys(data map[string]interface{}) map[string]interface{} {
-	return data
+func (m *MockTransformService) ConvertKeys(serviceName string, data map[string]interface{}) (map[string]interface{}, error) {
+	return data, nil
 }
 
 func (m *MockTransformService) NormalizeKeys(values map[string]interface{}) map[string]interface{} {
 	return values
 }
 
-func (m *MockTransformService) ExtractSecrets(values map[string]interface{}) (secrets, cleaned map[string]interface{}) {
-	return map[string]interface{}{}, values
-}
-
 func (m *MockTransformService) MergeValues(base, override map[string]interface{}) map[string]interface{} {
 	return override
 }
@@ -629,6 +625,14 @@ func (m *MockSOPSService) IsEncrypted(filePath string) bool {
 	return false
 }
 
+func (m *MockSOPSService) Validate(filePath string) error {
+	return nil
+}
+
+func (m *MockSOPSService) Rotate(filePath string) (bool, error) {
+	return false, nil
+}
+
 // Test task implementations
 type TestTask struct {
 	id       string
@@ -0,0 +1,291 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/release"
+
+	"helm-charts-migrator/v1/pkg/adapters"
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/journal"
+	"helm-charts-migrator/v1/pkg/keycase"
+	"helm-charts-migrator/v1/pkg/progress"
+	"helm-charts-migrator/v1/pkg/services"
+)
+
+// testClusters is a single cluster with a single namespace
+func testClusters() []ClusterInfo {
+	return []ClusterInfo{{
+		Name:             "test-cluster",
+		Context:          "test-context",
+		DefaultNamespace: "default",
+		Namespaces:       []NamespaceInfo{{Name: "default", Environment: "production"}},
+	}}
+}
+
+// newTestMigrator creates a migrator writing real files under the working
+// directory, with the base chart in place
+func newTestMigrator(t *testing.T, cfg *config.Config, mocks *MockServices) *Migrator {
+	t.Chdir(t.TempDir())
+	createBaseChart(t, ".")
+	mocks.File = services.NewFileService()
+	return NewMigrator(cfg, mocks.Kubernetes, mocks.Helm, mocks.File,
+		mocks.Transform, mocks.Cache, mocks.SOPS, false, true)
+}
+
+// releasesKubernetesService lists a release per name
+type releasesKubernetesService struct {
+	MockKubernetesService
+	names []string
+}
+
+func (r *releasesKubernetesService) ListReleases(ctx context.Context, kubeContext, namespace string) ([]*release.Release, error) {
+	releases := make([]*release.Release, 0, len(r.names))
+	for _, name := range r.names {
+		releases = append(releases, &release.Release{Name: name, Namespace: namespace})
+	}
+	return releases, nil
+}
+
+// failingHelmService fails to extract the values of one release
+type failingHelmService struct {
+	MockHelmService
+	failing string
+}
+
+func (f *failingHelmService) ExtractValues(rel *release.Release) (map[string]interface{}, error) {
+	if rel.Name == f.failing {
+		return nil, fmt.Errorf("mock helm error")
+	}
+	return f.MockHelmService.ExtractValues(rel)
+}
+
+// valuesHelmService extracts the same values from every release
+type valuesHelmService struct {
+	MockHelmService
+	values map[string]interface{}
+}
+
+func (v *valuesHelmService) ExtractValues(rel *release.Release) (map[string]interface{}, error) {
+	return v.values, nil
+}
+
+// namespaceValuesPath is where the values of testClusters are written
+var namespaceValuesPath = filepath.Join("apps", "test-service", "envs", "production",
+	"clusters", "test-cluster", "namespaces", "default", "values.yaml")
+
+func TestMigrateServices_FailedStepFailsService(t *testing.T) {
+	mocks := NewMockServices()
+	mocks.Kubernetes = &ErrorKubernetesService{}
+	migrator := newTestMigrator(t, createTestConfig(false), mocks)
+
+	runJournal, err := journal.Open(t.TempDir(), "run", "hash")
+	require.NoError(t, err)
+	defer runJournal.Close()
+	migrator.SetJournal(runJournal)
+
+	err = migrator.MigrateServices(context.Background(), []string{"test-service"}, testClusters())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service test-service")
+	assert.Contains(t, err.Error(), "mock kubernetes error")
+	assert.False(t, runJournal.IsCompleted(journal.ServiceKey("test-service")))
+}
+
+func TestMigrateServices_FailedStepCountsAsFailed(t *testing.T) {
+	mocks := NewMockServices()
+	mocks.Kubernetes = &releasesKubernetesService{names: []string{"service-a", "service-b"}}
+	mocks.Helm = &failingHelmService{failing: "service-b"}
+	migrator := newTestMigrator(t, createTestConfigWithParallel(2), mocks)
+	tracker := progress.NewTracker()
+	tracker.WatchPool(migrator.MetricsSnapshot)
+	migrator.SetProgress(tracker)
+
+	err := migrator.MigrateServices(context.Background(), []string{"service-a", "service-b"}, testClusters())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 services failed")
+
+	snapshot := migrator.MetricsSnapshot()
+	assert.Equal(t, int64(2), snapshot.TotalTasks)
+	assert.Equal(t, int64(1), snapshot.CompletedTasks)
+	assert.Equal(t, int64(1), snapshot.FailedTasks)
+
+	view := tracker.Snapshot()
+	assert.Equal(t, 1, view.Done)
+	assert.Equal(t, 1, view.Failed)
+}
+
+func TestCreateMigrator_AwsProfile(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		opts       MigratorOptions
+		want       string
+	}{
+		{"flag given", "production-sre", MigratorOptions{AwsProfile: "ci", AwsProfileSet: true}, "ci"},
+		{"flag default", "production-sre", MigratorOptions{AwsProfile: "cicd-sre"}, "production-sre"},
+		{"flag default without config", "", MigratorOptions{AwsProfile: "cicd-sre"}, "cicd-sre"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := createTestConfig(false)
+			cfg.Globals.SOPS.AwsProfile = tt.configured
+			tt.opts.CacheDir = t.TempDir()
+
+			_, err := NewMigratorFactory(cfg).CreateMigrator(tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.Globals.SOPS.AwsProfile)
+		})
+	}
+}
+
+func TestMigrateServices_AppliesAutoInjectRules(t *testing.T) {
+	cfg := createTestConfig(false)
+	cfg.Services["test-service"] = config.Service{
+		Enabled: true,
+		AutoInject: map[string]config.AutoInjectFile{
+			"envs/*/clusters/test-cluster/**/values.yaml": {Keys: []config.AutoInjectKey{
+				{Key: `configMap."root.properties"."auth.user"`, Value: "{environment}-{namespace}", Condition: "ifNotExists"},
+			}},
+			"envs/*/clusters/other-cluster/**/values.yaml": {Keys: []config.AutoInjectKey{
+				{Key: "other", Value: "x", Condition: "always"},
+			}},
+		},
+	}
+	mocks := NewMockServices()
+	mocks.Kubernetes = &releasesKubernetesService{names: []string{"test-service"}}
+	migrator := newTestMigrator(t, cfg, mocks)
+
+	require.NoError(t, migrator.MigrateServices(context.Background(), []string{"test-service"}, testClusters()))
+
+	values := readValues(t, namespaceValuesPath)
+	assert.Equal(t, map[string]interface{}{"root.properties": map[string]interface{}{"auth.user": "production-default"}},
+		values["configMap"])
+	assert.NotContains(t, values, "other")
+}
+
+// readValues reads a values file written by the migration
+func readValues(t *testing.T, path string) map[string]interface{} {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var values map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(data, &values))
+	return values
+}
+
+func TestTransformService_SecretsConfiguration(t *testing.T) {
+	tests := []struct {
+		name          string
+		minConfidence string
+		secrets       map[string]interface{}
+		values        map[string]interface{}
+	}{
+		{
+			name:          "all matches moved",
+			minConfidence: "",
+			secrets:       map[string]interface{}{"clientId": "abc", "dbPassword": "hunter2"},
+			values:        map[string]interface{}{"passwordPolicy": "strict", "replicaCount": 2},
+		},
+		{
+			name:          "weaker matches left in place",
+			minConfidence: "high",
+			secrets:       map[string]interface{}{"clientId": "abc"},
+			values:        map[string]interface{}{"dbPassword": "hunter2", "passwordPolicy": "strict", "replicaCount": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			cfg := &config.Config{
+				Globals: config.Globals{Secrets: &config.Secrets{
+					Patterns:   []string{"(?i)password"},
+					Exclusions: []string{"passwordPolicy"},
+				}},
+				Services: map[string]config.Service{
+					"auth": {Enabled: true, Secrets: &config.Secrets{
+						Keys:          []string{"clientId"},
+						MinConfidence: tt.minConfidence,
+					}},
+				},
+			}
+			nsDir := filepath.Join("apps", "auth", "envs", "dev", "clusters", "dev01", "namespaces", "vf-dev2")
+			require.NoError(t, os.MkdirAll(nsDir, 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(nsDir, "values.yaml"),
+				[]byte("dbPassword: hunter2\nclientId: abc\npasswordPolicy: strict\nreplicaCount: 2\n"), 0644))
+
+			pipeline := adapters.NewTransformationPipeline(cfg, services.NewFileService(), services.NewTransformationService(cfg))
+			require.NoError(t, pipeline.TransformService("auth"))
+
+			assert.Equal(t, tt.values, readValues(t, filepath.Join(nsDir, "values.yaml")))
+			assert.Equal(t, tt.secrets, readValues(t, filepath.Join(nsDir, "secrets.dec.yaml"))["secrets"])
+		})
+	}
+}
+
+func TestMigrateServices_ConvertsValuesKeys(t *testing.T) {
+	mocks := NewMockServices()
+	mocks.Kubernetes = &releasesKubernetesService{names: []string{"test-service"}}
+	mocks.Helm = &valuesHelmService{values: map[string]interface{}{
+		"Database_Url": "postgres://db",
+		"image":        map[string]interface{}{"pull-policy": "Always"},
+	}}
+	migrator := newTestMigrator(t, createTestConfig(false), mocks)
+
+	require.NoError(t, migrator.MigrateServices(context.Background(), []string{"test-service"}, testClusters()))
+
+	assert.Equal(t, map[string]interface{}{
+		"databaseUrl": "postgres://db",
+		"image":       map[string]interface{}{"pullPolicy": "Always"},
+	}, readValues(t, namespaceValuesPath))
+}
+
+func TestMigrateServices_RecordsKeyRenames(t *testing.T) {
+	mocks := NewMockServices()
+	mocks.Kubernetes = &releasesKubernetesService{names: []string{"test-service"}}
+	mocks.Helm = &valuesHelmService{values: map[string]interface{}{
+		"Database_Url": "postgres://db",
+		"replicaCount": 2,
+	}}
+	migrator := newTestMigrator(t, createTestConfig(false), mocks)
+	reportDir := t.TempDir()
+	migrator.SetReportDir(reportDir)
+
+	require.NoError(t, migrator.MigrateServices(context.Background(), []string{"test-service"}, testClusters()))
+
+	saved, err := keycase.LoadResult(filepath.Join("apps", "test-service", keycase.RenamesFilename))
+	require.NoError(t, err)
+	assert.Equal(t, keycase.RenameMap{"Database_Url": "databaseUrl"}, saved.Renames)
+
+	report, err := migrator.report("test-service").GenerateReport()
+	require.NoError(t, err)
+	assert.Equal(t, keycase.RenameMap{"Database_Url": "databaseUrl"}, report.KeyRenames)
+
+	data, err := os.ReadFile(filepath.Join(reportDir, "test-service.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "| `Database_Url` | `databaseUrl` |")
+}
+
+func TestMigrateServices_RewritesTemplateReferences(t *testing.T) {
+	mocks := NewMockServices()
+	mocks.Kubernetes = &releasesKubernetesService{names: []string{"test-service"}}
+	mocks.Helm = &valuesHelmService{values: map[string]interface{}{"Database_Url": "postgres://db"}}
+	migrator := newTestMigrator(t, createTestConfig(false), mocks)
+	templatesDir := filepath.Join("migration", "base-chart", "templates")
+	require.NoError(t, os.MkdirAll(templatesDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(templatesDir, "configmap.yaml"),
+		[]byte("data:\n  url: {{ .Values.Database_Url }}\n"), 0644))
+
+	require.NoError(t, migrator.MigrateServices(context.Background(), []string{"test-service"}, testClusters()))
+
+	data, err := os.ReadFile(filepath.Join("apps", "test-service", "templates", "configmap.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "data:\n  url: {{ .Values.databaseUrl }}\n", string(data))
+}
@@ -0,0 +1,82 @@
+package migration
+
+import (
+	"context"
+	"path/filepath"
+
+	"helm.sh/helm/v3/pkg/release"
+
+	k8s "helm-charts-migrator/v1/pkg/kubernetes"
+	"helm-charts-migrator/v1/pkg/logger"
+)
+
+// importReferencedObjects copies the allowed Secrets and ConfigMaps used by the
+// release's workloads into the namespace level: ConfigMap data into the
+// configMap values and Secret data into secrets.dec.yaml, written with the
+// secrets extracted from the values and encrypted with the other secrets files
+func (m *Migrator) importReferencedObjects(ctx context.Context, serviceName string, cluster ClusterInfo, ns NamespaceInfo, rel *release.Release, values map[string]interface{}, outputPath string) (map[string]interface{}, error) {
+	importConfig := m.config.GetReferenceImport(serviceName)
+	if !importConfig.IsEnabled() {
+		return values, nil
+	}
+	log := m.log.WithContext(ctx)
+
+	refs, err := k8s.FindReferences(rel.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	allowed := refs.Filter(importConfig.Allows)
+	log.V(1).InfoS("Found referenced objects",
+		"secrets", len(refs.Secrets),
+		"configMaps", len(refs.ConfigMaps),
+		"allowedSecrets", len(allowed.Secrets),
+		"allowedConfigMaps", len(allowed.ConfigMaps))
+	if allowed.IsEmpty() {
+		return values, nil
+	}
+
+	clientset, err := m.kubernetes.GetClient(cluster.Context)
+	if err != nil {
+		return nil, err
+	}
+	namespace := rel.Namespace
+	if namespace == "" {
+		namespace = ns.Name
+	}
+
+	imported, err := k8s.NewClientForClientset(clientset, cluster.Context).ImportReferences(ctx, namespace, allowed)
+	if err != nil {
+		return nil, err
+	}
+	return m.applyImportedReferences(log, values, imported, filepath.Join(outputPath, "secrets.dec.yaml")), nil
+}
+
+// applyImportedReferences merges imported data into the values and queues the
+// imported secrets for the secrets file. Values already set by the release
+// take precedence over imported ones.
+func (m *Migrator) applyImportedReferences(log *logger.NamedLogger, values map[string]interface{}, imported *k8s.ImportedReferences, secretsPath string) map[string]interface{} {
+	for _, missing := range imported.Missing {
+		log.InfoS("Referenced object or key not found, not imported", "object", missing)
+	}
+
+	if len(imported.ConfigMap) > 0 {
+		values = m.transform.MergeValues(map[string]interface{}{"configMap": stringMapValues(imported.ConfigMap)}, values)
+	}
+
+	if len(imported.Secrets) == 0 {
+		return values
+	}
+
+	m.pipeline.AddImportedSecrets(secretsPath, stringMapValues(imported.Secrets))
+	log.V(1).InfoS("Queued imported secrets", "path", secretsPath, "keys", len(imported.Secrets))
+
+	return values
+}
+
+func stringMapValues(data map[string]string) map[string]interface{} {
+	values := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		values[key] = value
+	}
+	return values
+}
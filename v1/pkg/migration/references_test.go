@@ -0,0 +1,84 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm-charts-migrator/v1/pkg/adapters"
+	"helm-charts-migrator/v1/pkg/config"
+	k8s "helm-charts-migrator/v1/pkg/kubernetes"
+	"helm-charts-migrator/v1/pkg/logger"
+	"helm-charts-migrator/v1/pkg/services"
+)
+
+func newReferencesTestMigrator(t *testing.T) *Migrator {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Globals.Secrets = &config.Secrets{Patterns: []string{"(?i)password", "(?i)apikey"}}
+	file := services.NewFileService()
+	transform := services.NewTransformationService(cfg)
+	return &Migrator{
+		config:    cfg,
+		file:      file,
+		transform: transform,
+		pipeline:  adapters.NewTransformationPipeline(cfg, file, transform),
+		log:       logger.WithName("test"),
+	}
+}
+
+func TestApplyImportedReferences(t *testing.T) {
+	t.Chdir(t.TempDir())
+	m := newReferencesTestMigrator(t)
+
+	// A stale file from an earlier run is replaced, not merged into
+	nsDir := filepath.Join("apps", "auth", "envs", "dev", "clusters", "dev01", "namespaces", "vf-dev2")
+	secretsPath := filepath.Join(nsDir, "secrets.dec.yaml")
+	require.NoError(t, os.MkdirAll(nsDir, 0755))
+	require.NoError(t, os.WriteFile(secretsPath, []byte("secrets:\n  removed: stale\n  apiKey: old\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(nsDir, "values.yaml"), []byte("dbPassword: from-release\napiKey: from-release\nreplicaCount: 2\n"), 0644))
+
+	values := map[string]interface{}{
+		"configMap": map[string]interface{}{"application.properties": "from-release"},
+	}
+	imported := &k8s.ImportedReferences{
+		Secrets: map[string]string{"apiKey": "imported", "token": "imported"},
+		ConfigMap: map[string]string{
+			"application.properties": "from-object",
+			"logback.xml":            "<configuration/>",
+		},
+	}
+
+	result := m.applyImportedReferences(m.log, values, imported, secretsPath)
+	assert.Equal(t, map[string]interface{}{
+		"application.properties": "from-release",
+		"logback.xml":            "<configuration/>",
+	}, result["configMap"])
+	assert.NotContains(t, result, "secrets", "secret data must not end up in plaintext values")
+
+	require.NoError(t, m.pipeline.TransformService("auth"))
+
+	data, err := os.ReadFile(secretsPath)
+	require.NoError(t, err)
+	var doc map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(data, &doc))
+	assert.Equal(t, map[string]interface{}{
+		"dbPassword": "from-release",
+		"apiKey":     "from-release",
+		"token":      "imported",
+	}, doc["secrets"])
+}
+
+func TestApplyImportedReferencesWithoutSecrets(t *testing.T) {
+	m := newReferencesTestMigrator(t)
+	secretsPath := filepath.Join(t.TempDir(), "secrets.dec.yaml")
+
+	result := m.applyImportedReferences(m.log, map[string]interface{}{"replicaCount": 2}, &k8s.ImportedReferences{}, secretsPath)
+
+	assert.Equal(t, map[string]interface{}{"replicaCount": 2}, result)
+	assert.NoFileExists(t, secretsPath)
+}
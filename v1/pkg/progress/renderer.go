@@ -0,0 +1,221 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"helm-charts-migrator/v1/pkg/logger"
+)
+
+const (
+	// DefaultTTYInterval is how often the terminal view is redrawn
+	DefaultTTYInterval = 250 * time.Millisecond
+	// DefaultLogInterval is how often a progress line is logged without a TTY
+	DefaultLogInterval = 15 * time.Second
+	// maxServiceLines bounds the number of per-service lines in the terminal view
+	maxServiceLines = 20
+)
+
+// Renderer periodically renders a tracker's progress
+type Renderer struct {
+	tracker  *Tracker
+	out      io.Writer
+	tty      bool
+	interval time.Duration
+	lines    int
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+	log      *logger.NamedLogger
+}
+
+// NewRenderer creates a renderer writing to out. A redrawn view is used when
+// out is a terminal, logs are text and a run log is open to take the log lines
+// while the view owns the terminal; otherwise progress is logged periodically.
+func NewRenderer(tracker *Tracker, out io.Writer) *Renderer {
+	tty := isTerminal(out) && logger.GetFormat() == logger.FormatText && logger.RunLogPath() != ""
+	interval := DefaultLogInterval
+	if tty {
+		interval = DefaultTTYInterval
+	}
+
+	return &Renderer{
+		tracker:  tracker,
+		out:      out,
+		tty:      tty,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		log:      logger.WithName("progress"),
+	}
+}
+
+// Start begins rendering in the background. The terminal view diverts console
+// logging to the run log until Stop, so log lines cannot corrupt the redraw.
+func (r *Renderer) Start() {
+	if r.tty {
+		r.log.InfoS("Showing live progress, logs continue in the run log", "path", logger.RunLogPath())
+		logger.DivertConsole(true)
+	}
+	go r.run()
+}
+
+// Stop renders the final state and stops the renderer
+func (r *Renderer) Stop() {
+	r.once.Do(func() {
+		close(r.stop)
+		<-r.done
+		if r.tty {
+			logger.DivertConsole(false)
+		}
+	})
+}
+
+func (r *Renderer) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.render()
+		case <-r.stop:
+			r.render()
+			return
+		}
+	}
+}
+
+func (r *Renderer) render() {
+	snap := r.tracker.Snapshot()
+	if r.tty {
+		r.draw(snap)
+		return
+	}
+	r.logLine(snap)
+}
+
+// draw redraws the terminal view in place
+func (r *Renderer) draw(snap Snapshot) {
+	var b strings.Builder
+	if r.lines > 0 {
+		// Move back to the top of the previous view and clear it
+		fmt.Fprintf(&b, "\033[%dA\033[J", r.lines)
+	}
+
+	view := FormatView(snap)
+	b.WriteString(view)
+	r.lines = strings.Count(view, "\n")
+
+	_, _ = io.WriteString(r.out, b.String())
+}
+
+// logLine logs a one-line summary of the progress
+func (r *Renderer) logLine(snap Snapshot) {
+	var running []string
+	for _, sp := range snap.Services {
+		if sp.Status == StatusRunning {
+			running = append(running, fmt.Sprintf("%s(%s)", sp.Service, describeStep(sp)))
+		}
+	}
+
+	r.log.InfoS("Migration progress",
+		"completed", fmt.Sprintf("%d/%d", snap.Finished(), snap.Total),
+		"failed", snap.Failed,
+		"running", strings.Join(running, ","),
+		"queueDepth", snap.QueueDepth,
+		"elapsed", snap.Elapsed.Round(time.Second),
+		"eta", formatETA(snap))
+}
+
+// FormatView renders the multi-line terminal view of a snapshot
+func FormatView(snap Snapshot) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Migration progress: %d/%d services", snap.Finished(), snap.Total)
+	if snap.Failed > 0 {
+		fmt.Fprintf(&b, " (%d failed)", snap.Failed)
+	}
+	fmt.Fprintf(&b, " | queue %d | elapsed %s | ETA %s\n",
+		snap.QueueDepth, snap.Elapsed.Round(time.Second), formatETA(snap))
+
+	// Running services first, then failures; finished ones only show up in the counts
+	shown := 0
+	for _, status := range []Status{StatusRunning, StatusFailed} {
+		for _, sp := range snap.Services {
+			if sp.Status != status {
+				continue
+			}
+			if shown == maxServiceLines {
+				fmt.Fprintf(&b, "  ... %d more\n", snap.Running+snap.Failed-shown)
+				return b.String()
+			}
+			fmt.Fprintf(&b, "  %s %-30s %s\n", statusSymbol(sp.Status), sp.Service, describeStep(sp))
+			shown++
+		}
+	}
+	return b.String()
+}
+
+// describeStep returns "step cluster/namespace" for running services and the error for failed ones
+func describeStep(sp ServiceProgress) string {
+	if sp.Status == StatusFailed && sp.Error != nil {
+		return sp.Error.Error()
+	}
+
+	parts := []string{}
+	if sp.Step != "" {
+		parts = append(parts, sp.Step)
+	}
+	switch {
+	case sp.Cluster != "" && sp.Namespace != "":
+		parts = append(parts, sp.Cluster+"/"+sp.Namespace)
+	case sp.Cluster != "":
+		parts = append(parts, sp.Cluster)
+	}
+	if len(parts) == 0 {
+		return string(sp.Status)
+	}
+	return strings.Join(parts, " ")
+}
+
+func statusSymbol(status Status) string {
+	switch status {
+	case StatusRunning:
+		return ">"
+	case StatusDone:
+		return "+"
+	case StatusFailed:
+		return "x"
+	default:
+		return "."
+	}
+}
+
+func formatETA(snap Snapshot) string {
+	if snap.Total > 0 && snap.Finished() == snap.Total {
+		return "done"
+	}
+	if snap.ETA <= 0 {
+		return "unknown"
+	}
+	return snap.ETA.Round(time.Second).String()
+}
+
+// isTerminal reports whether w is a character device such as a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,209 @@
+// Package progress tracks migration progress and renders it live, either as
+// a redrawn terminal view or as periodic log lines.
+package progress
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"helm-charts-migrator/v1/pkg/workers"
+)
+
+// Observer receives step events from the migrator
+type Observer interface {
+	// ServicesQueued is called once with every service of the run
+	ServicesQueued(services []string)
+	// ServiceStarted is called when a service leaves the queue
+	ServiceStarted(service string)
+	// StepStarted is called whenever a service enters a new step
+	StepStarted(service, step, cluster, namespace string)
+	// ServiceFinished is called when a service is done, successfully or not
+	ServiceFinished(service string, err error)
+}
+
+// Status is the state of a single service
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+// ServiceProgress is the progress of a single service
+type ServiceProgress struct {
+	Service   string
+	Status    Status
+	Step      string
+	Cluster   string
+	Namespace string
+	Started   time.Time
+	Finished  time.Time
+	Error     error
+}
+
+// Snapshot is a point-in-time view of the whole run
+type Snapshot struct {
+	Total      int
+	Pending    int
+	Running    int
+	Done       int
+	Failed     int
+	QueueDepth int
+	Elapsed    time.Duration
+	ETA        time.Duration
+	Services   []ServiceProgress
+}
+
+// Finished returns the number of services that are done or failed
+func (s Snapshot) Finished() int {
+	return s.Done + s.Failed
+}
+
+// Tracker collects step events and pool metrics; it is safe for concurrent use
+type Tracker struct {
+	mu       sync.Mutex
+	started  time.Time
+	total    int
+	services map[string]*ServiceProgress
+	pool     func() workers.MetricsSnapshot
+	now      func() time.Time
+}
+
+// NewTracker creates an empty tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		started:  time.Now(),
+		services: make(map[string]*ServiceProgress),
+		now:      time.Now,
+	}
+}
+
+// ServicesQueued implements Observer; it registers the services as pending
+func (t *Tracker) ServicesQueued(services []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.started = t.now()
+	t.total = len(services)
+	for _, service := range services {
+		if _, exists := t.services[service]; !exists {
+			t.services[service] = &ServiceProgress{Service: service, Status: StatusPending}
+		}
+	}
+}
+
+// WatchPool feeds queue depth and task counts from a worker pool's metrics
+func (t *Tracker) WatchPool(snapshot func() workers.MetricsSnapshot) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pool = snapshot
+}
+
+// ServiceStarted implements Observer
+func (t *Tracker) ServiceStarted(service string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sp := t.service(service)
+	sp.Status = StatusRunning
+	sp.Started = t.now()
+}
+
+// StepStarted implements Observer
+func (t *Tracker) StepStarted(service, step, cluster, namespace string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sp := t.service(service)
+	if sp.Status == StatusPending {
+		sp.Status = StatusRunning
+		sp.Started = t.now()
+	}
+	sp.Step = step
+	sp.Cluster = cluster
+	sp.Namespace = namespace
+}
+
+// ServiceFinished implements Observer
+func (t *Tracker) ServiceFinished(service string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sp := t.service(service)
+	sp.Finished = t.now()
+	sp.Error = err
+	sp.Step = ""
+	sp.Cluster = ""
+	sp.Namespace = ""
+	if err != nil {
+		sp.Status = StatusFailed
+	} else {
+		sp.Status = StatusDone
+	}
+}
+
+// service returns the entry for a service, creating it if needed; callers must hold the lock
+func (t *Tracker) service(name string) *ServiceProgress {
+	sp, exists := t.services[name]
+	if !exists {
+		sp = &ServiceProgress{Service: name, Status: StatusPending}
+		t.services[name] = sp
+		if len(t.services) > t.total {
+			t.total = len(t.services)
+		}
+	}
+	return sp
+}
+
+// Snapshot returns the current progress
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snap := Snapshot{
+		Total:    t.total,
+		Elapsed:  t.now().Sub(t.started),
+		Services: make([]ServiceProgress, 0, len(t.services)),
+	}
+
+	for _, sp := range t.services {
+		snap.Services = append(snap.Services, *sp)
+		switch sp.Status {
+		case StatusPending:
+			snap.Pending++
+		case StatusRunning:
+			snap.Running++
+		case StatusDone:
+			snap.Done++
+		case StatusFailed:
+			snap.Failed++
+		}
+	}
+	sort.Slice(snap.Services, func(i, j int) bool {
+		return snap.Services[i].Service < snap.Services[j].Service
+	})
+
+	// Services waiting for a free slot form the queue, unless a pool reports its own
+	snap.QueueDepth = snap.Pending
+	if t.pool != nil {
+		metrics := t.pool()
+		snap.QueueDepth = int(metrics.QueueDepth)
+		if snap.Total == 0 {
+			snap.Total = int(metrics.TotalTasks)
+			snap.Done = int(metrics.CompletedTasks)
+			snap.Failed = int(metrics.FailedTasks)
+		}
+	}
+
+	// ETA from observed throughput, which already reflects concurrency
+	finished := snap.Finished()
+	remaining := snap.Total - finished
+	if finished > 0 && remaining > 0 {
+		snap.ETA = time.Duration(float64(snap.Elapsed) / float64(finished) * float64(remaining))
+	}
+
+	return snap
+}
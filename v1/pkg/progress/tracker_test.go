@@ -0,0 +1,100 @@
+package progress
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm-charts-migrator/v1/pkg/workers"
+)
+
+func newTestTracker() (*Tracker, *time.Time) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker()
+	tracker.now = func() time.Time { return now }
+	return tracker, &now
+}
+
+func TestTracker_ServiceLifecycle(t *testing.T) {
+	tracker, now := newTestTracker()
+	tracker.ServicesQueued([]string{"auth", "heimdall", "api", "billing"})
+
+	tracker.ServiceStarted("auth")
+	tracker.StepStarted("auth", "extract", "dev01", "vf-dev2")
+	tracker.ServiceStarted("heimdall")
+
+	snap := tracker.Snapshot()
+	assert.Equal(t, 4, snap.Total)
+	assert.Equal(t, 2, snap.Running)
+	assert.Equal(t, 2, snap.Pending)
+	assert.Equal(t, 2, snap.QueueDepth)
+	assert.Equal(t, time.Duration(0), snap.ETA)
+
+	*now = now.Add(time.Minute)
+	tracker.ServiceFinished("auth", nil)
+	tracker.ServiceFinished("heimdall", errors.New("boom"))
+
+	snap = tracker.Snapshot()
+	assert.Equal(t, 1, snap.Done)
+	assert.Equal(t, 1, snap.Failed)
+	assert.Equal(t, 2, snap.Finished())
+	// Two services per minute so far, two remaining
+	assert.Equal(t, time.Minute, snap.ETA)
+
+	require.Len(t, snap.Services, 4)
+	assert.Equal(t, "api", snap.Services[0].Service)
+	assert.Equal(t, StatusDone, snap.Services[1].Status)
+	assert.Empty(t, snap.Services[1].Step)
+}
+
+func TestTracker_WatchPool(t *testing.T) {
+	tracker, _ := newTestTracker()
+	tracker.WatchPool(func() workers.MetricsSnapshot {
+		return workers.MetricsSnapshot{TotalTasks: 10, CompletedTasks: 4, FailedTasks: 1, QueueDepth: 3}
+	})
+
+	snap := tracker.Snapshot()
+	assert.Equal(t, 10, snap.Total)
+	assert.Equal(t, 5, snap.Finished())
+	assert.Equal(t, 3, snap.QueueDepth)
+}
+
+func TestFormatView(t *testing.T) {
+	tracker, now := newTestTracker()
+	tracker.ServicesQueued([]string{"auth", "heimdall", "api"})
+	tracker.StepStarted("auth", "extract", "dev01", "vf-dev2")
+	tracker.ServiceStarted("heimdall")
+	*now = now.Add(30 * time.Second)
+	tracker.ServiceFinished("heimdall", errors.New("chart not found"))
+
+	view := FormatView(tracker.Snapshot())
+	lines := strings.Split(strings.TrimSpace(view), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "1/3 services (1 failed)")
+	assert.Contains(t, lines[0], "queue 1")
+	assert.Contains(t, lines[0], "ETA 1m0s")
+	assert.Contains(t, lines[1], "auth")
+	assert.Contains(t, lines[1], "extract dev01/vf-dev2")
+	assert.Contains(t, lines[2], "chart not found")
+}
+
+func TestRenderer_NonTTYFallsBackToLogs(t *testing.T) {
+	tracker, _ := newTestTracker()
+	var out bytes.Buffer
+
+	renderer := NewRenderer(tracker, &out)
+	assert.False(t, renderer.tty)
+	assert.Equal(t, DefaultLogInterval, renderer.interval)
+
+	renderer.Start()
+	renderer.Stop()
+	renderer.Stop()
+
+	// Progress goes to the log, never to the writer, when it is not a terminal
+	assert.Empty(t, out.String())
+}
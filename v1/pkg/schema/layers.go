@@ -0,0 +1,173 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+	"helm.sh/helm/v3/pkg/chartutil"
+)
+
+// ValuesFilename is the values file of every level of a migrated chart
+const ValuesFilename = "values.yaml"
+
+// Layer is one values file of a chart
+type Layer struct {
+	File   string
+	Values map[string]interface{}
+}
+
+// LayerError lists the schema violations of a layer's effective values
+type LayerError struct {
+	File   string
+	Errors []string
+}
+
+func (e LayerError) Error() string {
+	return fmt.Sprintf("%s: %s", e.File, strings.Join(e.Errors, "; "))
+}
+
+// ServiceLayers reads a migrated chart's values.yaml, then every values.yaml
+// beneath its envs directory, parents before children
+func ServiceLayers(serviceDir string) ([]Layer, error) {
+	base, err := readLayer(filepath.Join(serviceDir, ValuesFilename))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	envsDir := filepath.Join(serviceDir, "envs")
+	err = filepath.Walk(envsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && info.Name() == ValuesFilename {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list values files: %w", err)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		di, dj := strings.Count(files[i], string(filepath.Separator)), strings.Count(files[j], string(filepath.Separator))
+		if di != dj {
+			return di < dj
+		}
+		return files[i] < files[j]
+	})
+
+	layers := []Layer{base}
+	for _, file := range files {
+		layer, err := readLayer(file)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+// readLayer reads a values file; an empty file has no values
+func readLayer(file string) (Layer, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return Layer{}, fmt.Errorf("failed to read values file: %w", err)
+	}
+
+	values := make(map[string]interface{})
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return Layer{}, fmt.Errorf("failed to parse values file %s: %w", file, err)
+		}
+	}
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	return Layer{File: file, Values: values}, nil
+}
+
+// Validate checks the effective values of every layer against a schema, the
+// way Helm does on install: the layer is merged over the layers of its parent
+// directories, the first layer being the chart's base values. Violations
+// already reported for a parent layer are not repeated for its children.
+func Validate(schemaJSON []byte, layers []Layer) ([]LayerError, error) {
+	var layerErrors []LayerError
+	violations := make([]map[string]bool, len(layers))
+
+	for i, layer := range layers {
+		effective := make(map[string]interface{})
+		inherited := make(map[string]bool)
+		for j := 0; j <= i; j++ {
+			if j < i && !isParent(layers[j].File, layer.File) {
+				continue
+			}
+			effective = chartutil.CoalesceTables(copyValues(layers[j].Values), effective)
+			if j < i {
+				for violation := range violations[j] {
+					inherited[violation] = true
+				}
+			}
+		}
+
+		violations[i] = make(map[string]bool)
+		err := chartutil.ValidateAgainstSingleSchema(effective, schemaJSON)
+		var validationErr chartutil.JSONSchemaValidationError
+		if err != nil && !errors.As(err, &validationErr) {
+			return nil, fmt.Errorf("failed to validate %s: %w", layer.File, err)
+		}
+		if err == nil {
+			continue
+		}
+
+		var reported []string
+		for _, line := range strings.Split(err.Error(), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			violations[i][line] = true
+			if !inherited[line] {
+				reported = append(reported, line)
+			}
+		}
+		if len(reported) > 0 {
+			layerErrors = append(layerErrors, LayerError{File: layer.File, Errors: reported})
+		}
+	}
+	return layerErrors, nil
+}
+
+// isParent reports whether a values file sits in a parent directory of another
+func isParent(parent, child string) bool {
+	parentDir, childDir := filepath.Dir(parent), filepath.Dir(child)
+	return parentDir != childDir && strings.HasPrefix(childDir, parentDir+string(filepath.Separator))
+}
+
+// copyValues deep-copies values, which merging modifies
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		copied[key] = copyValue(value)
+	}
+	return copied
+}
+
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return copyValues(v)
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyValue(item)
+		}
+		return copied
+	default:
+		return value
+	}
+}
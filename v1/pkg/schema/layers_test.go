@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeValues(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestServiceLayers(t *testing.T) {
+	dir := t.TempDir()
+	writeValues(t, filepath.Join(dir, "values.yaml"), "replicaCount: 1\n")
+	writeValues(t, filepath.Join(dir, "envs", "production", "clusters", "prod01", "namespaces", "viafoura", "values.yaml"), "replicaCount: 3\n")
+	writeValues(t, filepath.Join(dir, "envs", "production", "values.yaml"), "# only comments\n")
+	writeValues(t, filepath.Join(dir, "envs", "production", "legacy-values.yaml"), "replica_count: 3\n")
+
+	layers, err := ServiceLayers(dir)
+	require.NoError(t, err)
+	require.Len(t, layers, 3)
+	assert.Equal(t, filepath.Join(dir, "values.yaml"), layers[0].File)
+	assert.Equal(t, filepath.Join(dir, "envs", "production", "values.yaml"), layers[1].File)
+	assert.Empty(t, layers[1].Values)
+	assert.Equal(t, 3, layers[2].Values["replicaCount"])
+
+	_, err = ServiceLayers(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	base := Layer{File: "/apps/svc/values.yaml", Values: map[string]interface{}{
+		"replicaCount": 1,
+		"image":        map[string]interface{}{"tag": "1.0.0"},
+	}}
+	production := Layer{File: "/apps/svc/envs/production/values.yaml", Values: map[string]interface{}{
+		"replicaCount": 2,
+	}}
+	schemaJSON, err := Infer([]Layer{base, production}, Options{}).Marshal()
+	require.NoError(t, err)
+
+	t.Run("valid layers", func(t *testing.T) {
+		layerErrors, err := Validate(schemaJSON, []Layer{base, production})
+		require.NoError(t, err)
+		assert.Empty(t, layerErrors)
+	})
+
+	t.Run("misspelt key and wrong type", func(t *testing.T) {
+		broken := Layer{File: "/apps/svc/envs/production/values.yaml", Values: map[string]interface{}{
+			"replicaCout": 2,
+			"image":       map[string]interface{}{"tag": 100},
+		}}
+		namespace := Layer{File: "/apps/svc/envs/production/clusters/prod01/namespaces/viafoura/values.yaml", Values: map[string]interface{}{
+			"image": map[string]interface{}{"tag": nil},
+		}}
+
+		layerErrors, err := Validate(schemaJSON, []Layer{base, broken, namespace})
+		require.NoError(t, err)
+		require.Len(t, layerErrors, 2)
+		assert.Equal(t, broken.File, layerErrors[0].File)
+		assert.Contains(t, layerErrors[0].Error(), "replicaCout")
+		assert.Contains(t, layerErrors[0].Error(), "/image/tag")
+
+		// The namespace removes the tag; the parent's misspelt key is not repeated
+		assert.Equal(t, namespace.File, layerErrors[1].File)
+		assert.NotContains(t, layerErrors[1].Error(), "replicaCout")
+		assert.Contains(t, layerErrors[1].Error(), "tag")
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		_, err := Validate([]byte("{"), []Layer{base})
+		assert.Error(t, err)
+	})
+}
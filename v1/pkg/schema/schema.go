@@ -0,0 +1,222 @@
+// Package schema infers a JSON Schema for a chart's values from the values of
+// its environments and validates values layers against it
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Filename is the schema file Helm validates values against, next to Chart.yaml
+const Filename = "values.schema.json"
+
+// draft is the JSON Schema version written
+const draft = "http://json-schema.org/draft-07/schema#"
+
+// globalKey holds the values Helm shares with subcharts
+const globalKey = "global"
+
+// Schema is the subset of JSON Schema inferred for chart values
+type Schema struct {
+	Draft                string             `json:"$schema,omitempty"`
+	Type                 Types              `json:"type,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+// Types are the JSON types a value may have, written as a string when there is one
+type Types []string
+
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*t = list
+	return nil
+}
+
+// Options tune the inference
+type Options struct {
+	// MaxEnumValues is the most distinct string values a key may take to
+	// become an enum; 0 disables enums
+	MaxEnumValues int
+}
+
+// observation accumulates the values seen at one path
+type observation struct {
+	types      map[string]bool
+	properties map[string]*observation
+	required   map[string]int // times a key was set in the base values
+	baseSeen   int            // times an object was seen in the base values
+	closed     bool
+	items      *observation
+	strings    map[string]int
+	stringSeen int
+}
+
+func newObservation() *observation {
+	return &observation{
+		types:      make(map[string]bool),
+		properties: make(map[string]*observation),
+		required:   make(map[string]int),
+		strings:    make(map[string]int),
+	}
+}
+
+// Infer infers a schema from values layers, the first being the chart's base
+// values. Keys set in the base values are required, and objects with keys in
+// the base values accept no other keys than the ones seen in any layer, so a
+// misspelt key fails validation. Objects empty in the base values, such as
+// annotations, accept any key.
+func Infer(layers []Layer, opts Options) *Schema {
+	root := newObservation()
+	for i, layer := range layers {
+		root.observe(layer.Values, i == 0)
+	}
+
+	schema := root.build(opts)
+	schema.Draft = draft
+	if _, ok := schema.Properties[globalKey]; !ok && schema.AdditionalProperties != nil {
+		// Helm adds the global values when the chart has dependencies
+		if schema.Properties == nil {
+			schema.Properties = make(map[string]*Schema)
+		}
+		schema.Properties[globalKey] = &Schema{Type: Types{"object"}}
+	}
+	return schema
+}
+
+// observe records a value; base values also record required keys
+func (o *observation) observe(value interface{}, base bool) {
+	switch v := value.(type) {
+	case nil:
+		o.types["null"] = true
+	case map[string]interface{}:
+		o.types["object"] = true
+		if base {
+			o.baseSeen++
+			o.closed = o.closed || len(v) > 0
+		}
+		for key, child := range v {
+			if base && child != nil {
+				o.required[key]++
+			}
+			property, ok := o.properties[key]
+			if !ok {
+				property = newObservation()
+				o.properties[key] = property
+			}
+			property.observe(child, base)
+		}
+	case []interface{}:
+		o.types["array"] = true
+		if o.items == nil {
+			o.items = newObservation()
+		}
+		for _, item := range v {
+			o.items.observe(item, base)
+		}
+	case string:
+		o.types["string"] = true
+		o.strings[v]++
+		o.stringSeen++
+	case bool:
+		o.types["boolean"] = true
+	case int, int32, int64, uint, uint32, uint64:
+		o.types["integer"] = true
+	case float32, float64:
+		o.types["number"] = true
+	}
+}
+
+// build turns the observations of a path into a schema
+func (o *observation) build(opts Options) *Schema {
+	schema := &Schema{}
+	if o.types["number"] {
+		// Integers are numbers too
+		delete(o.types, "integer")
+	}
+	for name := range o.types {
+		schema.Type = append(schema.Type, name)
+	}
+	sort.Strings(schema.Type)
+
+	if len(o.properties) > 0 {
+		schema.Properties = make(map[string]*Schema, len(o.properties))
+		for key, property := range o.properties {
+			schema.Properties[key] = property.build(opts)
+		}
+	}
+	// Items of a list differ, so a key is required when every base object has it
+	for key, seen := range o.required {
+		if seen == o.baseSeen {
+			schema.Required = append(schema.Required, key)
+		}
+	}
+	sort.Strings(schema.Required)
+	if o.closed {
+		closed := false
+		schema.AdditionalProperties = &closed
+	}
+
+	if o.items != nil && len(o.items.types) > 0 {
+		schema.Items = o.items.build(opts)
+	}
+
+	// Only a few distinct strings, each seen repeatedly on average, look like a
+	// closed set; a single value is just a default
+	onlyStrings := len(o.types) == 1 && o.types["string"]
+	distinct := len(o.strings)
+	if onlyStrings && distinct > 1 && distinct <= opts.MaxEnumValues && o.stringSeen > distinct {
+		for value := range o.strings {
+			schema.Enum = append(schema.Enum, value)
+		}
+		sort.Slice(schema.Enum, func(i, j int) bool {
+			return schema.Enum[i].(string) < schema.Enum[j].(string)
+		})
+	}
+	return schema
+}
+
+// Marshal returns the schema as indented JSON
+func (s *Schema) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Save writes the schema
+func (s *Schema) Save(path string) error {
+	data, err := s.Marshal()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfer(t *testing.T) {
+	layers := []Layer{
+		{File: "values.yaml", Values: map[string]interface{}{
+			"replicaCount": 1,
+			"image": map[string]interface{}{
+				"repository": "nginx",
+				"pullPolicy": "IfNotPresent",
+				"tag":        nil,
+			},
+			"podAnnotations": map[string]interface{}{},
+			"ports": []interface{}{
+				map[string]interface{}{"name": "http", "port": 80},
+				map[string]interface{}{"name": "grpc"},
+			},
+		}},
+		{File: "envs/production/values.yaml", Values: map[string]interface{}{
+			"replicaCount":   2.5,
+			"image":          map[string]interface{}{"pullPolicy": "Always", "tag": "1.0.0"},
+			"podAnnotations": map[string]interface{}{"team": "sre"},
+		}},
+		{File: "envs/staging/values.yaml", Values: map[string]interface{}{
+			"image": map[string]interface{}{"pullPolicy": "Always", "tag": "1.1.0"},
+		}},
+	}
+
+	schema := Infer(layers, Options{MaxEnumValues: 5})
+
+	assert.Equal(t, draft, schema.Draft)
+	assert.Equal(t, Types{"object"}, schema.Type)
+	assert.Equal(t, []string{"image", "podAnnotations", "ports", "replicaCount"}, schema.Required)
+	require.NotNil(t, schema.AdditionalProperties)
+	assert.False(t, *schema.AdditionalProperties)
+	assert.Contains(t, schema.Properties, "global")
+
+	assert.Equal(t, Types{"number"}, schema.Properties["replicaCount"].Type)
+
+	image := schema.Properties["image"]
+	assert.Equal(t, []string{"pullPolicy", "repository"}, image.Required)
+	assert.Equal(t, []interface{}{"Always", "IfNotPresent"}, image.Properties["pullPolicy"].Enum)
+	assert.Equal(t, Types{"null", "string"}, image.Properties["tag"].Type)
+	assert.Empty(t, image.Properties["tag"].Enum)
+	assert.Empty(t, image.Properties["repository"].Enum, "a single value is a default, not an enum")
+
+	annotations := schema.Properties["podAnnotations"]
+	assert.Nil(t, annotations.AdditionalProperties, "objects empty in the base values accept any key")
+
+	ports := schema.Properties["ports"]
+	assert.Equal(t, Types{"array"}, ports.Type)
+	assert.Equal(t, []string{"name"}, ports.Items.Required)
+	assert.Equal(t, Types{"integer"}, ports.Items.Properties["port"].Type)
+}
+
+func TestInfer_EnumsDisabled(t *testing.T) {
+	layers := []Layer{
+		{Values: map[string]interface{}{"mode": "a"}},
+		{Values: map[string]interface{}{"mode": "b"}},
+		{Values: map[string]interface{}{"mode": "a"}},
+	}
+
+	assert.Equal(t, []interface{}{"a", "b"}, Infer(layers, Options{MaxEnumValues: 2}).Properties["mode"].Enum)
+	assert.Empty(t, Infer(layers, Options{MaxEnumValues: 1}).Properties["mode"].Enum)
+	assert.Empty(t, Infer(layers, Options{}).Properties["mode"].Enum)
+}
+
+func TestTypes_JSON(t *testing.T) {
+	data, err := json.Marshal(&Schema{Type: Types{"string"}, Items: &Schema{Type: Types{"integer", "string"}}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "string", "items": {"type": ["integer", "string"]}}`, string(data))
+
+	var schema Schema
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, Types{"string"}, schema.Type)
+	assert.Equal(t, Types{"integer", "string"}, schema.Items.Type)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/sops"
 	yaml "github.com/elioetibr/golang-yaml-advanced"
 )
 
@@ -166,6 +167,20 @@ func New(cfg *config.Config) (*SecretExtractor, error) {
 		serviceLocations:     make(map[string]*compiledLocations),
 	}
 
+	// Validate confidence thresholds
+	if cfg.Globals.Secrets != nil && cfg.Globals.Secrets.MinConfidence != "" {
+		if _, err := ParseConfidence(cfg.Globals.Secrets.MinConfidence); err != nil {
+			return nil, fmt.Errorf("invalid global minConfidence: %w", err)
+		}
+	}
+	for serviceName, serviceConfig := range cfg.Services {
+		if serviceConfig.Secrets != nil && serviceConfig.Secrets.MinConfidence != "" {
+			if _, err := ParseConfidence(serviceConfig.Secrets.MinConfidence); err != nil {
+				return nil, fmt.Errorf("invalid minConfidence for service '%s': %w", serviceName, err)
+			}
+		}
+	}
+
 	// Compile global key patterns
 	if cfg.Globals.Secrets != nil {
 		for _, pattern := range cfg.Globals.Secrets.Patterns {
@@ -496,6 +511,18 @@ func (e *SecretExtractor) analyzeKeyValue(key string, value interface{}, path st
 		return
 	}
 
+	if secretMatch, found := e.matchKeyValue(key, stringValue, path, serviceName); found {
+		result.Secrets = append(result.Secrets, secretMatch)
+	}
+}
+
+// matchKeyValue runs the exact key, key, UUID and value patterns against a
+// single string value, regardless of the configured scan locations
+func (e *SecretExtractor) matchKeyValue(key, stringValue, path, serviceName string) (SecretMatch, bool) {
+	if e.isExcludedKey(key, serviceName) {
+		return SecretMatch{}, false
+	}
+
 	secretMatch := SecretMatch{
 		Path:        path,
 		Key:         key,
@@ -529,14 +556,52 @@ func (e *SecretExtractor) analyzeKeyValue(key string, value interface{}, path st
 	e.checkValuePatterns(stringValue, serviceName, &secretMatch)
 
 	// If we found any matches, add to results
-	if len(secretMatch.MatchedBy) > 0 {
-		e.classifySecret(&secretMatch)
-		// Only set default confidence if none was set by classifySecret
-		if secretMatch.Confidence == "" {
-			secretMatch.Confidence = ConfidenceMedium
+	if len(secretMatch.MatchedBy) == 0 {
+		return secretMatch, false
+	}
+
+	e.classifySecret(&secretMatch)
+	// Only set default confidence if none was set by classifySecret
+	if secretMatch.Confidence == "" {
+		secretMatch.Confidence = ConfidenceMedium
+	}
+	return secretMatch, true
+}
+
+// isExcludedKey checks if key is excluded globally or for the service
+func (e *SecretExtractor) isExcludedKey(key, serviceName string) bool {
+	if e.config.Globals.Secrets != nil && containsKey(e.config.Globals.Secrets.Exclusions, key) {
+		return true
+	}
+	serviceConfig, exists := e.config.Services[serviceName]
+	return exists && serviceConfig.Secrets != nil && containsKey(serviceConfig.Secrets.Exclusions, key)
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
 		}
-		result.Secrets = append(result.Secrets, secretMatch)
 	}
+	return false
+}
+
+// MinConfidence returns the lowest confidence moved automatically for a
+// service: its own threshold, else the global one, else low
+func (e *SecretExtractor) MinConfidence(serviceName string) ConfidenceLevel {
+	if serviceConfig, exists := e.config.Services[serviceName]; exists && serviceConfig.Secrets != nil && serviceConfig.Secrets.MinConfidence != "" {
+		return ConfidenceLevel(strings.ToLower(serviceConfig.Secrets.MinConfidence))
+	}
+	if e.config.Globals.Secrets != nil && e.config.Globals.Secrets.MinConfidence != "" {
+		return ConfidenceLevel(strings.ToLower(e.config.Globals.Secrets.MinConfidence))
+	}
+	return ConfidenceLow
+}
+
+// NeedsReview reports whether a match is below the service's confidence
+// threshold and should be confirmed before it is moved
+func (e *SecretExtractor) NeedsReview(match SecretMatch, serviceName string) bool {
+	return confidenceRank(match.Confidence) < confidenceRank(e.MinConfidence(serviceName))
 }
 
 // checkServiceExactKeys checks if key matches exact service-specific keys
@@ -772,10 +837,7 @@ func (e *SecretExtractor) classifySecret(match *SecretMatch) {
 
 // Helper functions
 func (e *SecretExtractor) maskValue(value string) string {
-	if len(value) <= 8 {
-		return strings.Repeat("*", len(value))
-	}
-	return value[:4] + strings.Repeat("*", len(value)-8) + value[len(value)-4:]
+	return sops.MaskValue(value)
 }
 
 func (e *SecretExtractor) isUUIDValue(value string) bool {
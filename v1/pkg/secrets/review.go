@@ -0,0 +1,169 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+)
+
+// Decision is the reviewer's verdict on a candidate
+type Decision string
+
+const (
+	DecisionPending Decision = "pending"
+	DecisionAccept  Decision = "accept"
+	DecisionReject  Decision = "reject"
+)
+
+// ReviewCandidate is a match below the confidence threshold awaiting a decision
+type ReviewCandidate struct {
+	Service        string          `yaml:"service"`
+	File           string          `yaml:"file"`
+	Path           string          `yaml:"path"`
+	Key            string          `yaml:"key"`
+	MaskedValue    string          `yaml:"masked_value"`
+	Classification Classification  `yaml:"classification"`
+	Confidence     ConfidenceLevel `yaml:"confidence"`
+	Decision       Decision        `yaml:"decision"`
+}
+
+// Review is the content of a review file. Candidates are listed once per
+// service and key, since decisions are recorded per key.
+type Review struct {
+	Candidates []ReviewCandidate `yaml:"candidates"`
+}
+
+// ServiceDecisions are the keys accepted and rejected for a service
+type ServiceDecisions struct {
+	Accepted []string
+	Rejected []string
+}
+
+// LoadReview reads a review file; a missing file is an empty review
+func LoadReview(path string) (*Review, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Review{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read review file: %w", err)
+	}
+
+	var review Review
+	if err := yaml.Unmarshal(data, &review); err != nil {
+		return nil, fmt.Errorf("failed to parse review file %s: %w", path, err)
+	}
+	for i := range review.Candidates {
+		candidate := &review.Candidates[i]
+		switch candidate.Decision {
+		case "":
+			candidate.Decision = DecisionPending
+		case DecisionPending, DecisionAccept, DecisionReject:
+		default:
+			return nil, fmt.Errorf("invalid decision %q for %s key %s in %s, expected accept, reject or pending",
+				candidate.Decision, candidate.Service, candidate.Key, path)
+		}
+	}
+	return &review, nil
+}
+
+// Save writes the review file, or removes it when no candidate is left
+func (r *Review) Save(path string) error {
+	if len(r.Candidates) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove review file: %w", err)
+		}
+		return nil
+	}
+
+	data, err := yaml.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal review file: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write review file: %w", err)
+	}
+	return nil
+}
+
+// Add lists a pending candidate unless its service and key are already listed
+func (r *Review) Add(candidate ReviewCandidate) bool {
+	for _, existing := range r.Candidates {
+		if existing.Service == candidate.Service && existing.Key == candidate.Key {
+			return false
+		}
+	}
+	candidate.Decision = DecisionPending
+	r.Candidates = append(r.Candidates, candidate)
+	return true
+}
+
+// Pending returns the candidates still awaiting a decision
+func (r *Review) Pending() []*ReviewCandidate {
+	var pending []*ReviewCandidate
+	for i := range r.Candidates {
+		if r.Candidates[i].Decision == DecisionPending {
+			pending = append(pending, &r.Candidates[i])
+		}
+	}
+	return pending
+}
+
+// Decisions returns the accepted and rejected keys per service
+func (r *Review) Decisions() map[string]ServiceDecisions {
+	decisions := make(map[string]ServiceDecisions)
+	for _, candidate := range r.Candidates {
+		decided := decisions[candidate.Service]
+		switch candidate.Decision {
+		case DecisionAccept:
+			decided.Accepted = append(decided.Accepted, candidate.Key)
+		case DecisionReject:
+			decided.Rejected = append(decided.Rejected, candidate.Key)
+		default:
+			continue
+		}
+		decisions[candidate.Service] = decided
+	}
+	return decisions
+}
+
+// RemoveDecided drops the candidates that have a decision, once recorded
+func (r *Review) RemoveDecided() {
+	pending := make([]ReviewCandidate, 0, len(r.Candidates))
+	for _, candidate := range r.Candidates {
+		if candidate.Decision == DecisionPending {
+			pending = append(pending, candidate)
+		}
+	}
+	r.Candidates = pending
+}
+
+// ReviewCandidates returns the matches of a values file that are below the
+// service's confidence threshold, sorted by path
+func (e *SecretExtractor) ReviewCandidates(yamlData []byte, serviceName, file string) ([]ReviewCandidate, error) {
+	extraction, err := e.ExtractSecrets(yamlData, serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []ReviewCandidate
+	for _, match := range extraction.Secrets {
+		if !e.NeedsReview(match, serviceName) {
+			continue
+		}
+		candidates = append(candidates, ReviewCandidate{
+			Service:        serviceName,
+			File:           file,
+			Path:           match.Path,
+			Key:            match.Key,
+			MaskedValue:    match.MaskedValue,
+			Classification: match.Classification,
+			Confidence:     match.Confidence,
+			Decision:       DecisionPending,
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Path < candidates[j].Path })
+	return candidates, nil
+}
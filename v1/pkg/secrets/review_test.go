@@ -0,0 +1,140 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const reviewValues = `configMap:
+  db:
+    password: hunter2hunter2
+  3f4beddd-2061-49b0-ae80-6f1f2ed65b37: client-secret-value
+  plain: hello
+`
+
+func TestExtractor_MinConfidence(t *testing.T) {
+	cfg := createTestConfig()
+	extractor, err := New(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, ConfidenceLow, extractor.MinConfidence("heimdall"), "defaults to moving every match")
+
+	cfg.Globals.Secrets.MinConfidence = "Medium"
+	heimdall := cfg.Services["heimdall"]
+	heimdall.Secrets.MinConfidence = "high"
+	cfg.Services["heimdall"] = heimdall
+	extractor, err = New(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, ConfidenceHigh, extractor.MinConfidence("heimdall"))
+	assert.Equal(t, ConfidenceMedium, extractor.MinConfidence("other"))
+
+	assert.True(t, extractor.NeedsReview(SecretMatch{Confidence: ConfidenceMedium}, "heimdall"))
+	assert.False(t, extractor.NeedsReview(SecretMatch{Confidence: ConfidenceHigh}, "heimdall"))
+
+	cfg.Globals.Secrets.MinConfidence = "certain"
+	_, err = New(cfg)
+	assert.ErrorContains(t, err, "minConfidence")
+}
+
+func TestExtractor_Exclusions(t *testing.T) {
+	cfg := createTestConfig()
+	heimdall := cfg.Services["heimdall"]
+	heimdall.Secrets.Exclusions = []string{"password"}
+	cfg.Services["heimdall"] = heimdall
+	extractor, err := New(cfg)
+	require.NoError(t, err)
+
+	result, err := extractor.ExtractSecrets([]byte(reviewValues), "heimdall")
+	require.NoError(t, err)
+	require.Len(t, result.Secrets, 1)
+	assert.Equal(t, "3f4beddd-2061-49b0-ae80-6f1f2ed65b37", result.Secrets[0].Key)
+
+	result, err = extractor.ExtractSecrets([]byte(reviewValues), "other")
+	require.NoError(t, err)
+	assert.Len(t, result.Secrets, 2, "exclusions only apply to their service")
+}
+
+func TestSeparator_LeavesCandidatesInPlace(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Globals.Secrets.MinConfidence = "high"
+	extractor, err := New(cfg)
+	require.NoError(t, err)
+
+	_, result, err := NewSeparator(extractor).SeparateSecrets([]byte(reviewValues), "heimdall")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, result.MovedCount)
+	require.Len(t, result.Candidates, 1)
+	assert.Equal(t, "configMap.db.password", result.Candidates[0].Path)
+
+	data := result.ModifiedData.(map[string]interface{})
+	configMap := data["configMap"].(map[string]interface{})
+	assert.Contains(t, configMap, "db")
+	assert.NotContains(t, configMap, "3f4beddd-2061-49b0-ae80-6f1f2ed65b37")
+}
+
+func TestExtractor_ReviewCandidates(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.Globals.Secrets.MinConfidence = "high"
+	extractor, err := New(cfg)
+	require.NoError(t, err)
+
+	candidates, err := extractor.ReviewCandidates([]byte(reviewValues), "heimdall", "apps/heimdall/values.yaml")
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, ReviewCandidate{
+		Service:        "heimdall",
+		File:           "apps/heimdall/values.yaml",
+		Path:           "configMap.db.password",
+		Key:            "password",
+		MaskedValue:    "hunt******ter2",
+		Classification: ClassificationPassword,
+		Confidence:     ConfidenceMedium,
+		Decision:       DecisionPending,
+	}, candidates[0])
+}
+
+func TestReview(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets-review.yaml")
+
+	review, err := LoadReview(path)
+	require.NoError(t, err)
+	assert.Empty(t, review.Candidates)
+
+	assert.True(t, review.Add(ReviewCandidate{Service: "heimdall", Key: "password", Path: "configMap.db.password"}))
+	assert.False(t, review.Add(ReviewCandidate{Service: "heimdall", Key: "password", Path: "configMap.other.password"}),
+		"decisions are per key, so a key is listed once")
+	assert.True(t, review.Add(ReviewCandidate{Service: "heimdall", Key: "tenant"}))
+	assert.True(t, review.Add(ReviewCandidate{Service: "auth", Key: "password"}))
+	require.NoError(t, review.Save(path))
+
+	// Decide by editing the file, as a reviewer would
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	edited := strings.Replace(string(data), "decision: pending", "decision: accept", 1)
+	edited = strings.Replace(edited, "decision: pending", "decision: reject", 1)
+	require.NoError(t, os.WriteFile(path, []byte(edited), 0644))
+
+	review, err = LoadReview(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]ServiceDecisions{
+		"heimdall": {Accepted: []string{"password"}, Rejected: []string{"tenant"}},
+	}, review.Decisions())
+	require.Len(t, review.Pending(), 1)
+	assert.Equal(t, "auth", review.Pending()[0].Service)
+
+	review.RemoveDecided()
+	require.Len(t, review.Candidates, 1)
+
+	review.Candidates = nil
+	require.NoError(t, review.Save(path))
+	assert.NoFileExists(t, path)
+
+	require.NoError(t, os.WriteFile(path, []byte("candidates:\n  - service: a\n    key: b\n    decision: maybe\n"), 0644))
+	_, err = LoadReview(path)
+	assert.ErrorContains(t, err, `invalid decision "maybe"`)
+}
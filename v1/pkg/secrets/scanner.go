@@ -0,0 +1,209 @@
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/sops"
+)
+
+// ScanIgnoreMarker on a line excludes it from scanning, for known false positives
+const ScanIgnoreMarker = "secrets-scan:ignore"
+
+// Finding is a plaintext secret detected in a file
+type Finding struct {
+	File           string          `yaml:"file"`
+	Line           int             `yaml:"line"`
+	Path           string          `yaml:"path"`
+	Key            string          `yaml:"key"`
+	MaskedValue    string          `yaml:"masked_value"`
+	Classification Classification  `yaml:"classification"`
+	Confidence     ConfidenceLevel `yaml:"confidence"`
+}
+
+// String formats the finding as file:line: message
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: possible %s secret at %s = %s (%s confidence)",
+		filepath.ToSlash(f.File), f.Line, f.Classification, f.Path, f.MaskedValue, f.Confidence)
+}
+
+// Scanner looks for plaintext secrets in generated files using the
+// extractor's key, UUID and value patterns
+type Scanner struct {
+	extractor     *SecretExtractor
+	minConfidence ConfidenceLevel
+}
+
+// NewScanner creates a Scanner reporting findings of at least minConfidence
+func NewScanner(extractor *SecretExtractor, minConfidence ConfidenceLevel) *Scanner {
+	if minConfidence == "" {
+		minConfidence = ConfidenceLow
+	}
+	return &Scanner{
+		extractor:     extractor,
+		minConfidence: minConfidence,
+	}
+}
+
+// ParseConfidence converts low, medium or high into a ConfidenceLevel
+func ParseConfidence(value string) (ConfidenceLevel, error) {
+	level := ConfidenceLevel(strings.ToLower(value))
+	if confidenceRank(level) == 0 {
+		return "", fmt.Errorf("invalid confidence %q, expected low, medium or high", value)
+	}
+	return level, nil
+}
+
+func confidenceRank(level ConfidenceLevel) int {
+	switch level {
+	case ConfidenceLow:
+		return 1
+	case ConfidenceMedium:
+		return 2
+	case ConfidenceHigh:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// ScanDir scans every plaintext file under root. Files are skipped by their
+// content, not their name: a .enc. file that was never encrypted is scanned,
+// and so is a .dec. plaintext copy, which must not be committed.
+func (s *Scanner) ScanDir(root string) ([]Finding, error) {
+	var findings []Finding
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if bytes.IndexByte(data, 0) >= 0 || sops.IsEncrypted(data, path) {
+			return nil
+		}
+
+		findings = append(findings, s.ScanFile(path, data)...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, nil
+}
+
+var (
+	// scanKeyValueRegex matches "key: value" and "key = value" lines, including list items
+	scanKeyValueRegex = regexp.MustCompile(`^(\s*)(?:-\s+)?("[^"]+"|'[^']+'|[\w.\-/]+)\s*(?::\s|:$|=)\s*(.*)$`)
+	// scanIgnoredValueRegex matches values that cannot hold a literal secret
+	scanIgnoredValueRegex = regexp.MustCompile(`^(?:[|>][+-]?\d*|\{\}|\[\]|~|null|true|false)$`)
+)
+
+// ScanFile scans the lines of a single file. It works line by line, tracking
+// indentation to build dotted key paths, so that templates and documents that
+// are not valid YAML are scanned too and every finding has its line number.
+func (s *Scanner) ScanFile(path string, data []byte) []Finding {
+	serviceName := serviceFromPath(path)
+
+	type parent struct {
+		indent int
+		key    string
+	}
+	var (
+		findings []Finding
+		parents  []parent
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") ||
+			strings.Contains(line, ScanIgnoreMarker) {
+			continue
+		}
+
+		match := scanKeyValueRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		indent := len(match[1])
+		key := strings.Trim(match[2], `"'`)
+		value := cleanScannedValue(match[3])
+
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			parents = parents[:len(parents)-1]
+		}
+		keys := make([]string, 0, len(parents)+1)
+		for _, p := range parents {
+			keys = append(keys, p.key)
+		}
+		keyPath := strings.Join(append(keys, key), ".")
+
+		if value == "" || scanIgnoredValueRegex.MatchString(value) {
+			parents = append(parents, parent{indent: indent, key: key})
+			continue
+		}
+		if strings.Contains(value, "{{") || strings.HasPrefix(value, "ENC[") || strings.HasPrefix(value, "${") {
+			continue
+		}
+
+		secretMatch, found := s.extractor.matchKeyValue(key, value, keyPath, serviceName)
+		if !found || confidenceRank(secretMatch.Confidence) < confidenceRank(s.minConfidence) {
+			continue
+		}
+		findings = append(findings, Finding{
+			File:           path,
+			Line:           lineNumber,
+			Path:           keyPath,
+			Key:            key,
+			MaskedValue:    secretMatch.MaskedValue,
+			Classification: secretMatch.Classification,
+			Confidence:     secretMatch.Confidence,
+		})
+	}
+	return findings
+}
+
+// cleanScannedValue strips trailing comments, separators and quotes from a value
+func cleanScannedValue(value string) string {
+	value = strings.TrimSpace(value)
+	if value != "" && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[1 : end+1]
+		}
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return strings.Trim(strings.TrimSuffix(value, ","), `"'`)
+}
+
+// serviceFromPath returns the service of a file under apps/<service>/, if any
+func serviceFromPath(path string) string {
+	appPath, err := config.ParseAppPath(path)
+	if err != nil {
+		return ""
+	}
+	return appPath.Service
+}
@@ -0,0 +1,114 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestScanner(t *testing.T, minConfidence ConfidenceLevel) *Scanner {
+	extractor, err := New(createTestConfig())
+	require.NoError(t, err)
+	return NewScanner(extractor, minConfidence)
+}
+
+// encryptedSecretsFixture carries SOPS metadata without needing real keys
+const encryptedSecretsFixture = `secrets:
+    password: ENC[AES256_GCM,data:c2VjcmV0,iv:aXZpdml2aXZpdml2,tag:dGFndGFndGFndGFn,type:str]
+sops:
+    kms:
+        - arn: arn:aws:kms:us-east-1:000000000000:key/test
+          created_at: "2025-01-01T00:00:00Z"
+          enc: ZW5jcnlwdGVkLWRhdGEta2V5
+          aws_profile: ""
+    lastmodified: "2025-01-01T00:00:00Z"
+    mac: ENC[AES256_GCM,data:bWFj,iv:aXZpdml2aXZpdml2,tag:dGFndGFndGFndGFn,type:str]
+    encrypted_regex: ^(secrets)$
+    version: 3.10.2
+`
+
+func TestScanner_ScanFile(t *testing.T) {
+	scanner := newTestScanner(t, ConfidenceLow)
+
+	data := []byte(`configMap:
+  database:
+    host: db.example.com
+    password: "sup3r-s3cret-pass"   # leaked
+  api_key: {{ .Values.apiKey }}
+  empty: ""
+secrets: {}
+application.properties: |
+  db.password=hunter2hunter2
+legit:
+  # password: commented-out
+  token: allowed-token-value # secrets-scan:ignore
+`)
+
+	findings := scanner.ScanFile("apps/heimdall/values.yaml", data)
+	require.Len(t, findings, 2)
+
+	assert.Equal(t, 4, findings[0].Line)
+	assert.Equal(t, "configMap.database.password", findings[0].Path)
+	assert.Equal(t, "password", findings[0].Key)
+	assert.Equal(t, "sup3*********pass", findings[0].MaskedValue)
+	assert.Equal(t, ClassificationPassword, findings[0].Classification)
+
+	assert.Equal(t, 9, findings[1].Line)
+	assert.Equal(t, "application.properties.db.password", findings[1].Path)
+	assert.Equal(t, "apps/heimdall/values.yaml:9: possible password secret at application.properties.db.password = hunt******ter2 (medium confidence)",
+		findings[1].String())
+}
+
+func TestScanner_MinConfidence(t *testing.T) {
+	data := []byte("app:\n  id: 0a5dd6a1-0b59-4a34-9c4c-1f9e9a1b2c3d\n")
+
+	assert.NotEmpty(t, newTestScanner(t, ConfidenceMedium).ScanFile("values.yaml", data))
+	assert.Empty(t, newTestScanner(t, ConfidenceHigh).ScanFile("values.yaml", data))
+}
+
+func TestScanner_ScanDir(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "apps")
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	write("heimdall/values.yaml", "image:\n  tag: 1.0.0\n")
+	write("heimdall/envs/dev01/values.yaml", "config:\n  password: plain-text-password\n")
+	write("heimdall/envs/dev01/secrets.dec.yaml", "secrets:\n  password: plain-text-password\n")
+	write("heimdall/envs/dev02/secrets.enc.yaml", "secrets:\n  password: never-encrypted-password\n")
+	write("heimdall/README.md", "Set `token: example-token-value` in your values\n")
+
+	findings, err := newTestScanner(t, ConfidenceLow).ScanDir(root)
+	require.NoError(t, err)
+
+	// .dec. copies and unencrypted .enc. files are scanned like any other file
+	require.Len(t, findings, 3)
+	assert.Equal(t, filepath.Join(root, "heimdall/envs/dev01/secrets.dec.yaml"), findings[0].File)
+	assert.Equal(t, filepath.Join(root, "heimdall/envs/dev01/values.yaml"), findings[1].File)
+	assert.Equal(t, 2, findings[1].Line)
+	assert.Equal(t, filepath.Join(root, "heimdall/envs/dev02/secrets.enc.yaml"), findings[2].File)
+}
+
+func TestScanner_ScanDirSkipsEncryptedFiles(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "secrets.enc.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(encryptedSecretsFixture), 0644))
+
+	findings, err := newTestScanner(t, ConfidenceLow).ScanDir(root)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestParseConfidence(t *testing.T) {
+	level, err := ParseConfidence("HIGH")
+	require.NoError(t, err)
+	assert.Equal(t, ConfidenceHigh, level)
+
+	_, err = ParseConfidence("certain")
+	assert.Error(t, err)
+}
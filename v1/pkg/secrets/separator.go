@@ -22,6 +22,10 @@ type SeparationResult struct {
 	ExtractedSecrets []ExtractedSecret `yaml:"extracted_secrets"`
 	MovedCount       int               `yaml:"moved_count"`
 	Warnings         []string          `yaml:"warnings"`
+	// StorePath is the top-level key the secrets were moved under
+	StorePath string `yaml:"store_path"`
+	// Candidates are matches below the confidence threshold, left in place for review
+	Candidates []SecretMatch `yaml:"candidates,omitempty"`
 }
 
 // ExtractedSecret represents a secret that was extracted and moved
@@ -71,6 +75,7 @@ func (s *Separator) SeparateSecrets(yamlData []byte, serviceName string) ([]byte
 
 	// Get the store path from config
 	storePath := s.getStorePath()
+	result.StorePath = storePath
 
 	// Build secrets map, starting with existing secrets if any
 	secrets := make(map[string]interface{})
@@ -85,6 +90,12 @@ func (s *Separator) SeparateSecrets(yamlData []byte, serviceName string) ([]byte
 
 	// Process each detected secret
 	for _, secret := range extraction.Secrets {
+		// Leave low-confidence matches in place until they are reviewed
+		if s.extractor.NeedsReview(secret, serviceName) {
+			result.Candidates = append(result.Candidates, secret)
+			continue
+		}
+
 		// Extract and move the secret
 		if moved := s.moveSecretToMap(data, secrets, secret, result); moved {
 			result.MovedCount++
@@ -273,7 +284,7 @@ func (s *Separator) getStorePath() string {
 	}
 
 	// Fall back to global configuration
-	if s.config != nil && s.config.Globals.Secrets.Locations != nil && s.config.Globals.Secrets.Locations.StorePath != "" {
+	if s.config != nil && s.config.Globals.Secrets != nil && s.config.Globals.Secrets.Locations != nil && s.config.Globals.Secrets.Locations.StorePath != "" {
 		return s.config.Globals.Secrets.Locations.StorePath
 	}
 
@@ -293,7 +304,7 @@ func (s *Separator) getBasePath() string {
 	}
 
 	// Fall back to global configuration
-	if s.config != nil && s.config.Globals.Secrets.Locations != nil && s.config.Globals.Secrets.Locations.BasePath != "" {
+	if s.config != nil && s.config.Globals.Secrets != nil && s.config.Globals.Secrets.Locations != nil && s.config.Globals.Secrets.Locations.BasePath != "" {
 		return s.config.Globals.Secrets.Locations.BasePath
 	}
 
@@ -364,22 +375,5 @@ func (s *Separator) getCustomMapping(originalPath string) string {
 		}
 	}
 
-	// Fall back to deprecated direct key mappings for backward compatibility
-	// Check for service-specific key mappings (deprecated)
-	if s.serviceName != "" && s.config != nil && s.config.Services != nil {
-		if svc, exists := s.config.Services[s.serviceName]; exists && svc.Secrets != nil && svc.Secrets.KeyMappings != nil {
-			if mappedPath, found := svc.Secrets.KeyMappings[originalPath]; found {
-				return mappedPath
-			}
-		}
-	}
-
-	// Check global key mappings (deprecated)
-	if s.config != nil && s.config.Globals.Secrets.KeyMappings != nil {
-		if mappedPath, found := s.config.Globals.Secrets.KeyMappings[originalPath]; found {
-			return mappedPath
-		}
-	}
-
 	return ""
 }
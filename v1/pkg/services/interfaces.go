@@ -6,6 +6,8 @@ import (
 	yaml "github.com/elioetibr/golang-yaml-advanced"
 	"helm.sh/helm/v3/pkg/release"
 	"k8s.io/client-go/kubernetes"
+
+	"helm-charts-migrator/v1/pkg/keycase"
 )
 
 // KubernetesService handles all Kubernetes cluster operations
@@ -73,11 +75,8 @@ type TransformationService interface {
 	// NormalizeKeys normalizes keys in a values map
 	NormalizeKeys(values map[string]interface{}) map[string]interface{}
 	
-	// ExtractSecrets extracts secrets from values
-	ExtractSecrets(values map[string]interface{}) (secrets, cleaned map[string]interface{})
-	
-	// ConvertKeys converts keys based on configured rules (e.g., camelCase)
-	ConvertKeys(values map[string]interface{}) map[string]interface{}
+	// ConvertKeys converts keys based on the service's converter configuration (e.g., camelCase)
+	ConvertKeys(serviceName string, values map[string]interface{}) (map[string]interface{}, error)
 	
 	// MergeValues merges multiple value sources
 	MergeValues(base, override map[string]interface{}) map[string]interface{}
@@ -103,10 +102,10 @@ type CacheService interface {
 
 // SOPSService handles SOPS encryption/decryption
 type SOPSService interface {
-	// Encrypt encrypts a file using SOPS
+	// Encrypt encrypts a .dec. file into its .enc. counterpart
 	Encrypt(filePath string) error
 	
-	// Decrypt decrypts a file using SOPS
+	// Decrypt returns the plaintext of a SOPS encrypted file
 	Decrypt(filePath string) ([]byte, error)
 	
 	// EncryptBatch encrypts multiple files in parallel
@@ -114,6 +113,13 @@ type SOPSService interface {
 	
 	// IsEncrypted checks if a file is SOPS encrypted
 	IsEncrypted(filePath string) bool
+	
+	// Validate checks that an encrypted file carries complete SOPS metadata
+	Validate(filePath string) error
+	
+	// Rotate re-wraps the data key of an encrypted file for its current keys,
+	// reporting whether the file changed
+	Rotate(filePath string) (bool, error)
 }
 
 // TransformConfig holds transformation configuration
@@ -160,6 +166,9 @@ type ReportService interface {
 	// RecordExtraction records an extraction operation
 	RecordExtraction(file string, extraction Extraction)
 	
+	// RecordKeyRenames records the keys renamed and collisions resolved converting a file
+	RecordKeyRenames(file string, renames *keycase.Result)
+	
 	// GenerateReport generates the final transformation report
 	GenerateReport() (*TransformationReport, error)
 	
@@ -194,6 +203,8 @@ type TransformationReport struct {
 	EndTime          string
 	Transformations  []Transformation
 	Extractions      []Extraction
+	KeyRenames       keycase.RenameMap
+	KeyCollisions    []keycase.Collision
 	Summary          ReportSummary
 }
 
@@ -205,5 +216,8 @@ type ReportSummary struct {
 	TotalExtractions     int
 	SuccessfulExtracts   int
 	FailedExtracts       int
+	RenamedKeys          int
+	KeyCollisions        int
+	ErrorsByKind         map[string]int
 	Duration             string
 }
\ No newline at end of file
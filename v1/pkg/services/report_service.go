@@ -5,10 +5,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/errkind"
+	"helm-charts-migrator/v1/pkg/keycase"
 	"helm-charts-migrator/v1/pkg/logger"
 	yaml "github.com/elioetibr/golang-yaml-advanced"
 )
@@ -21,6 +25,7 @@ type reportService struct {
 	startTime       time.Time
 	transformations []Transformation
 	extractions     []Extraction
+	renames         *keycase.Result
 	mu              sync.Mutex
 }
 
@@ -31,6 +36,7 @@ func NewReportService(cfg *config.Config) ReportService {
 		log:             logger.WithName("report-service"),
 		transformations: []Transformation{},
 		extractions:     []Extraction{},
+		renames:         keycase.NewResult(),
 	}
 }
 
@@ -43,6 +49,7 @@ func (r *reportService) StartReport(serviceName string) {
 	r.startTime = time.Now()
 	r.transformations = []Transformation{}
 	r.extractions = []Extraction{}
+	r.renames = keycase.NewResult()
 
 	r.log.InfoS("Started report", "service", serviceName)
 }
@@ -87,6 +94,19 @@ func (r *reportService) RecordExtraction(file string, extraction Extraction) {
 	}
 }
 
+// RecordKeyRenames records the keys renamed and collisions resolved converting a file
+func (r *reportService) RecordKeyRenames(file string, renames *keycase.Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.renames.Merge(renames)
+
+	r.log.V(3).InfoS("Key renames recorded",
+		"file", file,
+		"renamed", len(renames.Renames),
+		"collisions", len(renames.Collisions))
+}
+
 // GenerateReport generates the final transformation report
 func (r *reportService) GenerateReport() (*TransformationReport, error) {
 	r.mu.Lock()
@@ -104,6 +124,8 @@ func (r *reportService) GenerateReport() (*TransformationReport, error) {
 		EndTime:         endTime.Format(time.RFC3339),
 		Transformations: r.transformations,
 		Extractions:     r.extractions,
+		KeyRenames:      r.renames.Renames,
+		KeyCollisions:   r.renames.Collisions,
 		Summary:         summary,
 	}
 
@@ -168,6 +190,9 @@ func (r *reportService) calculateSummary(duration time.Duration) ReportSummary {
 	summary := ReportSummary{
 		TotalTransformations: len(r.transformations),
 		TotalExtractions:     len(r.extractions),
+		RenamedKeys:          len(r.renames.Renames),
+		KeyCollisions:        len(r.renames.Collisions),
+		ErrorsByKind:         make(map[string]int),
 		Duration:             duration.String(),
 	}
 
@@ -177,6 +202,7 @@ func (r *reportService) calculateSummary(duration time.Duration) ReportSummary {
 			summary.SuccessfulTransforms++
 		} else if t.Error != nil {
 			summary.FailedTransforms++
+			summary.ErrorsByKind[errkind.Classify(t.Error).String()]++
 		}
 	}
 
@@ -186,6 +212,9 @@ func (r *reportService) calculateSummary(duration time.Duration) ReportSummary {
 			summary.SuccessfulExtracts++
 		} else {
 			summary.FailedExtracts++
+			if e.Error != nil {
+				summary.ErrorsByKind[errkind.Classify(e.Error).String()]++
+			}
 		}
 	}
 
@@ -222,6 +251,15 @@ Total Extractions: %d (Success: %d, Failed: %d)
 		report.Summary.SuccessfulExtracts,
 		report.Summary.FailedExtracts)
 
+	if len(report.Summary.ErrorsByKind) > 0 {
+		output += "ERRORS BY KIND\n"
+		output += "--------------\n"
+		for _, kind := range sortedKinds(report.Summary.ErrorsByKind) {
+			output += fmt.Sprintf("%s: %d\n", kind, report.Summary.ErrorsByKind[kind])
+		}
+		output += "\n"
+	}
+
 	// Add transformations section
 	if len(report.Transformations) > 0 {
 		output += "TRANSFORMATIONS\n"
@@ -235,7 +273,7 @@ Total Extractions: %d (Success: %d, Failed: %d)
 			}
 			output += fmt.Sprintf("%d. [%s] %s - %s\n", i+1, status, t.Type, t.Description)
 			if t.Error != nil {
-				output += fmt.Sprintf("   Error: %v\n", t.Error)
+				output += fmt.Sprintf("   Error [%s]: %v\n", errkind.Classify(t.Error), t.Error)
 			}
 		}
 		output += "\n"
@@ -255,12 +293,26 @@ Total Extractions: %d (Success: %d, Failed: %d)
 			output += fmt.Sprintf("   Destination: %s\n", e.Destination)
 			output += fmt.Sprintf("   Items: %d\n", e.ItemsCount)
 			if e.Error != nil {
-				output += fmt.Sprintf("   Error: %v\n", e.Error)
+				output += fmt.Sprintf("   Error [%s]: %v\n", errkind.Classify(e.Error), e.Error)
 			}
 		}
 		output += "\n"
 	}
 
+	// Add key renames section
+	if len(report.KeyRenames) > 0 || len(report.KeyCollisions) > 0 {
+		output += "KEY RENAMES\n"
+		output += "-----------\n"
+		output += fmt.Sprintf("Renamed: %d, Collisions: %d\n", report.Summary.RenamedKeys, report.Summary.KeyCollisions)
+		for _, c := range report.KeyCollisions {
+			output += fmt.Sprintf("! %s: %s\n", c, describeCollision(c))
+		}
+		for _, oldPath := range sortedRenames(report.KeyRenames) {
+			output += fmt.Sprintf("%s -> %s\n", oldPath, report.KeyRenames[oldPath])
+		}
+		output += "\n"
+	}
+
 	output += "================================================================================\n"
 	return output
 }
@@ -296,6 +348,16 @@ func (r *reportService) formatMarkdownReport(report *TransformationReport) strin
 		report.Summary.SuccessfulExtracts,
 		report.Summary.FailedExtracts)
 
+	if len(report.Summary.ErrorsByKind) > 0 {
+		output += "### Errors by Kind\n\n"
+		output += "| Kind | Count |\n"
+		output += "|------|-------|\n"
+		for _, kind := range sortedKinds(report.Summary.ErrorsByKind) {
+			output += fmt.Sprintf("| %s | %d |\n", kind, report.Summary.ErrorsByKind[kind])
+		}
+		output += "\n"
+	}
+
 	// Add transformations section
 	if len(report.Transformations) > 0 {
 		output += "## Transformations\n\n"
@@ -308,7 +370,7 @@ func (r *reportService) formatMarkdownReport(report *TransformationReport) strin
 			}
 			output += fmt.Sprintf("%d. %s **%s** - %s\n", i+1, status, t.Type, t.Description)
 			if t.Error != nil {
-				output += fmt.Sprintf("   - Error: `%v`\n", t.Error)
+				output += fmt.Sprintf("   - Error (%s): `%v`\n", errkind.Classify(t.Error), t.Error)
 			}
 			if t.Before != nil && t.After != nil {
 				output += "   - Changes applied\n"
@@ -330,12 +392,30 @@ func (r *reportService) formatMarkdownReport(report *TransformationReport) strin
 			output += fmt.Sprintf("   - Destination: `%s`\n", e.Destination)
 			output += fmt.Sprintf("   - Items extracted: %d\n", e.ItemsCount)
 			if e.Error != nil {
-				output += fmt.Sprintf("   - Error: `%v`\n", e.Error)
+				output += fmt.Sprintf("   - Error (%s): `%v`\n", errkind.Classify(e.Error), e.Error)
 			}
 		}
 		output += "\n"
 	}
 
+	// Add key renames section
+	if len(report.KeyRenames) > 0 || len(report.KeyCollisions) > 0 {
+		output += "## Key Renames\n\n"
+		if len(report.KeyCollisions) > 0 {
+			output += "### Collisions\n\n"
+			for _, c := range report.KeyCollisions {
+				output += fmt.Sprintf("- `%s` from `%s`: %s\n", c.Path, strings.Join(c.Keys, "`, `"), describeCollision(c))
+			}
+			output += "\n"
+		}
+		output += "| Old Path | New Path |\n"
+		output += "|----------|----------|\n"
+		for _, oldPath := range sortedRenames(report.KeyRenames) {
+			output += fmt.Sprintf("| `%s` | `%s` |\n", oldPath, report.KeyRenames[oldPath])
+		}
+		output += "\n"
+	}
+
 	// Add legend
 	output += `## Legend
 
@@ -347,6 +427,34 @@ func (r *reportService) formatMarkdownReport(report *TransformationReport) strin
 	return output
 }
 
+// sortedKinds returns the error kinds of a summary in stable order
+func sortedKinds(counts map[string]int) []string {
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// describeCollision describes how a key collision was resolved
+func describeCollision(c keycase.Collision) string {
+	if c.Merged {
+		return fmt.Sprintf("merged beneath %s", c.Kept)
+	}
+	return fmt.Sprintf("kept %s (%s)", c.Kept, c.Policy)
+}
+
+// sortedRenames returns the old paths of a rename map in stable order
+func sortedRenames(renames keycase.RenameMap) []string {
+	paths := make([]string, 0, len(renames))
+	for oldPath := range renames {
+		paths = append(paths, oldPath)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
 // Helper method to create a transformation record
 func CreateTransformation(transformType, description string, before, after interface{}, applied bool, err error) Transformation {
 	return Transformation{
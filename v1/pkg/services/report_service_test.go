@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/keycase"
 	yaml "github.com/elioetibr/golang-yaml-advanced"
 )
 
@@ -352,3 +353,43 @@ func TestReportService_Duration(t *testing.T) {
 
 	assert.True(t, endTime.After(startTime))
 }
+
+func TestReportService_KeyRenames(t *testing.T) {
+	svc := NewReportService(&config.Config{})
+	svc.StartReport("test-service")
+
+	collision := keycase.Collision{
+		Path:   "dbHost",
+		Keys:   []string{"db_host", "dbHost"},
+		Kept:   "dbHost",
+		Policy: keycase.CollisionKeepCamel,
+	}
+	svc.RecordKeyRenames("dev/legacy-values.yaml", &keycase.Result{
+		Renames:    keycase.RenameMap{"image_tag": "imageTag"},
+		Collisions: []keycase.Collision{collision},
+	})
+	svc.RecordKeyRenames("prod/legacy-values.yaml", &keycase.Result{
+		Renames:    keycase.RenameMap{"image_tag": "imageTag", "pull_policy": "pullPolicy"},
+		Collisions: []keycase.Collision{collision},
+	})
+
+	report, err := svc.GenerateReport()
+	require.NoError(t, err)
+	assert.Equal(t, keycase.RenameMap{"image_tag": "imageTag", "pull_policy": "pullPolicy"}, report.KeyRenames)
+	assert.Equal(t, 2, report.Summary.RenamedKeys)
+	assert.Equal(t, 1, report.Summary.KeyCollisions)
+
+	tmpDir := t.TempDir()
+	require.NoError(t, svc.SaveReport(filepath.Join(tmpDir, "report.txt")))
+	text, err := os.ReadFile(filepath.Join(tmpDir, "report.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(text), "KEY RENAMES")
+	assert.Contains(t, string(text), "! dbHost (from db_host, dbHost): kept dbHost (keep-camel)")
+	assert.Contains(t, string(text), "image_tag -> imageTag")
+
+	require.NoError(t, svc.SaveReport(filepath.Join(tmpDir, "report.md")))
+	markdown, err := os.ReadFile(filepath.Join(tmpDir, "report.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(markdown), "## Key Renames")
+	assert.Contains(t, string(markdown), "| `pull_policy` | `pullPolicy` |")
+}
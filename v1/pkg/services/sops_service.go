@@ -1,10 +1,10 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -13,151 +13,182 @@ import (
 
 	"helm-charts-migrator/v1/pkg/config"
 	"helm-charts-migrator/v1/pkg/logger"
+	"helm-charts-migrator/v1/pkg/sops"
 )
 
-// sopsService implements SOPSService interface
+const (
+	defaultSOPSWorkers = 5
+	defaultSOPSTimeout = 30
+	defaultSOPSConfig  = ".sops.yaml"
+)
+
+// sopsService implements SOPSService interface in-process with the SOPS library
 type sopsService struct {
-	config *config.SOPSConfig
-	log    *logger.NamedLogger
+	config    *config.SOPSConfig
+	manager   *sops.Manager
+	matcher   *sops.PathMatcher
+	configErr error
+	envOnce   sync.Once
+	log       *logger.NamedLogger
 }
 
 // NewSOPSService creates a new SOPSService
 func NewSOPSService(cfg *config.SOPSConfig) SOPSService {
 	if cfg == nil {
 		cfg = &config.SOPSConfig{
-			Enabled:         true,
-			ParallelWorkers: 5,
-			Timeout:         30,
+			Enabled: true,
+		}
+	}
+
+	// Work on a copy so defaults never leak back into the loaded config
+	effective := *cfg
+	if effective.ParallelWorkers <= 0 {
+		effective.ParallelWorkers = defaultSOPSWorkers
+	}
+	if effective.Timeout <= 0 {
+		effective.Timeout = defaultSOPSTimeout
+	}
+	if effective.ConfigFile == "" {
+		effective.ConfigFile = defaultSOPSConfig
+	}
+
+	log := logger.WithName("sops-service")
+	manager := sops.NewManager(log, effective.ConfigFile)
+
+	// Rules given in the migrator config take precedence over the SOPS config file
+	var matcher *sops.PathMatcher
+	err := manager.SetCreationRules(effective.CreationRules)
+	if err == nil {
+		if effective.PathRegex == "" && len(effective.CreationRules) > 0 {
+			matcher, err = sops.NewPathMatcherForRules(effective.CreationRules)
+		} else {
+			matcher, err = sops.NewPathMatcher(effective.PathRegex, effective.ConfigFile)
 		}
 	}
-	
+	if err == nil {
+		log.V(3).InfoS("SOPS path rule", "regex", matcher.String(), "creationRules", len(effective.CreationRules))
+	}
+
 	return &sopsService{
-		config: cfg,
-		log:    logger.WithName("sops-service"),
+		config:    &effective,
+		manager:   manager,
+		matcher:   matcher,
+		configErr: err,
+		log:       log,
 	}
 }
 
-// Encrypt encrypts a file using SOPS
+// Encrypt encrypts a .dec. file into its .enc. counterpart
 func (s *sopsService) Encrypt(filePath string) error {
 	if !s.config.Enabled {
 		s.log.V(2).InfoS("SOPS encryption disabled", "file", filePath)
 		return nil
 	}
-	
+	if s.configErr != nil {
+		return s.configErr
+	}
+
 	// Check if file should be encrypted based on naming convention
-	if !s.shouldEncrypt(filePath) {
-		s.log.V(3).InfoS("Skipping file, doesn't match encryption pattern", "file", filePath)
+	if !s.matcher.Matches(filePath) {
+		s.log.V(3).InfoS("Skipping file, doesn't match encryption pattern", "file", filePath, "regex", s.matcher.String())
 		return nil
 	}
-	
-	// Check if file is already encrypted
+
+	// A .dec. file that is already encrypted must not be encrypted twice
 	if s.IsEncrypted(filePath) {
 		s.log.V(2).InfoS("File already encrypted", "file", filePath)
 		return nil
 	}
-	
-	// Prepare SOPS command
-	args := []string{"-e", "-i", filePath}
-	
-	// Add config file if specified
-	if s.config.ConfigFile != "" {
-		args = append(args, "--config", s.config.ConfigFile)
-	}
-	
-	// Build command
-	cmd := exec.Command("sops", args...)
-	
-	// Set AWS profile if configured
-	if s.config.AwsProfile != "" {
-		cmd.Env = append(os.Environ(), fmt.Sprintf("AWS_PROFILE=%s", s.config.AwsProfile))
-	}
-	
-	// Set timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.Timeout)*time.Second)
-	defer cancel()
-	
-	// Execute with context
-	output, err := exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...).CombinedOutput()
+
+	encPath := sops.EncryptedPath(filePath)
+	if s.config.SkipUnchanged && isUpToDate(filePath, encPath) {
+		s.log.V(2).InfoS("Skipping unchanged file", "file", filePath, "encrypted", encPath)
+		return nil
+	}
+
+	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt %s: %w\nOutput: %s", filePath, err, output)
+		return fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+
+	s.applyKeyEnvironment()
+	var encrypted []byte
+	err = s.withTimeout("encrypt", filePath, func() error {
+		var encryptErr error
+		encrypted, encryptErr = s.manager.EncryptData(data, filePath, encPath)
+		return encryptErr
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encrypt %s: %w", filePath, err)
+	}
+
+	if existing, err := os.ReadFile(encPath); err == nil && bytes.Equal(existing, encrypted) {
+		s.log.V(2).InfoS("Encrypted file unchanged", "file", filePath, "encrypted", encPath)
+		return nil
+	}
+	if err := writeFileAtomic(encPath, encrypted); err != nil {
+		return fmt.Errorf("failed to write encrypted file %s: %w", encPath, err)
 	}
-	
-	s.log.V(2).InfoS("File encrypted successfully", "file", filePath)
+
+	s.log.V(2).InfoS("File encrypted successfully", "file", filePath, "encrypted", encPath)
 	return nil
 }
 
-// Decrypt decrypts a file using SOPS
+// Decrypt returns the plaintext of a file, reading it as-is when it is not encrypted
 func (s *sopsService) Decrypt(filePath string) ([]byte, error) {
-	if !s.config.Enabled {
-		// If SOPS is disabled, just read the file
-		return os.ReadFile(filePath)
-	}
-	
-	// Check if file is encrypted
-	if !s.IsEncrypted(filePath) {
-		// File is not encrypted, just read it
-		return os.ReadFile(filePath)
-	}
-	
-	// Prepare SOPS command
-	args := []string{"-d", filePath}
-	
-	// Add config file if specified
-	if s.config.ConfigFile != "" {
-		args = append(args, "--config", s.config.ConfigFile)
-	}
-	
-	// Build command
-	cmd := exec.Command("sops", args...)
-	
-	// Set AWS profile if configured
-	if s.config.AwsProfile != "" {
-		cmd.Env = append(os.Environ(), fmt.Sprintf("AWS_PROFILE=%s", s.config.AwsProfile))
-	}
-	
-	// Set timeout
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.Timeout)*time.Second)
-	defer cancel()
-	
-	// Execute with context
-	output, err := exec.CommandContext(ctx, cmd.Path, cmd.Args[1:]...).Output()
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+
+	if !s.config.Enabled || !sops.IsEncrypted(data, filePath) {
+		return data, nil
+	}
+
+	s.applyKeyEnvironment()
+	var plaintext []byte
+	err = s.withTimeout("decrypt", filePath, func() error {
+		var decryptErr error
+		plaintext, decryptErr = s.manager.DecryptData(data, filePath)
+		return decryptErr
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt %s: %w", filePath, err)
 	}
-	
-	return output, nil
+
+	return plaintext, nil
 }
 
-// EncryptBatch encrypts multiple files in parallel
+// EncryptBatch encrypts multiple files in parallel; workers <= 0 uses the configured ParallelWorkers
 func (s *sopsService) EncryptBatch(filePaths []string, workers int) error {
 	if !s.config.Enabled {
 		s.log.InfoS("SOPS encryption disabled, skipping batch encryption")
 		return nil
 	}
-	
-	if workers <= 0 {
-		workers = s.config.ParallelWorkers
+	if s.configErr != nil {
+		return s.configErr
 	}
+
 	if workers <= 0 {
-		workers = 5 // Default
+		workers = s.config.ParallelWorkers
 	}
-	
+
 	// Filter files that need encryption
 	var toEncrypt []string
 	for _, path := range filePaths {
-		if s.shouldEncrypt(path) && !s.IsEncrypted(path) {
+		if s.matcher.Matches(path) {
 			toEncrypt = append(toEncrypt, path)
 		}
 	}
-	
+
 	if len(toEncrypt) == 0 {
 		s.log.InfoS("No files need encryption")
 		return nil
 	}
-	
+
 	s.log.InfoS("Starting parallel SOPS encryption", "files", len(toEncrypt), "workers", workers)
 	startTime := time.Now()
-	
+
 	var (
 		wg           sync.WaitGroup
 		sem          = make(chan struct{}, workers)
@@ -166,32 +197,31 @@ func (s *sopsService) EncryptBatch(filePaths []string, workers int) error {
 		errors       []error
 		errorsMu     sync.Mutex
 	)
-	
+
 	for _, filePath := range toEncrypt {
 		wg.Add(1)
 		go func(path string) {
 			defer wg.Done()
-			
+
 			// Acquire semaphore
 			sem <- struct{}{}
 			defer func() { <-sem }()
-			
+
 			// Encrypt file
 			if err := s.Encrypt(path); err != nil {
 				atomic.AddInt32(&failCount, 1)
 				errorsMu.Lock()
-				errors = append(errors, fmt.Errorf("failed to encrypt %s: %w", path, err))
+				errors = append(errors, err)
 				errorsMu.Unlock()
 				s.log.Error(err, "Failed to encrypt file", "file", path)
 			} else {
 				atomic.AddInt32(&successCount, 1)
-				s.log.V(2).InfoS("Encrypted file", "file", path)
 			}
 		}(filePath)
 	}
-	
+
 	wg.Wait()
-	
+
 	duration := time.Since(startTime)
 	s.log.InfoS("Parallel SOPS encryption completed",
 		"duration", duration.Round(time.Millisecond),
@@ -199,48 +229,163 @@ func (s *sopsService) EncryptBatch(filePaths []string, workers int) error {
 		"success", atomic.LoadInt32(&successCount),
 		"failed", atomic.LoadInt32(&failCount),
 		"workers", workers)
-	
+
 	// Return first error if any
 	if len(errors) > 0 {
 		return fmt.Errorf("encryption failed for %d files: %w", len(errors), errors[0])
 	}
-	
+
 	return nil
 }
 
 // IsEncrypted checks if a file is SOPS encrypted
 func (s *sopsService) IsEncrypted(filePath string) bool {
-	// Check if file exists
 	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return false
 	}
-	
-	// Check for SOPS metadata in the file
-	content := string(data)
-	return strings.Contains(content, "sops:") && 
-	       (strings.Contains(content, "kms:") || 
-	        strings.Contains(content, "pgp:") || 
-	        strings.Contains(content, "age:") ||
-	        strings.Contains(content, "azure_kv:") ||
-	        strings.Contains(content, "gcp_kms:"))
+	return sops.IsEncrypted(data, filePath)
+}
+
+// Validate checks that an encrypted file carries complete SOPS metadata
+func (s *sopsService) Validate(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	if err := sops.ValidateEncrypted(data, filePath); err != nil {
+		return err
+	}
+
+	if !bytes.Contains(data, []byte("ENC[")) {
+		s.log.V(1).InfoS("File has SOPS metadata but no encrypted values", "file", filePath)
+	}
+	return nil
+}
+
+// Rotate re-wraps the data key of an encrypted file for the keys of its creation rule
+func (s *sopsService) Rotate(filePath string) (bool, error) {
+	if s.configErr != nil {
+		return false, s.configErr
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+
+	s.applyKeyEnvironment()
+	var (
+		rotated []byte
+		changed bool
+	)
+	err = s.withTimeout("rotate", filePath, func() error {
+		var rotateErr error
+		rotated, changed, rotateErr = s.manager.RotateData(data, filePath)
+		return rotateErr
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to rotate %s: %w", filePath, err)
+	}
+	if !changed {
+		return false, nil
+	}
+
+	if err := writeFileAtomic(filePath, rotated); err != nil {
+		return false, fmt.Errorf("failed to write rotated file %s: %w", filePath, err)
+	}
+	return true, nil
+}
+
+// withTimeout runs a SOPS operation, giving up after the configured timeout.
+// The SOPS library takes no context, so a timed-out call is abandoned, not
+// cancelled; operations therefore only compute in fn, and callers write the
+// result themselves once fn returned in time.
+func (s *sopsService) withTimeout(op, filePath string, fn func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	timeout := time.Duration(s.config.Timeout) * time.Second
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		s.log.Warning("SOPS operation timed out", "operation", op, "file", filePath, "timeout", timeout)
+		return fmt.Errorf("SOPS %s timed out after %s: %w", op, timeout, context.DeadlineExceeded)
+	}
 }
 
-// shouldEncrypt checks if a file should be encrypted based on naming convention
-func (s *sopsService) shouldEncrypt(filePath string) bool {
-	// Check if it's a .dec.yaml file that should be encrypted
-	base := filepath.Base(filePath)
-	
-	// Files ending with .dec.yaml or .dec.yml should be encrypted
-	if strings.HasSuffix(base, ".dec.yaml") || strings.HasSuffix(base, ".dec.yml") {
-		return true
-	}
-	
-	// Check against configured regex if provided
-	if s.config.PathRegex != "" {
-		// This would require regex compilation and matching
-		// For now, we'll use the simple suffix check
-	}
-	
-	return false
-}
\ No newline at end of file
+// applyKeyEnvironment exports the configured key locations once, as the SOPS
+// key sources read the AWS profile, age identities and GnuPG home from the
+// environment. SOPS opens those paths as given, so a leading ~ is expanded here.
+func (s *sopsService) applyKeyEnvironment() {
+	s.envOnce.Do(func() {
+		for _, env := range []struct{ name, value string }{
+			{"AWS_PROFILE", s.config.AwsProfile},
+			{"SOPS_AGE_KEY_FILE", expandHome(s.config.AgeKeyFile)},
+			{"GNUPGHOME", expandHome(s.config.GnuPGHome)},
+		} {
+			if env.value == "" {
+				continue
+			}
+			if err := os.Setenv(env.name, env.value); err != nil {
+				s.log.Error(err, "Failed to set SOPS key environment", "variable", env.name)
+				continue
+			}
+			s.log.V(1).InfoS("Using SOPS key setting", "variable", env.name, "value", env.value)
+		}
+	})
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so readers never see a partially written file
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// isUpToDate reports whether the encrypted file is at least as new as the plaintext
+func isUpToDate(decPath, encPath string) bool {
+	decInfo, err := os.Stat(decPath)
+	if err != nil {
+		return false
+	}
+	encInfo, err := os.Stat(encPath)
+	if err != nil {
+		return false
+	}
+	return !encInfo.ModTime().Before(decInfo.ModTime())
+}
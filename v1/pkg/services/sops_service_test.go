@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+	"helm-charts-migrator/v1/pkg/config"
+)
+
+// encryptedFixture carries SOPS metadata without needing real keys
+const encryptedFixture = `secrets:
+    password: ENC[AES256_GCM,data:c2VjcmV0,iv:aXZpdml2aXZpdml2,tag:dGFndGFndGFndGFn,type:str]
+sops:
+    kms:
+        - arn: arn:aws:kms:us-east-1:000000000000:key/test
+          created_at: "2025-01-01T00:00:00Z"
+          enc: ZW5jcnlwdGVkLWRhdGEta2V5
+          aws_profile: ""
+    lastmodified: "2025-01-01T00:00:00Z"
+    mac: ENC[AES256_GCM,data:bWFj,iv:aXZpdml2aXZpdml2,tag:dGFndGFndGFndGFn,type:str]
+    encrypted_regex: ^(secrets)$
+    version: 3.10.2
+`
+
+func writeFile(t *testing.T, path, content string) string {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func newTestSOPSService(cfg config.SOPSConfig) *sopsService {
+	cfg.Enabled = true
+	if cfg.ConfigFile == "" {
+		cfg.ConfigFile = "does-not-exist.yaml"
+	}
+	return NewSOPSService(&cfg).(*sopsService)
+}
+
+func TestSOPSService_Defaults(t *testing.T) {
+	cfg := &config.SOPSConfig{Enabled: true}
+	svc := NewSOPSService(cfg).(*sopsService)
+
+	assert.Equal(t, 5, svc.config.ParallelWorkers)
+	assert.Equal(t, 30, svc.config.Timeout)
+	assert.Equal(t, ".sops.yaml", svc.config.ConfigFile)
+	assert.Zero(t, cfg.ParallelWorkers, "the caller's config must not be modified")
+}
+
+func TestSOPSService_IsEncryptedAndValidate(t *testing.T) {
+	dir := t.TempDir()
+	svc := newTestSOPSService(config.SOPSConfig{})
+
+	encrypted := writeFile(t, filepath.Join(dir, "secrets.enc.yaml"), encryptedFixture)
+	plain := writeFile(t, filepath.Join(dir, "secrets.dec.yaml"), "secrets:\n  password: secret\n")
+
+	assert.True(t, svc.IsEncrypted(encrypted))
+	assert.False(t, svc.IsEncrypted(plain))
+	assert.False(t, svc.IsEncrypted(filepath.Join(dir, "missing.yaml")))
+
+	assert.NoError(t, svc.Validate(encrypted))
+	assert.Error(t, svc.Validate(plain))
+
+	noMAC := writeFile(t, filepath.Join(dir, "nomac.enc.yaml"),
+		"secrets: x\nsops:\n    lastmodified: \"2025-01-01T00:00:00Z\"\n    version: 3.10.2\n")
+	assert.Error(t, svc.Validate(noMAC))
+}
+
+func TestSOPSService_DecryptPlainFile(t *testing.T) {
+	dir := t.TempDir()
+	svc := newTestSOPSService(config.SOPSConfig{})
+	plain := writeFile(t, filepath.Join(dir, "values.yaml"), "key: value\n")
+
+	data, err := svc.Decrypt(plain)
+	require.NoError(t, err)
+	assert.Equal(t, "key: value\n", string(data))
+}
+
+func TestSOPSService_EncryptSkips(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		svc := newTestSOPSService(config.SOPSConfig{})
+		svc.config.Enabled = false
+		assert.NoError(t, svc.Encrypt("apps/auth/secrets.dec.yaml"))
+		assert.NoError(t, svc.EncryptBatch([]string{"apps/auth/secrets.dec.yaml"}, 2))
+	})
+
+	t.Run("path does not match the regex", func(t *testing.T) {
+		dir := t.TempDir()
+		svc := newTestSOPSService(config.SOPSConfig{PathRegex: `(.*)/(.*).dec.yaml$`})
+		path := writeFile(t, filepath.Join(dir, "config.dec.json"), `{"a": 1}`)
+
+		require.NoError(t, svc.Encrypt(path))
+		assert.NoFileExists(t, filepath.Join(dir, "config.enc.json"))
+	})
+
+	t.Run("unchanged since last encryption", func(t *testing.T) {
+		dir := t.TempDir()
+		svc := newTestSOPSService(config.SOPSConfig{SkipUnchanged: true})
+		decPath := writeFile(t, filepath.Join(dir, "secrets.dec.yaml"), "secrets:\n  a: b\n")
+		encPath := writeFile(t, filepath.Join(dir, "secrets.enc.yaml"), encryptedFixture)
+		older := time.Now().Add(-time.Hour)
+		require.NoError(t, os.Chtimes(decPath, older, older))
+
+		// Without keys a real encryption would fail, so success means it was skipped
+		require.NoError(t, svc.Encrypt(decPath))
+		data, err := os.ReadFile(encPath)
+		require.NoError(t, err)
+		assert.Equal(t, encryptedFixture, string(data))
+	})
+
+	t.Run("invalid regex is reported", func(t *testing.T) {
+		svc := newTestSOPSService(config.SOPSConfig{PathRegex: "(["})
+		assert.Error(t, svc.Encrypt("secrets.dec.yaml"))
+		assert.Error(t, svc.EncryptBatch([]string{"secrets.dec.yaml"}, 1))
+	})
+}
+
+func TestSOPSService_EncryptWithoutCreationRule(t *testing.T) {
+	dir := t.TempDir()
+	svc := newTestSOPSService(config.SOPSConfig{})
+	path := writeFile(t, filepath.Join(dir, "secrets.dec.yaml"), "secrets:\n  a: b\n")
+
+	err := svc.EncryptBatch([]string{path, filepath.Join(dir, "values.yaml")}, 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "encryption failed for 1 files")
+	assert.NoFileExists(t, filepath.Join(dir, "secrets.enc.yaml"))
+}
+
+func TestSOPSService_WithTimeout(t *testing.T) {
+	svc := newTestSOPSService(config.SOPSConfig{Timeout: 1})
+
+	release := make(chan struct{})
+	defer close(release)
+
+	err := svc.withTimeout("encrypt", "secrets.dec.yaml", func() error {
+		<-release
+		return nil
+	})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	err = svc.withTimeout("encrypt", "secrets.dec.yaml", func() error {
+		return errors.New("kms failure")
+	})
+	assert.EqualError(t, err, "kms failure")
+}
+
+// newAgeIdentity generates an age key pair, returning the identity and its recipient
+func newAgeIdentity(t *testing.T) (string, string) {
+	t.Helper()
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	return identity.String(), identity.Recipient().String()
+}
+
+func readYAML(t *testing.T, data []byte) map[string]interface{} {
+	t.Helper()
+	var out map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(data, &out))
+	return out
+}
+
+func TestSOPSService_AgeRoundTrip(t *testing.T) {
+	// The service exports the key file; restore the environment afterwards
+	t.Setenv("SOPS_AGE_KEY_FILE", "")
+
+	dir := t.TempDir()
+	identity, recipient := newAgeIdentity(t)
+	keyFile := writeFile(t, filepath.Join(dir, "keys.txt"), identity+"\n")
+
+	svc := newTestSOPSService(config.SOPSConfig{
+		AgeKeyFile: keyFile,
+		CreationRules: []config.SOPSCreationRule{{
+			PathRegex:      `\.dec\.yaml$`,
+			Age:            []string{recipient},
+			EncryptedRegex: "^secrets$",
+		}},
+	})
+
+	plaintext := "name: auth\nsecrets:\n    password: s3cret\n    token: abc123\n"
+	decPath := writeFile(t, filepath.Join(dir, "apps", "auth", "envs", "dev01", "secrets.dec.yaml"), plaintext)
+	encPath := filepath.Join(dir, "apps", "auth", "envs", "dev01", "secrets.enc.yaml")
+
+	require.NoError(t, svc.EncryptBatch([]string{decPath}, 0))
+	require.FileExists(t, encPath)
+
+	encrypted, err := os.ReadFile(encPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "s3cret")
+	assert.Contains(t, string(encrypted), "name: auth", "keys outside encrypted_regex stay readable")
+	assert.True(t, svc.IsEncrypted(encPath))
+	assert.NoError(t, svc.Validate(encPath))
+
+	decrypted, err := svc.Decrypt(encPath)
+	require.NoError(t, err)
+	assert.Equal(t, readYAML(t, []byte(plaintext)), readYAML(t, decrypted))
+}
+
+func TestSOPSService_AgeKeyFileInHome(t *testing.T) {
+	t.Setenv("SOPS_AGE_KEY_FILE", "")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	identity, recipient := newAgeIdentity(t)
+	writeFile(t, filepath.Join(home, ".config", "sops", "age", "keys.txt"), identity+"\n")
+
+	svc := newTestSOPSService(config.SOPSConfig{
+		AgeKeyFile: "~/.config/sops/age/keys.txt",
+		CreationRules: []config.SOPSCreationRule{{
+			PathRegex: `\.dec\.yaml$`,
+			Age:       []string{recipient},
+		}},
+	})
+
+	dir := t.TempDir()
+	decPath := writeFile(t, filepath.Join(dir, "secrets.dec.yaml"), "secrets:\n    password: s3cret\n")
+	require.NoError(t, svc.Encrypt(decPath))
+
+	decrypted, err := svc.Decrypt(filepath.Join(dir, "secrets.enc.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(decrypted), "s3cret")
+	assert.Equal(t, filepath.Join(home, ".config", "sops", "age", "keys.txt"), os.Getenv("SOPS_AGE_KEY_FILE"))
+}
+
+func TestSOPSService_AgeShamirKeyGroups(t *testing.T) {
+	t.Setenv("SOPS_AGE_KEY_FILE", "")
+
+	dir := t.TempDir()
+	first, firstRecipient := newAgeIdentity(t)
+	second, secondRecipient := newAgeIdentity(t)
+	bothKeys := writeFile(t, filepath.Join(dir, "both.txt"), first+"\n"+second+"\n")
+	oneKey := writeFile(t, filepath.Join(dir, "one.txt"), first+"\n")
+
+	rules := []config.SOPSCreationRule{{
+		KeyGroups: []config.SOPSKeyGroup{
+			{Age: []string{firstRecipient}},
+			{Age: []string{secondRecipient}},
+		},
+		ShamirThreshold: 2,
+	}}
+
+	decPath := writeFile(t, filepath.Join(dir, "secrets.dec.yaml"), "secrets:\n    password: s3cret\n")
+	encPath := filepath.Join(dir, "secrets.enc.yaml")
+
+	svc := newTestSOPSService(config.SOPSConfig{AgeKeyFile: bothKeys, CreationRules: rules})
+	require.NoError(t, svc.Encrypt(decPath))
+
+	decrypted, err := svc.Decrypt(encPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(decrypted), "s3cret")
+
+	// One share is not enough to recover the data key
+	partial := newTestSOPSService(config.SOPSConfig{AgeKeyFile: oneKey, CreationRules: rules})
+	_, err = partial.Decrypt(encPath)
+	assert.Error(t, err)
+}
+
+func TestSOPSService_AgeWrongIdentity(t *testing.T) {
+	t.Setenv("SOPS_AGE_KEY_FILE", "")
+
+	dir := t.TempDir()
+	_, recipient := newAgeIdentity(t)
+	other, _ := newAgeIdentity(t)
+	otherKeys := writeFile(t, filepath.Join(dir, "other.txt"), other+"\n")
+
+	decPath := writeFile(t, filepath.Join(dir, "secrets.dec.yaml"), "secrets:\n    password: s3cret\n")
+	svc := newTestSOPSService(config.SOPSConfig{
+		AgeKeyFile:    otherKeys,
+		CreationRules: []config.SOPSCreationRule{{Age: []string{recipient}}},
+	})
+	require.NoError(t, svc.Encrypt(decPath))
+
+	_, err := svc.Decrypt(filepath.Join(dir, "secrets.enc.yaml"))
+	assert.Error(t, err)
+}
@@ -1,7 +1,7 @@
 package services
 
 import (
-	"regexp"
+	"fmt"
 
 	"helm-charts-migrator/v1/pkg/config"
 	"helm-charts-migrator/v1/pkg/keycase"
@@ -50,46 +50,18 @@ func (t *transformationService) NormalizeKeys(values map[string]interface{}) map
 	return result
 }
 
-// ExtractSecrets extracts secrets from values
-func (t *transformationService) ExtractSecrets(values map[string]interface{}) (secrets, cleaned map[string]interface{}) {
+// ConvertKeys converts keys based on the service's converter configuration (e.g., camelCase)
+func (t *transformationService) ConvertKeys(serviceName string, values map[string]interface{}) (map[string]interface{}, error) {
 	if values == nil {
-		return make(map[string]interface{}), make(map[string]interface{})
-	}
-
-	// Deep copy the values to avoid modifying the original
-	// Deep copy the values to avoid modifying the original
-	cleanedValues := t.deepMerge(make(map[string]interface{}), values)
-	extractedSecrets := make(map[string]interface{})
-
-	// Get patterns from configuration - no fallback
-	var patterns []string
-	if t.config != nil && t.config.Globals.Secrets != nil {
-		patterns = t.config.Globals.Secrets.Patterns
-	}
-
-	// Only extract secrets if patterns are configured
-	if len(patterns) > 0 {
-		t.extractSecretsRecursive(cleanedValues, extractedSecrets, "", patterns)
+		return make(map[string]interface{}), nil
 	}
 
-	return extractedSecrets, cleanedValues
-}
-
-// ConvertKeys converts keys based on configured rules (e.g., camelCase)
-func (t *transformationService) ConvertKeys(values map[string]interface{}) map[string]interface{} {
-	if values == nil {
-		return make(map[string]interface{})
+	// Create converter with the global configuration and the service's overrides
+	converter, err := keycase.NewFromConfig(t.config.GetConverterConfig(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("invalid converter configuration for service %s: %w", serviceName, err)
 	}
 
-	// Create converter with configuration
-	converter := keycase.NewConverter()
-
-	// Apply configuration from globals
-	cfg := t.config.Globals.Converter
-	converter.SkipJavaProperties = cfg.SkipJavaProperties
-	converter.SkipUppercaseKeys = cfg.SkipUppercaseKeys
-	converter.MinUppercaseChars = cfg.MinUppercaseChars
-
 	// Convert the map
 	return converter.ConvertMap(values)
 }
@@ -107,63 +79,6 @@ func (t *transformationService) MergeValues(base, override map[string]interface{
 	return t.deepMerge(base, override)
 }
 
-// extractSecretsRecursive recursively extracts secrets from nested structures
-func (t *transformationService) extractSecretsRecursive(cleaned, secrets map[string]interface{}, path string, patterns []string) {
-	for key, value := range cleaned {
-		currentPath := key
-		if path != "" {
-			currentPath = path + "." + key
-		}
-
-		// Check if this key matches any of the configured regex patterns
-		isSecret := false
-		for _, pattern := range patterns {
-			matched, err := t.matchesPattern(currentPath, pattern)
-			if err != nil {
-				t.log.V(4).InfoS("Pattern matching error", "pattern", pattern, "error", err)
-				continue
-			}
-			if matched {
-				isSecret = true
-				break
-			}
-		}
-
-		if isSecret {
-			// Move to secrets
-			secrets[key] = value
-			delete(cleaned, key)
-			t.log.V(3).InfoS("Extracted secret", "path", currentPath)
-			continue
-		}
-
-		// Recursively process nested maps
-		if nestedMap, ok := value.(map[string]interface{}); ok {
-			nestedSecrets := make(map[string]interface{})
-			t.extractSecretsRecursive(nestedMap, nestedSecrets, currentPath, patterns)
-
-			if len(nestedSecrets) > 0 {
-				// Add nested secrets
-				secrets[key] = nestedSecrets
-			}
-
-			// Remove empty maps from cleaned values
-			if len(nestedMap) == 0 {
-				delete(cleaned, key)
-			}
-		}
-	}
-}
-
-// matchesPattern checks if a path matches a regex pattern
-func (t *transformationService) matchesPattern(path, pattern string) (bool, error) {
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		return false, err
-	}
-	return re.MatchString(path), nil
-}
-
 // deepMerge performs a deep merge of two maps
 func (t *transformationService) deepMerge(base, override map[string]interface{}) map[string]interface{} {
 	result := make(map[string]interface{})
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm-charts-migrator/v1/pkg/config"
+)
+
+func TestTransformationService_ConvertKeysUsesServiceConverter(t *testing.T) {
+	cfg := &config.Config{
+		Services: map[string]config.Service{
+			"api": {
+				Enabled:   true,
+				Converter: &config.ConverterConfig{PreserveKeys: []string{"db_host"}},
+			},
+		},
+	}
+	svc := NewTransformationService(cfg)
+	values := map[string]interface{}{"db_host": "db", "log_level": "info"}
+
+	converted, err := svc.ConvertKeys("api", values)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"db_host": "db", "logLevel": "info"}, converted)
+
+	converted, err = svc.ConvertKeys("web", values)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"dbHost": "db", "logLevel": "info"}, converted)
+}
+
+func TestTransformationService_ConvertKeysInvalidServiceConverter(t *testing.T) {
+	cfg := &config.Config{
+		Services: map[string]config.Service{
+			"api": {Converter: &config.ConverterConfig{TargetStyle: "SCREAMING"}},
+		},
+	}
+
+	_, err := NewTransformationService(cfg).ConvertKeys("api", map[string]interface{}{"a_b": 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service api")
+}
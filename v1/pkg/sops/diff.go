@@ -0,0 +1,135 @@
+package sops
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+)
+
+// ChangeKind describes how a key differs between two secrets files
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "added"
+	ChangeRemoved ChangeKind = "removed"
+	ChangeChanged ChangeKind = "changed"
+)
+
+// KeyChange is a single key that differs between two secrets files
+type KeyChange struct {
+	Key  string
+	Kind ChangeKind
+	Old  string
+	New  string
+}
+
+// Diff compares two plaintext YAML documents leaf by leaf, returning the
+// changes sorted by key
+func Diff(oldData, newData []byte) ([]KeyChange, error) {
+	oldValues, err := Flatten(oldData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse old file: %w", err)
+	}
+	newValues, err := Flatten(newData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse new file: %w", err)
+	}
+
+	var changes []KeyChange
+	for key, oldValue := range oldValues {
+		newValue, exists := newValues[key]
+		switch {
+		case !exists:
+			changes = append(changes, KeyChange{Key: key, Kind: ChangeRemoved, Old: oldValue})
+		case newValue != oldValue:
+			changes = append(changes, KeyChange{Key: key, Kind: ChangeChanged, Old: oldValue, New: newValue})
+		}
+	}
+	for key, newValue := range newValues {
+		if _, exists := oldValues[key]; !exists {
+			changes = append(changes, KeyChange{Key: key, Kind: ChangeAdded, New: newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes, nil
+}
+
+// Flatten returns the leaf values of a YAML document keyed by their dotted
+// path, with list items addressed as key[index]
+func Flatten(data []byte) (map[string]string, error) {
+	values := make(map[string]string)
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return values, nil
+	}
+
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	flattenInto(values, "", doc)
+	return values, nil
+}
+
+func flattenInto(values map[string]string, prefix string, node interface{}) {
+	switch typed := node.(type) {
+	case map[string]interface{}:
+		if len(typed) == 0 && prefix != "" {
+			values[prefix] = "{}"
+		}
+		for key, value := range typed {
+			flattenInto(values, joinKey(prefix, key), value)
+		}
+	case map[interface{}]interface{}:
+		if len(typed) == 0 && prefix != "" {
+			values[prefix] = "{}"
+		}
+		for key, value := range typed {
+			flattenInto(values, joinKey(prefix, fmt.Sprint(key)), value)
+		}
+	case []interface{}:
+		if len(typed) == 0 && prefix != "" {
+			values[prefix] = "[]"
+		}
+		for i, value := range typed {
+			flattenInto(values, fmt.Sprintf("%s[%d]", prefix, i), value)
+		}
+	case nil:
+		if prefix != "" {
+			values[prefix] = "null"
+		}
+	default:
+		values[prefix] = fmt.Sprint(typed)
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// MaskValue hides the middle of a secret, keeping the first and last four
+// characters of values longer than eight characters
+func MaskValue(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + strings.Repeat("*", len(value)-8) + value[len(value)-4:]
+}
+
+// MaskValueWithDigest masks a secret like MaskValue and adds the first eight
+// hex digits of its SHA-256, so values differing only in the masked middle
+// still show as different in diffs
+func MaskValueWithDigest(value string) string {
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(value))
+	return MaskValue(value) + " #" + hex.EncodeToString(sum[:4])
+}
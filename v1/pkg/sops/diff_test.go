@@ -0,0 +1,78 @@
+package sops
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	oldData := []byte(`secrets:
+  password: password123
+  apiKey: removed-key
+  hosts:
+    - a.example.com
+    - b.example.com
+`)
+	newData := []byte(`secrets:
+  password: password456
+  token: new-token-value
+  hosts:
+    - a.example.com
+    - c.example.com
+`)
+
+	changes, err := Diff(oldData, newData)
+	require.NoError(t, err)
+
+	assert.Equal(t, []KeyChange{
+		{Key: "secrets.apiKey", Kind: ChangeRemoved, Old: "removed-key"},
+		{Key: "secrets.hosts[1]", Kind: ChangeChanged, Old: "b.example.com", New: "c.example.com"},
+		{Key: "secrets.password", Kind: ChangeChanged, Old: "password123", New: "password456"},
+		{Key: "secrets.token", Kind: ChangeAdded, New: "new-token-value"},
+	}, changes)
+}
+
+func TestDiff_EmptySide(t *testing.T) {
+	changes, err := Diff(nil, []byte("secrets:\n  password: x\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []KeyChange{{Key: "secrets.password", Kind: ChangeAdded, New: "x"}}, changes)
+
+	changes, err = Diff([]byte("secrets: {}\n"), []byte("secrets: {}\n"))
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func TestFlatten(t *testing.T) {
+	values, err := Flatten([]byte(`secrets:
+  port: 5432
+  enabled: true
+  empty: {}
+  none: null
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"secrets.port":    "5432",
+		"secrets.enabled": "true",
+		"secrets.empty":   "{}",
+		"secrets.none":    "null",
+	}, values)
+}
+
+func TestMaskValue(t *testing.T) {
+	assert.Equal(t, "*****", MaskValue("short"))
+	assert.Equal(t, "pass***d123", MaskValue("password123"))
+	assert.Equal(t, "", MaskValue(""))
+}
+
+func TestMaskValueWithDigest(t *testing.T) {
+	// A change in the masked middle still changes the output
+	assert.Equal(t, MaskValue("password-one-123"), MaskValue("password-two-123"))
+	assert.NotEqual(t, MaskValueWithDigest("password-one-123"), MaskValueWithDigest("password-two-123"))
+
+	assert.Regexp(t, `^pass\*\*\*d123 #[0-9a-f]{8}$`, MaskValueWithDigest("password123"))
+	assert.Equal(t, MaskValueWithDigest("password123"), MaskValueWithDigest("password123"))
+	assert.Equal(t, "", MaskValueWithDigest(""))
+}
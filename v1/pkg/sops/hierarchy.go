@@ -0,0 +1,26 @@
+package sops
+
+import (
+	"helm-charts-migrator/v1/pkg/config"
+)
+
+// hierarchyComment returns the header comment for a secrets file under
+// apps/<service>/envs, based on the hierarchy level it belongs to. Files
+// elsewhere get no comment.
+func hierarchyComment(secretsPath string) (string, config.PathLevel) {
+	appPath, err := config.ParseAppPath(secretsPath)
+	if err != nil || appPath.Kind != config.FileKindSecrets {
+		return "", ""
+	}
+
+	switch level := appPath.Level(); level {
+	case config.LevelEnvironment:
+		return "# Placeholder to environment level secrets\n", level
+	case config.LevelCluster:
+		return "# Placeholder to cluster level secrets\n", level
+	case config.LevelNamespace:
+		return "# Placeholder to namespace level secrets.\n# It can override any previous secrets as needed.\n", level
+	default:
+		return "", level
+	}
+}
@@ -0,0 +1,48 @@
+package sops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm-charts-migrator/v1/pkg/logger"
+)
+
+func TestExtractSecretsToFile_HierarchyComment(t *testing.T) {
+	root := t.TempDir()
+	log := logger.WithName("sops-test")
+
+	tests := []struct {
+		dir     string
+		comment string
+	}{
+		{"apps/heimdall", ""},
+		{"apps/heimdall/envs/production", "# Placeholder to environment level secrets\n"},
+		{"apps/heimdall/envs/production/clusters/prod01", "# Placeholder to cluster level secrets\n"},
+		{"apps/heimdall/envs/production/clusters/prod01/namespaces/viafoura",
+			"# Placeholder to namespace level secrets.\n# It can override any previous secrets as needed.\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			dir := filepath.Join(root, tt.dir)
+			require.NoError(t, os.MkdirAll(dir, 0755))
+			valuesPath := filepath.Join(dir, "values.yaml")
+			secretsPath := filepath.Join(dir, "secrets.dec.yaml")
+			require.NoError(t, os.WriteFile(valuesPath, []byte("image: app\nsecrets:\n  password: x\n"), 0644))
+
+			require.NoError(t, ExtractSecretsToFile(valuesPath, secretsPath, log))
+
+			data := mustRead(t, secretsPath)
+			assert.Equal(t, tt.comment+"secrets:\n    password: x\n", string(data))
+			assert.NotContains(t, string(mustRead(t, valuesPath)), "secrets")
+
+			// Existing files with a comment are left alone
+			require.NoError(t, AddCommentsToExistingSecrets(secretsPath, log))
+			assert.Equal(t, data, mustRead(t, secretsPath))
+		})
+	}
+}
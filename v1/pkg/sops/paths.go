@@ -0,0 +1,145 @@
+package sops
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+
+	"helm-charts-migrator/v1/pkg/config"
+)
+
+const (
+	// DecryptedMarker marks plaintext secrets files, e.g. secrets.dec.yaml
+	DecryptedMarker = ".dec."
+	// EncryptedMarker marks SOPS encrypted secrets files, e.g. secrets.enc.yaml
+	EncryptedMarker = ".enc."
+)
+
+// IsDecryptedPath reports whether the file name carries the .dec. marker
+func IsDecryptedPath(path string) bool {
+	return strings.Contains(filepath.Base(path), DecryptedMarker)
+}
+
+// IsEncryptedPath reports whether the file name carries the .enc. marker
+func IsEncryptedPath(path string) bool {
+	return strings.Contains(filepath.Base(path), EncryptedMarker)
+}
+
+// EncryptedPath returns the .enc. counterpart of a .dec. file
+func EncryptedPath(path string) string {
+	return replaceMarker(path, DecryptedMarker, EncryptedMarker)
+}
+
+// DecryptedPath returns the .dec. counterpart of an .enc. file
+func DecryptedPath(path string) string {
+	return replaceMarker(path, EncryptedMarker, DecryptedMarker)
+}
+
+// replaceMarker swaps the marker in the file name only, never in directory names
+func replaceMarker(path, from, to string) string {
+	dir, base := filepath.Split(path)
+	return dir + strings.Replace(base, from, to, 1)
+}
+
+// PathMatcher decides which plaintext files are encrypted. A file must carry
+// the .dec. marker and, when path regexes are known, match one of them.
+type PathMatcher struct {
+	regexes []*regexp.Regexp
+}
+
+// NewPathMatcher builds a matcher from pathRegex or, when empty, from the
+// path_regex of the first creation rule in the SOPS config file
+func NewPathMatcher(pathRegex, configPath string) (*PathMatcher, error) {
+	if pathRegex == "" && configPath != "" {
+		var err error
+		pathRegex, err = creationRulePathRegex(configPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if pathRegex == "" {
+		return &PathMatcher{}, nil
+	}
+
+	regex, err := regexp.Compile(pathRegex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile SOPS path regex %q: %w", pathRegex, err)
+	}
+	return &PathMatcher{regexes: []*regexp.Regexp{regex}}, nil
+}
+
+// NewPathMatcherForRules matches the files covered by any of the creation
+// rules; a rule without a path regex covers every .dec. file
+func NewPathMatcherForRules(rules []config.SOPSCreationRule) (*PathMatcher, error) {
+	matcher := &PathMatcher{}
+	for _, rule := range rules {
+		if rule.PathRegex == "" {
+			return &PathMatcher{}, nil
+		}
+		regex, err := regexp.Compile(rule.PathRegex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile SOPS path regex %q: %w", rule.PathRegex, err)
+		}
+		matcher.regexes = append(matcher.regexes, regex)
+	}
+	return matcher, nil
+}
+
+// Matches reports whether path should be encrypted
+func (m *PathMatcher) Matches(path string) bool {
+	if !IsDecryptedPath(path) {
+		return false
+	}
+	if len(m.regexes) == 0 {
+		return true
+	}
+	for _, regex := range m.regexes {
+		if regex.MatchString(filepath.ToSlash(path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the regexes in use, or the marker rule when there are none
+func (m *PathMatcher) String() string {
+	if len(m.regexes) == 0 {
+		return "*" + DecryptedMarker + "*"
+	}
+	patterns := make([]string, len(m.regexes))
+	for i, regex := range m.regexes {
+		patterns[i] = regex.String()
+	}
+	return strings.Join(patterns, " | ")
+}
+
+// creationRulePathRegex reads the path_regex of the first creation rule; a
+// missing config file is not an error
+func creationRulePathRegex(configPath string) (string, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read SOPS config: %w", err)
+	}
+
+	var sopsConfig struct {
+		CreationRules []struct {
+			PathRegex string `yaml:"path_regex"`
+		} `yaml:"creation_rules"`
+	}
+	if err := yaml.Unmarshal(data, &sopsConfig); err != nil {
+		return "", fmt.Errorf("failed to parse SOPS config %s: %w", configPath, err)
+	}
+
+	if len(sopsConfig.CreationRules) == 0 {
+		return "", nil
+	}
+	return sopsConfig.CreationRules[0].PathRegex, nil
+}
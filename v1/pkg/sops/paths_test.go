@@ -0,0 +1,61 @@
+package sops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCounterpartPaths(t *testing.T) {
+	assert.Equal(t, "apps/auth/envs/dev01/secrets.enc.yaml", EncryptedPath("apps/auth/envs/dev01/secrets.dec.yaml"))
+	assert.Equal(t, "apps/auth/envs/dev01/secrets.dec.yaml", DecryptedPath("apps/auth/envs/dev01/secrets.enc.yaml"))
+
+	// Only the file name is rewritten, never a directory that happens to contain the marker
+	assert.Equal(t, "apps/my.dec.app/secrets.enc.json", EncryptedPath("apps/my.dec.app/secrets.dec.json"))
+	assert.False(t, IsDecryptedPath("apps/my.dec.app/values.yaml"))
+	assert.True(t, IsEncryptedPath("secrets.enc.yml"))
+}
+
+func TestPathMatcher(t *testing.T) {
+	t.Run("marker only without a regex", func(t *testing.T) {
+		matcher, err := NewPathMatcher("", "")
+		require.NoError(t, err)
+		assert.True(t, matcher.Matches("apps/auth/secrets.dec.yaml"))
+		assert.True(t, matcher.Matches("apps/auth/config.dec.json"))
+		assert.False(t, matcher.Matches("apps/auth/secrets.enc.yaml"))
+	})
+
+	t.Run("explicit regex", func(t *testing.T) {
+		matcher, err := NewPathMatcher(`(.*)/(.*).dec.(yml|yaml)$`, "")
+		require.NoError(t, err)
+		assert.True(t, matcher.Matches("apps/auth/secrets.dec.yaml"))
+		assert.False(t, matcher.Matches("apps/auth/config.dec.json"))
+	})
+
+	t.Run("regex from the first creation rule", func(t *testing.T) {
+		configPath := filepath.Join(t.TempDir(), ".sops.yaml")
+		require.NoError(t, os.WriteFile(configPath, []byte(`creation_rules:
+  - path_regex: "apps/auth/.*\\.dec\\.yaml$"
+  - path_regex: ".*"
+`), 0644))
+
+		matcher, err := NewPathMatcher("", configPath)
+		require.NoError(t, err)
+		assert.True(t, matcher.Matches("apps/auth/secrets.dec.yaml"))
+		assert.False(t, matcher.Matches("apps/heimdall/secrets.dec.yaml"))
+	})
+
+	t.Run("missing config file falls back to the marker", func(t *testing.T) {
+		matcher, err := NewPathMatcher("", filepath.Join(t.TempDir(), "missing.yaml"))
+		require.NoError(t, err)
+		assert.True(t, matcher.Matches("secrets.dec.yaml"))
+	})
+
+	t.Run("invalid regex", func(t *testing.T) {
+		_, err := NewPathMatcher("([", "")
+		assert.Error(t, err)
+	})
+}
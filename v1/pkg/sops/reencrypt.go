@@ -0,0 +1,170 @@
+package sops
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/aes"
+	"github.com/getsops/sops/v3/cmd/sops/common"
+	"github.com/getsops/sops/v3/config"
+	"github.com/getsops/sops/v3/keyservice"
+)
+
+// errNoReuse is returned when the existing encrypted file cannot be reused;
+// the caller then encrypts from scratch with a fresh data key
+var errNoReuse = errors.New("existing encryption cannot be reused")
+
+// reencrypt encrypts branches reusing the data key and ciphertext of the
+// existing encrypted file, so unchanged values keep their exact ciphertext and
+// only changed secrets show up in diffs. It returns the existing file as-is
+// when nothing changed, and errNoReuse when the existing file cannot be
+// reused (different keys or rules, or no access to its data key).
+func (m *Manager) reencrypt(existing []byte, branches sops.TreeBranches, conf *config.Config, store common.Store, outputPath string) ([]byte, error) {
+	previous, err := store.LoadEncryptedFile(existing)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load existing encrypted file: %v", errNoReuse, err)
+	}
+	if !sameEncryptionRules(previous.Metadata, conf) {
+		return nil, fmt.Errorf("%w: keys or encryption rules changed", errNoReuse)
+	}
+
+	// Decrypt through a recording cipher to learn the ciphertext of every value
+	recorder := newReuseCipher(aes.NewCipher())
+	dataKey, err := common.DecryptTree(common.DecryptTreeOpts{
+		Tree:        &previous,
+		KeyServices: []keyservice.KeyServiceClient{keyservice.NewLocalClient()},
+		Cipher:      recorder,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to decrypt existing encrypted file: %v", errNoReuse, err)
+	}
+
+	previousPlain, err := store.EmitPlainFile(previous.Branches)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to emit existing plaintext: %v", errNoReuse, err)
+	}
+	currentPlain, err := store.EmitPlainFile(branches)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to emit plaintext: %v", errNoReuse, err)
+	}
+	if bytes.Equal(previousPlain, currentPlain) {
+		m.log.V(2).InfoS("Secrets unchanged, keeping existing encrypted file", "file", outputPath)
+		return existing, nil
+	}
+
+	// Same keys and data key, new values; EncryptTree refreshes lastmodified and the MAC
+	tree := sops.Tree{
+		Branches: branches,
+		Metadata: previous.Metadata,
+		FilePath: outputPath,
+	}
+	if err := common.EncryptTree(common.EncryptTreeOpts{
+		Tree:    &tree,
+		Cipher:  recorder,
+		DataKey: dataKey,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encrypt tree: %w", err)
+	}
+
+	m.log.V(2).InfoS("Re-encrypted changed values only", "file", outputPath,
+		"reused", recorder.reused, "encrypted", recorder.encrypted)
+
+	encryptedData, err := store.EmitEncryptedFile(tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to emit encrypted file: %w", err)
+	}
+	return encryptedData, nil
+}
+
+// sameEncryptionRules reports whether a file encrypted with metadata would be
+// encrypted the same way by the creation rule conf
+func sameEncryptionRules(metadata sops.Metadata, conf *config.Config) bool {
+	if !sameKeyGroups(metadata, conf) ||
+		metadata.EncryptedRegex != conf.EncryptedRegex ||
+		metadata.UnencryptedRegex != conf.UnencryptedRegex ||
+		metadata.EncryptedSuffix != conf.EncryptedSuffix ||
+		metadata.UnencryptedSuffix != conf.UnencryptedSuffix ||
+		metadata.MACOnlyEncrypted != conf.MACOnlyEncrypted {
+		return false
+	}
+	return true
+}
+
+// keyGroupIDs identifies the master keys of each group, ignoring their encrypted data keys
+func keyGroupIDs(groups []sops.KeyGroup) [][]string {
+	ids := make([][]string, len(groups))
+	for i, group := range groups {
+		ids[i] = make([]string, len(group))
+		for j, key := range group {
+			ids[i][j] = key.TypeToIdentifier() + ":" + key.ToString()
+		}
+	}
+	return ids
+}
+
+// reuseCipher wraps a cipher, remembering every value it decrypts so that
+// encrypting the same value at the same path returns the same ciphertext
+type reuseCipher struct {
+	inner     sops.Cipher
+	mu        sync.Mutex
+	seen      map[string][]decryptedValue
+	reused    int
+	encrypted int
+}
+
+type decryptedValue struct {
+	plaintext  interface{}
+	ciphertext string
+}
+
+func newReuseCipher(inner sops.Cipher) *reuseCipher {
+	return &reuseCipher{
+		inner: inner,
+		seen:  make(map[string][]decryptedValue),
+	}
+}
+
+// Encrypt implements sops.Cipher
+func (c *reuseCipher) Encrypt(plaintext interface{}, key []byte, additionalData string) (string, error) {
+	value := commentValue(plaintext)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, previous := range c.seen[additionalData] {
+		if reflect.DeepEqual(previous.plaintext, value) {
+			c.reused++
+			return previous.ciphertext, nil
+		}
+	}
+
+	c.encrypted++
+	return c.inner.Encrypt(plaintext, key, additionalData)
+}
+
+// Decrypt implements sops.Cipher
+func (c *reuseCipher) Decrypt(ciphertext string, key []byte, additionalData string) (interface{}, error) {
+	plaintext, err := c.inner.Decrypt(ciphertext, key, additionalData)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seen[additionalData] = append(c.seen[additionalData], decryptedValue{
+		plaintext:  commentValue(plaintext),
+		ciphertext: ciphertext,
+	})
+	return plaintext, nil
+}
+
+// commentValue unwraps comments, which are encrypted by their text
+func commentValue(value interface{}) interface{} {
+	if comment, ok := value.(sops.Comment); ok {
+		return comment.Value
+	}
+	return value
+}
@@ -0,0 +1,123 @@
+package sops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/logger"
+)
+
+// newAgeManager returns a manager encrypting for a fresh age key, with the
+// identity exported for decryption
+func newAgeManager(t *testing.T) (*Manager, string) {
+	t.Helper()
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	keyFile := filepath.Join(t.TempDir(), "keys.txt")
+	require.NoError(t, os.WriteFile(keyFile, []byte(identity.String()+"\n"), 0600))
+	t.Setenv("SOPS_AGE_KEY_FILE", keyFile)
+
+	manager := NewManager(logger.WithName("sops-test"), "")
+	require.NoError(t, manager.SetCreationRules([]config.SOPSCreationRule{{
+		Age:            []string{identity.Recipient().String()},
+		EncryptedRegex: "^secrets$",
+	}}))
+	return manager, identity.Recipient().String()
+}
+
+// encryptedValues returns the ciphertext of every secret and the wrapped data key
+func encryptedValues(t *testing.T, path string) (map[string]string, string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var doc struct {
+		Secrets map[string]string `yaml:"secrets"`
+		SOPS    struct {
+			Age []struct {
+				Enc string `yaml:"enc"`
+			} `yaml:"age"`
+		} `yaml:"sops"`
+	}
+	require.NoError(t, yaml.Unmarshal(data, &doc))
+	require.Len(t, doc.SOPS.Age, 1)
+	return doc.Secrets, doc.SOPS.Age[0].Enc
+}
+
+func writePlain(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestEncryptFile_ReusesCiphertextForUnchangedValues(t *testing.T) {
+	manager, _ := newAgeManager(t)
+	dir := t.TempDir()
+	decPath := filepath.Join(dir, "secrets.dec.yaml")
+	encPath := filepath.Join(dir, "secrets.enc.yaml")
+
+	writePlain(t, decPath, "secrets:\n    password: s3cret\n    token: abc\n")
+	require.NoError(t, manager.EncryptFile(decPath, encPath))
+	before, beforeKey := encryptedValues(t, encPath)
+
+	writePlain(t, decPath, "secrets:\n    password: s3cret\n    token: changed\n    added: new\n")
+	require.NoError(t, manager.EncryptFile(decPath, encPath))
+	after, afterKey := encryptedValues(t, encPath)
+
+	assert.Equal(t, beforeKey, afterKey, "the data key is kept")
+	assert.Equal(t, before["password"], after["password"], "unchanged values keep their ciphertext")
+	assert.NotEqual(t, before["token"], after["token"])
+	assert.Contains(t, after, "added")
+
+	plaintext, err := manager.DecryptData(mustRead(t, encPath), encPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(plaintext), "token: changed")
+	assert.Contains(t, string(plaintext), "added: new")
+}
+
+func TestEncryptFile_UnchangedPlaintextKeepsFile(t *testing.T) {
+	manager, _ := newAgeManager(t)
+	dir := t.TempDir()
+	decPath := filepath.Join(dir, "secrets.dec.yaml")
+	encPath := filepath.Join(dir, "secrets.enc.yaml")
+
+	writePlain(t, decPath, "secrets:\n    password: s3cret\n")
+	require.NoError(t, manager.EncryptFile(decPath, encPath))
+	first := mustRead(t, encPath)
+
+	require.NoError(t, manager.EncryptFile(decPath, encPath))
+	assert.Equal(t, string(first), string(mustRead(t, encPath)))
+}
+
+func TestEncryptFile_NewKeysEncryptFromScratch(t *testing.T) {
+	manager, _ := newAgeManager(t)
+	dir := t.TempDir()
+	decPath := filepath.Join(dir, "secrets.dec.yaml")
+	encPath := filepath.Join(dir, "secrets.enc.yaml")
+
+	writePlain(t, decPath, "secrets:\n    password: s3cret\n")
+	require.NoError(t, manager.EncryptFile(decPath, encPath))
+	before, beforeKey := encryptedValues(t, encPath)
+
+	// A different recipient means the existing data key must not be reused
+	rotated, _ := newAgeManager(t)
+	require.NoError(t, rotated.EncryptFile(decPath, encPath))
+	after, afterKey := encryptedValues(t, encPath)
+
+	assert.NotEqual(t, beforeKey, afterKey)
+	assert.NotEqual(t, before["password"], after["password"])
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return data
+}
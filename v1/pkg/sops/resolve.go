@@ -0,0 +1,119 @@
+package sops
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	yaml "github.com/elioetibr/golang-yaml-advanced"
+)
+
+// Layer is one plaintext secrets file taking part in a resolution
+type Layer struct {
+	File string
+	Data []byte
+}
+
+// ResolvedValue is the effective value of a leaf key and the file it comes from
+type ResolvedValue struct {
+	Key    string
+	Value  string
+	Origin string
+}
+
+// Resolve merges secrets layers the way Helm merges values files: later layers
+// override earlier ones key by key, lists are replaced as a whole and a null
+// value removes the key. It returns the effective leaf values sorted by key.
+func Resolve(layers []Layer) ([]ResolvedValue, error) {
+	merged := make(map[string]interface{})
+	origins := make(map[string]string)
+
+	for _, layer := range layers {
+		var doc map[string]interface{}
+		if len(strings.TrimSpace(string(layer.Data))) > 0 {
+			if err := yaml.Unmarshal(layer.Data, &doc); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", layer.File, err)
+			}
+		}
+		mergeLayer(merged, doc, "", layer.File, origins)
+	}
+
+	values := make(map[string]string)
+	flattenInto(values, "", merged)
+
+	resolved := make([]ResolvedValue, 0, len(values))
+	for key, value := range values {
+		resolved = append(resolved, ResolvedValue{Key: key, Value: value, Origin: originOf(origins, key)})
+	}
+	sort.Slice(resolved, func(i, j int) bool { return resolved[i].Key < resolved[j].Key })
+	return resolved, nil
+}
+
+// mergeLayer merges src into dst, recording the file that set each key
+func mergeLayer(dst, src map[string]interface{}, prefix, file string, origins map[string]string) {
+	for key, value := range src {
+		path := joinKey(prefix, key)
+
+		if value == nil {
+			delete(dst, key)
+			forgetOrigins(origins, path)
+			continue
+		}
+
+		if srcMap, ok := asStringMap(value); ok {
+			dstMap, ok := asStringMap(dst[key])
+			if !ok {
+				dstMap = make(map[string]interface{})
+				forgetOrigins(origins, path)
+			}
+			dst[key] = dstMap
+			mergeLayer(dstMap, srcMap, path, file, origins)
+			if len(dstMap) == 0 {
+				origins[path] = file
+			}
+			continue
+		}
+
+		forgetOrigins(origins, path)
+		dst[key] = value
+		origins[path] = file
+	}
+}
+
+// forgetOrigins drops the origins of a key and everything below it
+func forgetOrigins(origins map[string]string, path string) {
+	for key := range origins {
+		if key == path || strings.HasPrefix(key, path+".") || strings.HasPrefix(key, path+"[") {
+			delete(origins, key)
+		}
+	}
+}
+
+// originOf returns the origin of a leaf, or of the list or map containing it
+func originOf(origins map[string]string, key string) string {
+	for {
+		if origin, ok := origins[key]; ok {
+			return origin
+		}
+		cut := strings.LastIndexAny(key, ".[")
+		if cut <= 0 {
+			return ""
+		}
+		key = key[:cut]
+	}
+}
+
+func asStringMap(value interface{}) (map[string]interface{}, bool) {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		return typed, true
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			converted[fmt.Sprint(key)] = item
+		}
+		return converted, true
+	default:
+		return nil, false
+	}
+}
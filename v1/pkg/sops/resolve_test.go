@@ -0,0 +1,52 @@
+package sops
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolve(t *testing.T) {
+	layers := []Layer{
+		{File: "service", Data: []byte(`secrets:
+  db:
+    user: app
+    password: base-password
+  hosts: [a, b]
+  legacy: old
+`)},
+		{File: "environment", Data: nil},
+		{File: "cluster", Data: []byte(`secrets:
+  db:
+    password: cluster-password
+  hosts: [c]
+`)},
+		{File: "namespace", Data: []byte(`secrets:
+  legacy: null
+  token: ns-token
+`)},
+	}
+
+	resolved, err := Resolve(layers)
+	require.NoError(t, err)
+
+	assert.Equal(t, []ResolvedValue{
+		{Key: "secrets.db.password", Value: "cluster-password", Origin: "cluster"},
+		{Key: "secrets.db.user", Value: "app", Origin: "service"},
+		{Key: "secrets.hosts[0]", Value: "c", Origin: "cluster"},
+		{Key: "secrets.token", Value: "ns-token", Origin: "namespace"},
+	}, resolved)
+}
+
+func TestResolve_TypeChange(t *testing.T) {
+	resolved, err := Resolve([]Layer{
+		{File: "base", Data: []byte("secrets:\n  conf:\n    a: 1\n    b: 2\n")},
+		{File: "override", Data: []byte("secrets:\n  conf: inline\n")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []ResolvedValue{{Key: "secrets.conf", Value: "inline", Origin: "override"}}, resolved)
+
+	_, err = Resolve([]Layer{{File: "broken", Data: []byte("secrets: [")}})
+	assert.ErrorContains(t, err, "broken")
+}
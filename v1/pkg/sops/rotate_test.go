@@ -0,0 +1,94 @@
+package sops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/logger"
+)
+
+func TestRotateData_RewrapsForNewRecipient(t *testing.T) {
+	manager, _ := newAgeManager(t)
+	oldKeyFile := os.Getenv("SOPS_AGE_KEY_FILE")
+	dir := t.TempDir()
+	decPath := filepath.Join(dir, "secrets.dec.yaml")
+	encPath := filepath.Join(dir, "secrets.enc.yaml")
+
+	writePlain(t, decPath, "secrets:\n    password: s3cret\n")
+	require.NoError(t, manager.EncryptFile(decPath, encPath))
+	before, beforeKey := encryptedValues(t, encPath)
+
+	newIdentity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	rotator := NewManager(logger.WithName("sops-test"), "")
+	require.NoError(t, rotator.SetCreationRules([]config.SOPSCreationRule{{
+		Age:            []string{newIdentity.Recipient().String()},
+		EncryptedRegex: "^secrets$",
+	}}))
+
+	// Rotation decrypts the data key with the old identity
+	rotated, changed, err := rotator.RotateData(mustRead(t, encPath), encPath)
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.NoError(t, os.WriteFile(encPath, rotated, 0644))
+
+	after, afterKey := encryptedValues(t, encPath)
+	assert.Equal(t, before, after, "values are not re-encrypted")
+	assert.NotEqual(t, beforeKey, afterKey)
+
+	// Only the new identity can decrypt now
+	newKeyFile := filepath.Join(t.TempDir(), "new.txt")
+	require.NoError(t, os.WriteFile(newKeyFile, []byte(newIdentity.String()+"\n"), 0600))
+	t.Setenv("SOPS_AGE_KEY_FILE", newKeyFile)
+	plaintext, err := rotator.DecryptData(rotated, encPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(plaintext), "password: s3cret")
+
+	t.Setenv("SOPS_AGE_KEY_FILE", oldKeyFile)
+	_, err = rotator.DecryptData(rotated, encPath)
+	assert.Error(t, err)
+
+	// A second rotation has nothing to do
+	t.Setenv("SOPS_AGE_KEY_FILE", newKeyFile)
+	again, changed, err := rotator.RotateData(rotated, encPath)
+	require.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, rotated, again)
+}
+
+func TestRotateData_ShamirThreshold(t *testing.T) {
+	manager, recipient := newAgeManager(t)
+	dir := t.TempDir()
+	decPath := filepath.Join(dir, "secrets.dec.yaml")
+	encPath := filepath.Join(dir, "secrets.enc.yaml")
+
+	writePlain(t, decPath, "secrets:\n    password: s3cret\n")
+	require.NoError(t, manager.EncryptFile(decPath, encPath))
+
+	second, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	rotator := NewManager(logger.WithName("sops-test"), "")
+	require.NoError(t, rotator.SetCreationRules([]config.SOPSCreationRule{{
+		KeyGroups: []config.SOPSKeyGroup{
+			{Age: []string{recipient}},
+			{Age: []string{second.Recipient().String()}},
+		},
+		ShamirThreshold: 2,
+		EncryptedRegex:  "^secrets$",
+	}}))
+
+	rotated, changed, err := rotator.RotateData(mustRead(t, encPath), encPath)
+	require.NoError(t, err)
+	require.True(t, changed)
+	assert.Contains(t, string(rotated), "shamir_threshold: 2")
+
+	// The first identity alone holds only one share now
+	_, err = rotator.DecryptData(rotated, encPath)
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+package sops
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/age"
+	sopsconfig "github.com/getsops/sops/v3/config"
+	"github.com/getsops/sops/v3/kms"
+	"github.com/getsops/sops/v3/pgp"
+
+	"helm-charts-migrator/v1/pkg/config"
+)
+
+// creationRule is a validated config.SOPSCreationRule
+type creationRule struct {
+	regex *regexp.Regexp
+	rule  config.SOPSCreationRule
+}
+
+// SetCreationRules makes the manager select keys from rules instead of the
+// SOPS config file. Rules are tried in order; an empty PathRegex matches any file.
+func (m *Manager) SetCreationRules(rules []config.SOPSCreationRule) error {
+	compiled := make([]creationRule, 0, len(rules))
+	for i, rule := range rules {
+		compiledRule, err := compileCreationRule(rule)
+		if err != nil {
+			return fmt.Errorf("invalid SOPS creation rule %d: %w", i, err)
+		}
+		compiled = append(compiled, compiledRule)
+	}
+	m.rules = compiled
+	return nil
+}
+
+// ruleForPaths returns the first configured rule matching one of the paths.
+// Master keys hold the data key they wrap, so every call builds fresh ones.
+func (m *Manager) ruleForPaths(paths ...string) (*sopsconfig.Config, error) {
+	for _, compiled := range m.rules {
+		for _, path := range paths {
+			if compiled.regex == nil || compiled.regex.MatchString(filepath.ToSlash(path)) {
+				return buildConfig(compiled.rule)
+			}
+		}
+	}
+	return nil, fmt.Errorf("no SOPS creation rule matches %v", paths)
+}
+
+func compileCreationRule(rule config.SOPSCreationRule) (creationRule, error) {
+	compiled := creationRule{rule: rule}
+	if rule.PathRegex != "" {
+		regex, err := regexp.Compile(rule.PathRegex)
+		if err != nil {
+			return compiled, fmt.Errorf("failed to compile path regex %q: %w", rule.PathRegex, err)
+		}
+		compiled.regex = regex
+	}
+
+	// Build the keys once up front so invalid rules fail early
+	if _, err := buildConfig(rule); err != nil {
+		return compiled, err
+	}
+	return compiled, nil
+}
+
+// buildConfig creates the SOPS creation config, with new master keys, for a rule
+func buildConfig(rule config.SOPSCreationRule) (*sopsconfig.Config, error) {
+	groups := rule.KeyGroups
+	if len(rule.KMS)+len(rule.Age)+len(rule.PGP) > 0 {
+		groups = append([]config.SOPSKeyGroup{{KMS: rule.KMS, Age: rule.Age, PGP: rule.PGP}}, groups...)
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("no kms, age or pgp keys")
+	}
+
+	keyGroups := make([]sops.KeyGroup, 0, len(groups))
+	for _, group := range groups {
+		keyGroup, err := buildKeyGroup(group)
+		if err != nil {
+			return nil, err
+		}
+		keyGroups = append(keyGroups, keyGroup)
+	}
+
+	if rule.ShamirThreshold > len(keyGroups) {
+		return nil, fmt.Errorf("shamir threshold %d exceeds the %d key groups", rule.ShamirThreshold, len(keyGroups))
+	}
+
+	return &sopsconfig.Config{
+		KeyGroups:       keyGroups,
+		ShamirThreshold: rule.ShamirThreshold,
+		EncryptedRegex:  rule.EncryptedRegex,
+	}, nil
+}
+
+// buildKeyGroup creates the master keys of a group
+func buildKeyGroup(group config.SOPSKeyGroup) (sops.KeyGroup, error) {
+	var keyGroup sops.KeyGroup
+	for _, arn := range group.KMS {
+		keyGroup = append(keyGroup, kms.NewMasterKeyFromArn(arn, nil, ""))
+	}
+	for _, recipient := range group.Age {
+		key, err := age.MasterKeyFromRecipient(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %q: %w", recipient, err)
+		}
+		keyGroup = append(keyGroup, key)
+	}
+	for _, fingerprint := range group.PGP {
+		keyGroup = append(keyGroup, pgp.NewMasterKeyFromFingerprint(fingerprint))
+	}
+	if len(keyGroup) == 0 {
+		return nil, fmt.Errorf("empty key group")
+	}
+	return keyGroup, nil
+}
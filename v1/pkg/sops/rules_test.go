@@ -0,0 +1,64 @@
+package sops
+
+import (
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm-charts-migrator/v1/pkg/config"
+	"helm-charts-migrator/v1/pkg/logger"
+)
+
+func TestSetCreationRules(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	recipient := identity.Recipient().String()
+
+	manager := NewManager(logger.WithName("sops-test"), "")
+	require.NoError(t, manager.SetCreationRules([]config.SOPSCreationRule{
+		{
+			PathRegex: `^apps/auth/`,
+			Age:       []string{recipient},
+			KMS:       []string{"arn:aws:kms:us-east-1:000000000000:key/test"},
+		},
+		{
+			KeyGroups: []config.SOPSKeyGroup{
+				{Age: []string{recipient}},
+				{PGP: []string{"85D77543B3D624B63CEA9E6DBC17301B491B3F21"}},
+			},
+			ShamirThreshold: 2,
+			EncryptedRegex:  "^secrets$",
+		},
+	}))
+
+	auth, err := manager.ruleForPaths("apps/auth/envs/dev01/secrets.dec.yaml")
+	require.NoError(t, err)
+	require.Len(t, auth.KeyGroups, 1)
+	assert.Len(t, auth.KeyGroups[0], 2)
+
+	// The catch-all rule without a path regex picks up everything else
+	other, err := manager.ruleForPaths("apps/heimdall/secrets.dec.yaml")
+	require.NoError(t, err)
+	assert.Len(t, other.KeyGroups, 2)
+	assert.Equal(t, 2, other.ShamirThreshold)
+	assert.Equal(t, "^secrets$", other.EncryptedRegex)
+}
+
+func TestSetCreationRules_Invalid(t *testing.T) {
+	manager := NewManager(logger.WithName("sops-test"), "")
+
+	tests := map[string]config.SOPSCreationRule{
+		"no keys":          {PathRegex: ".*"},
+		"bad regex":        {PathRegex: "([", Age: []string{"age1x"}},
+		"bad recipient":    {Age: []string{"not-a-recipient"}},
+		"empty key group":  {KeyGroups: []config.SOPSKeyGroup{{}}},
+		"threshold > keys": {KMS: []string{"arn:aws:kms:us-east-1:000000000000:key/test"}, ShamirThreshold: 2},
+	}
+	for name, rule := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, manager.SetCreationRules([]config.SOPSCreationRule{rule}))
+		})
+	}
+}
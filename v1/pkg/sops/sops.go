@@ -1,16 +1,18 @@
 package sops
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/getsops/sops/v3"
 	"github.com/getsops/sops/v3/aes"
 	"github.com/getsops/sops/v3/cmd/sops/common"
 	"github.com/getsops/sops/v3/config"
-	"github.com/getsops/sops/v3/decrypt"
+	"github.com/getsops/sops/v3/keyservice"
+	"github.com/getsops/sops/v3/version"
 	yaml "github.com/elioetibr/golang-yaml-advanced"
 
 	"helm-charts-migrator/v1/pkg/logger"
@@ -20,6 +22,7 @@ import (
 type Manager struct {
 	log        *logger.NamedLogger
 	configPath string
+	rules      []creationRule
 }
 
 // NewManager creates a new SOPS manager
@@ -43,24 +46,54 @@ func (m *Manager) EncryptFile(inputPath, outputPath string) error {
 		return fmt.Errorf("failed to read input file: %w", err)
 	}
 
-	// Load SOPS config
-	conf, err := config.LoadCreationRuleForFile(m.configPath, outputPath, nil)
+	encryptedData, err := m.EncryptData(data, inputPath, outputPath)
 	if err != nil {
-		// If no config found, try with input path
-		conf, err = config.LoadCreationRuleForFile(m.configPath, inputPath, nil)
-		if err != nil {
-			return fmt.Errorf("failed to load SOPS config for file: %w", err)
-		}
+		return err
+	}
+
+	if existing, err := os.ReadFile(outputPath); err == nil && bytes.Equal(existing, encryptedData) {
+		m.log.V(1).InfoS("Encrypted file unchanged", "output", outputPath)
+		return nil
+	}
+
+	// Write encrypted file
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	if err := os.WriteFile(outputPath, encryptedData, 0644); err != nil {
+		return fmt.Errorf("failed to write encrypted file: %w", err)
+	}
+
+	m.log.V(1).InfoS("File encrypted successfully", "output", outputPath)
+	return nil
+}
+
+// EncryptData encrypts plaintext read from inputPath for outputPath. The
+// creation rule is looked up for the output path first, then the input path.
+// When outputPath already holds a file encrypted with the same keys, its data
+// key and the ciphertext of unchanged values are reused.
+func (m *Manager) EncryptData(data []byte, inputPath, outputPath string) ([]byte, error) {
+	conf, err := m.creationRule(inputPath, outputPath)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get the appropriate store based on file extension
-	storeConfig := config.StoresConfig{}
-	store := common.DefaultStoreForPath(&storeConfig, outputPath)
+	store := common.DefaultStoreForPath(config.NewStoresConfig(), outputPath)
 
 	// Create branches for encryption
 	branches, err := store.LoadPlainFile(data)
 	if err != nil {
-		return fmt.Errorf("failed to load plain file: %w", err)
+		return nil, fmt.Errorf("failed to load plain file: %w", err)
+	}
+
+	if existing, err := os.ReadFile(outputPath); err == nil && IsEncrypted(existing, outputPath) {
+		encryptedData, err := m.reencrypt(existing, branches, conf, store, outputPath)
+		if !errors.Is(err, errNoReuse) {
+			return encryptedData, err
+		}
+		m.log.V(1).InfoS("Encrypting with a fresh data key", "output", outputPath, "reason", err.Error())
 	}
 
 	// Create sops.Tree for encryption
@@ -74,7 +107,7 @@ func (m *Manager) EncryptFile(inputPath, outputPath string) error {
 			UnencryptedSuffix: conf.UnencryptedSuffix,
 			EncryptedSuffix:   conf.EncryptedSuffix,
 			MACOnlyEncrypted:  conf.MACOnlyEncrypted,
-			Version:           "3.10.2",
+			Version:           version.Version,
 		},
 		FilePath: outputPath,
 	}
@@ -82,34 +115,44 @@ func (m *Manager) EncryptFile(inputPath, outputPath string) error {
 	// Generate data key
 	dataKey, errs := tree.GenerateDataKey()
 	if len(errs) > 0 {
-		return fmt.Errorf("failed to generate data key: %v", errs)
+		return nil, fmt.Errorf("failed to generate data key: %w", errors.Join(errs...))
 	}
 
-	// Encrypt the tree
-	cipher := aes.NewCipher()
-	_, err = tree.Encrypt(dataKey, cipher)
-	if err != nil {
-		return fmt.Errorf("failed to encrypt tree: %w", err)
+	// Encrypt the tree and its MAC
+	if err := common.EncryptTree(common.EncryptTreeOpts{
+		Tree:    &tree,
+		Cipher:  aes.NewCipher(),
+		DataKey: dataKey,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to encrypt tree: %w", err)
 	}
-	// Note: The MAC is handled internally by the store when emitting
 
-	// Emit encrypted file
 	encryptedData, err := store.EmitEncryptedFile(tree)
 	if err != nil {
-		return fmt.Errorf("failed to emit encrypted file: %w", err)
+		return nil, fmt.Errorf("failed to emit encrypted file: %w", err)
 	}
+	return encryptedData, nil
+}
 
-	// Write encrypted file
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+// creationRule returns the creation rule matching the output or input path,
+// from the configured rules or else the SOPS config file
+func (m *Manager) creationRule(inputPath, outputPath string) (*config.Config, error) {
+	if len(m.rules) > 0 {
+		return m.ruleForPaths(outputPath, inputPath)
 	}
 
-	if err := os.WriteFile(outputPath, encryptedData, 0644); err != nil {
-		return fmt.Errorf("failed to write encrypted file: %w", err)
+	conf, err := config.LoadCreationRuleForFile(m.configPath, outputPath, nil)
+	if err != nil || conf == nil {
+		// If no config found, try with input path
+		conf, err = config.LoadCreationRuleForFile(m.configPath, inputPath, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SOPS config for file: %w", err)
+		}
 	}
-
-	m.log.V(1).InfoS("File encrypted successfully", "output", outputPath)
-	return nil
+	if conf == nil {
+		return nil, fmt.Errorf("no SOPS creation rules found in %s", m.configPath)
+	}
+	return conf, nil
 }
 
 // DecryptFile decrypts a SOPS encrypted file
@@ -122,10 +165,9 @@ func (m *Manager) DecryptFile(inputPath, outputPath string) error {
 		return fmt.Errorf("failed to read encrypted file: %w", err)
 	}
 
-	// Decrypt the data
-	decryptedData, err := decrypt.Data(data, "yaml")
+	decryptedData, err := m.DecryptData(data, inputPath)
 	if err != nil {
-		return fmt.Errorf("failed to decrypt file: %w", err)
+		return err
 	}
 
 	// Write decrypted file
@@ -141,6 +183,69 @@ func (m *Manager) DecryptFile(inputPath, outputPath string) error {
 	return nil
 }
 
+// DecryptData decrypts the content of an encrypted file, verifying its MAC
+func (m *Manager) DecryptData(data []byte, path string) ([]byte, error) {
+	store := common.DefaultStoreForPath(config.NewStoresConfig(), path)
+
+	tree, err := store.LoadEncryptedFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encrypted file: %w", err)
+	}
+
+	if _, err := common.DecryptTree(common.DecryptTreeOpts{
+		Tree:        &tree,
+		KeyServices: []keyservice.KeyServiceClient{keyservice.NewLocalClient()},
+		Cipher:      aes.NewCipher(),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to decrypt file: %w", err)
+	}
+
+	decryptedData, err := store.EmitPlainFile(tree.Branches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to emit decrypted file: %w", err)
+	}
+	return decryptedData, nil
+}
+
+// IsEncrypted reports whether data is a SOPS document for the store of path
+func IsEncrypted(data []byte, path string) bool {
+	store := common.DefaultStoreForPath(config.NewStoresConfig(), path)
+	_, err := store.LoadEncryptedFile(data)
+	return err == nil
+}
+
+// ValidateEncrypted checks that data carries complete SOPS metadata. It does
+// not need access to the keys, so it is safe to run in CI.
+func ValidateEncrypted(data []byte, path string) error {
+	store := common.DefaultStoreForPath(config.NewStoresConfig(), path)
+
+	tree, err := store.LoadEncryptedFile(data)
+	if err != nil {
+		return fmt.Errorf("no SOPS metadata found: %w", err)
+	}
+
+	if tree.Metadata.MessageAuthenticationCode == "" {
+		return fmt.Errorf("SOPS metadata has no MAC")
+	}
+	if tree.Metadata.LastModified.IsZero() {
+		return fmt.Errorf("SOPS metadata has no lastmodified timestamp")
+	}
+	if len(tree.Metadata.KeyGroups) == 0 {
+		return fmt.Errorf("SOPS metadata has no key groups")
+	}
+	for i, group := range tree.Metadata.KeyGroups {
+		if len(group) == 0 {
+			return fmt.Errorf("SOPS key group %d is empty", i)
+		}
+		for _, key := range group {
+			if len(key.EncryptedDataKey()) == 0 {
+				return fmt.Errorf("SOPS key %s has no encrypted data key", key.ToString())
+			}
+		}
+	}
+	return nil
+}
+
 // ExtractSecretsToFile extracts secrets from a values file and saves them to a separate file
 func ExtractSecretsToFile(valuesPath, secretsPath string, log *logger.NamedLogger) error {
 	log.V(2).InfoS("Extracting secrets from values", "input", valuesPath, "output", secretsPath)
@@ -177,58 +282,9 @@ func ExtractSecretsToFile(valuesPath, secretsPath string, log *logger.NamedLogge
 		return fmt.Errorf("failed to marshal secrets: %w", err)
 	}
 
-	// Check if this is a hierarchical secrets file and determine its level
-	// Using simple string matching for KISS principle
-	secretsLevel := ""
-	if filepath.Base(secretsPath) == "secrets.dec.yaml" {
-		// Convert to forward slashes for consistent matching
-		normalizedPath := filepath.ToSlash(secretsPath)
-		// Check if path matches hierarchical patterns: apps/*/envs/*
-		// Handle both absolute and relative paths
-		if (strings.Contains(normalizedPath, "/apps/") || strings.HasPrefix(normalizedPath, "apps/")) &&
-			strings.Contains(normalizedPath, "/envs/") {
-			// Split path to check structure
-			parts := strings.Split(normalizedPath, "/")
-			// Find where "apps" starts
-			for i := 0; i < len(parts); i++ {
-				if parts[i] == "apps" && i+2 < len(parts) && parts[i+2] == "envs" {
-					// Determine the level based on path structure after "envs"
-					// Pattern variations:
-					// - apps/{service}/envs/{cluster}/secrets.dec.yaml -> cluster level (i+4 parts total)
-					// - apps/{service}/envs/{cluster}/{environment}/secrets.dec.yaml -> environment level (i+5 parts total)
-					// - apps/{service}/envs/{cluster}/{environment}/{namespace}/secrets.dec.yaml -> namespace level (i+6 parts total)
-
-					if parts[len(parts)-1] == "secrets.dec.yaml" {
-						pathLength := len(parts)
-						if pathLength == i+5 { // cluster level
-							secretsLevel = "cluster"
-						} else if pathLength == i+6 { // environment level
-							secretsLevel = "environment"
-						} else if pathLength == i+7 { // namespace level
-							secretsLevel = "namespace"
-						}
-						break
-					}
-				}
-			}
-		}
-	}
-
-	// Prepare the final data with appropriate comment based on level
-	var finalData []byte
-	switch secretsLevel {
-	case "cluster":
-		comment := "# Placeholder to cluster level secrets\n"
-		finalData = append([]byte(comment), secretsData...)
-	case "environment":
-		comment := "# Placeholder to environment level secrets\n"
-		finalData = append([]byte(comment), secretsData...)
-	case "namespace":
-		comment := "# Placeholder to namespace level secrets.\n# It can override any previous secrets as needed.\n"
-		finalData = append([]byte(comment), secretsData...)
-	default:
-		finalData = secretsData
-	}
+	// Hierarchical secrets files start with a comment describing their level
+	comment, _ := hierarchyComment(secretsPath)
+	finalData := append([]byte(comment), secretsData...)
 
 	// Create output directory
 	if err := os.MkdirAll(filepath.Dir(secretsPath), 0755); err != nil {
@@ -324,40 +380,9 @@ func AddCommentsToExistingSecrets(secretsPath string, log *logger.NamedLogger) e
 		return nil
 	}
 
-	// Determine the hierarchical level
-	secretsLevel := ""
-	if filepath.Base(secretsPath) == "secrets.dec.yaml" {
-		normalizedPath := filepath.ToSlash(secretsPath)
-		if (strings.Contains(normalizedPath, "/apps/") || strings.HasPrefix(normalizedPath, "apps/")) &&
-			strings.Contains(normalizedPath, "/envs/") {
-			parts := strings.Split(normalizedPath, "/")
-			for i := 0; i < len(parts); i++ {
-				if parts[i] == "apps" && i+2 < len(parts) && parts[i+2] == "envs" {
-					pathLength := len(parts)
-					if pathLength == i+5 {
-						secretsLevel = "cluster"
-					} else if pathLength == i+6 {
-						secretsLevel = "environment"
-					} else if pathLength == i+7 {
-						secretsLevel = "namespace"
-					}
-					break
-				}
-			}
-		}
-	}
-
-	// Prepare the comment based on level
-	var comment string
-	switch secretsLevel {
-	case "cluster":
-		comment = "# Placeholder to cluster level secrets\n"
-	case "environment":
-		comment = "# Placeholder to environment level secrets\n"
-	case "namespace":
-		comment = "# Placeholder to namespace level secrets.\n# It can override any previous secrets as needed.\n"
-	default:
-		// No comment for non-hierarchical files
+	// Non-hierarchical files get no comment
+	comment, secretsLevel := hierarchyComment(secretsPath)
+	if comment == "" {
 		return nil
 	}
 
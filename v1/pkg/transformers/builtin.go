@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"helm-charts-migrator/v1/pkg/config"
 	"helm-charts-migrator/v1/pkg/keycase"
 	"helm-charts-migrator/v1/pkg/logger"
 )
@@ -27,12 +28,31 @@ func NewCamelCaseTransformer(skipJavaProperties, skipUppercase bool, minUppercas
 	}
 }
 
+// NewCamelCaseTransformerFromConfig creates a key case transformer from
+// converter settings, including the target style, preserved keys and skipped paths
+func NewCamelCaseTransformerFromConfig(cfg config.ConverterConfig) (*CamelCaseTransformer, error) {
+	converter, err := keycase.NewFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid converter configuration: %w", err)
+	}
+
+	return &CamelCaseTransformer{
+		converter: converter,
+		log:       logger.WithName("camelcase-transformer"),
+	}, nil
+}
+
+// SetCollisionPolicy sets how keys converting to the same key are resolved
+func (t *CamelCaseTransformer) SetCollisionPolicy(policy keycase.CollisionPolicy) {
+	t.converter.CollisionPolicy = policy
+}
+
 func (t *CamelCaseTransformer) Name() string {
 	return "camelCase"
 }
 
 func (t *CamelCaseTransformer) Description() string {
-	return "Converts map keys from snake_case to camelCase"
+	return fmt.Sprintf("Converts map keys from snake_case to %s", t.style())
 }
 
 func (t *CamelCaseTransformer) Priority() int {
@@ -52,12 +72,26 @@ func (t *CamelCaseTransformer) Transform(data interface{}) (interface{}, error)
 		return nil, fmt.Errorf("invalid input type: %T", data)
 	}
 	
-	result := t.converter.ConvertMap(input)
-	t.log.V(3).InfoS("Converted keys to camelCase", "keyCount", len(result))
+	result, renames, err := t.converter.Convert(input)
+	if err != nil {
+		return nil, err
+	}
+	t.log.V(3).InfoS("Converted keys",
+		"style", t.style(),
+		"renamed", len(renames.Renames),
+		"collisions", len(renames.Collisions))
 	
 	return result, nil
 }
 
+// style returns the style keys are converted to
+func (t *CamelCaseTransformer) style() keycase.Style {
+	if t.converter.TargetStyle == "" {
+		return keycase.StyleCamel
+	}
+	return t.converter.TargetStyle
+}
+
 // KeyNormalizerTransformer normalizes key names based on patterns
 type KeyNormalizerTransformer struct {
 	patterns map[string]string // oldPattern -> newPattern
@@ -37,12 +37,10 @@ func (f *TransformerFactory) GetRegistry() *TransformerRegistry {
 func (f *TransformerFactory) registerBuiltinTransformers() {
 	// Register CamelCase transformer
 	if f.config != nil && f.config.Globals.Converter.MinUppercaseChars > 0 {
-		camelCase := NewCamelCaseTransformer(
-			f.config.Globals.Converter.SkipJavaProperties,
-			f.config.Globals.Converter.SkipUppercaseKeys,
-			f.config.Globals.Converter.MinUppercaseChars,
-		)
-		if err := f.registry.Register(camelCase); err != nil {
+		camelCase, err := NewCamelCaseTransformerFromConfig(f.config.Globals.Converter)
+		if err != nil {
+			f.log.Error(err, "Failed to create camelCase transformer")
+		} else if err := f.registry.Register(camelCase); err != nil {
 			f.log.Error(err, "Failed to register camelCase transformer")
 		}
 	}
@@ -96,22 +94,46 @@ func (f *TransformerFactory) CreateTransformer(name string, config map[string]in
 }
 
 // createCamelCaseTransformer creates a configured camelCase transformer
-func (f *TransformerFactory) createCamelCaseTransformer(config map[string]interface{}) (Transformer, error) {
-	skipJava := false
-	skipUpper := false
-	minUpper := 3
+func (f *TransformerFactory) createCamelCaseTransformer(settings map[string]interface{}) (Transformer, error) {
+	converterConfig := config.ConverterConfig{MinUppercaseChars: 3}
 	
-	if v, ok := config["skipJavaProperties"].(bool); ok {
-		skipJava = v
+	if v, ok := settings["skipJavaProperties"].(bool); ok {
+		converterConfig.SkipJavaProperties = v
+	}
+	if v, ok := settings["skipUppercaseKeys"].(bool); ok {
+		converterConfig.SkipUppercaseKeys = v
+	}
+	if v, ok := settings["minUppercaseChars"].(int); ok {
+		converterConfig.MinUppercaseChars = v
 	}
-	if v, ok := config["skipUppercaseKeys"].(bool); ok {
-		skipUpper = v
+	if v, ok := settings["collisionPolicy"].(string); ok {
+		converterConfig.CollisionPolicy = v
 	}
-	if v, ok := config["minUppercaseChars"].(int); ok {
-		minUpper = v
+	if v, ok := settings["targetStyle"].(string); ok {
+		converterConfig.TargetStyle = v
 	}
+	converterConfig.PreserveKeys = stringList(settings["preserveKeys"])
+	converterConfig.SkipPaths = stringList(settings["skipPaths"])
 	
-	return NewCamelCaseTransformer(skipJava, skipUpper, minUpper), nil
+	return NewCamelCaseTransformerFromConfig(converterConfig)
+}
+
+// stringList reads a list of strings from transformer configuration
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
 }
 
 // createKeyNormalizer creates a configured key normalizer
@@ -258,9 +280,22 @@ func (f *TransformerFactory) ApplyServiceTransformers(serviceName string, data i
 	result := data
 	var err error
 	
+	// Keys are converted with the service's converter settings when it has any
+	var camelCase *CamelCaseTransformer
+	if service.Converter != nil {
+		camelCase, err = NewCamelCaseTransformerFromConfig(f.config.GetConverterConfig(serviceName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create camelCase transformer for service %s: %w", serviceName, err)
+		}
+	}
+	
 	// Apply transformers in priority order
 	for _, name := range f.registry.ListByPriority() {
-		result, err = f.registry.Apply(name, result)
+		if camelCase != nil && name == camelCase.Name() {
+			result, err = applyTransformer(camelCase, result)
+		} else {
+			result, err = f.registry.Apply(name, result)
+		}
 		if err != nil {
 			f.log.V(3).InfoS("Transformer skipped", 
 				"service", serviceName,
@@ -276,4 +311,17 @@ func (f *TransformerFactory) ApplyServiceTransformers(serviceName string, data i
 		"transformerCount", f.registry.Count())
 	
 	return result, nil
-}
\ No newline at end of file
+}
+// applyTransformer validates and transforms data with a transformer that is not
+// in the registry, as TransformerRegistry.Apply does
+func applyTransformer(transformer Transformer, data interface{}) (interface{}, error) {
+	if err := transformer.Validate(data); err != nil {
+		return nil, fmt.Errorf("validation failed for transformer %s: %w", transformer.Name(), err)
+	}
+
+	result, err := transformer.Transform(data)
+	if err != nil {
+		return nil, fmt.Errorf("transformation %s failed: %w", transformer.Name(), err)
+	}
+	return result, nil
+}
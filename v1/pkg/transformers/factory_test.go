@@ -0,0 +1,81 @@
+package transformers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"helm-charts-migrator/v1/pkg/config"
+)
+
+func TestApplyServiceTransformers_ConverterOverride(t *testing.T) {
+	cfg := &config.Config{
+		Globals: config.Globals{
+			Converter: config.ConverterConfig{
+				MinUppercaseChars: 3,
+				SkipPaths:         []string{"configMap.*"},
+			},
+		},
+		Services: map[string]config.Service{
+			"heimdall": {
+				Enabled: true,
+				Converter: &config.ConverterConfig{
+					TargetStyle:  "kebab-case",
+					PreserveKeys: []string{"keep_me"},
+				},
+			},
+			"livecomments": {Enabled: true},
+		},
+	}
+	factory := NewTransformerFactory(cfg)
+
+	values := func() map[string]interface{} {
+		return map[string]interface{}{
+			"image_tag": "latest",
+			"keep_me":   true,
+			"config_map": map[string]interface{}{
+				"feature_flag": "on",
+			},
+		}
+	}
+
+	// The defaults transformer adds keys of its own, so only the given ones are compared
+	result, err := factory.ApplyServiceTransformers("heimdall", values())
+	require.NoError(t, err)
+	assert.Subset(t, result, map[string]interface{}{
+		"image-tag": "latest",
+		"keep_me":   true,
+		"config-map": map[string]interface{}{
+			"feature-flag": "on",
+		},
+	}, result)
+
+	result, err = factory.ApplyServiceTransformers("livecomments", values())
+	require.NoError(t, err)
+	assert.Subset(t, result, map[string]interface{}{
+		"imageTag": "latest",
+		"keepMe":   true,
+		"configMap": map[string]interface{}{
+			"feature_flag": "on",
+		},
+	}, result)
+}
+
+func TestCreateTransformer_CamelCaseSettings(t *testing.T) {
+	factory := NewTransformerFactory(nil)
+
+	transformer, err := factory.CreateTransformer("camelCase", map[string]interface{}{
+		"targetStyle":  "PascalCase",
+		"preserveKeys": []interface{}{"keep_me"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Converts map keys from snake_case to PascalCase", transformer.Description())
+
+	result, err := transformer.Transform(map[string]interface{}{"image_tag": "latest", "keep_me": 1})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ImageTag": "latest", "keep_me": 1}, result)
+
+	_, err = factory.CreateTransformer("camelCase", map[string]interface{}{"targetStyle": "upper"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,242 @@
+package workers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"helm-charts-migrator/v1/pkg/logger"
+)
+
+// ErrCircuitOpen is returned (wrapped in a CircuitOpenError) when a task is
+// short-circuited because its dependency has failed too often
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState represents the state of a circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets every call through and counts consecutive failures
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every call until the cooldown has elapsed
+	CircuitOpen
+	// CircuitHalfOpen lets a limited number of probe calls through
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig defines when a dependency circuit opens and recovers
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit (0 disables)
+	FailureThreshold int `yaml:"failure_threshold" json:"failure_threshold"`
+	// Cooldown is how long the circuit stays open before probing again
+	Cooldown time.Duration `yaml:"cooldown" json:"cooldown"`
+	// HalfOpenMaxCalls is the number of concurrent probe calls allowed while half-open
+	HalfOpenMaxCalls int `yaml:"half_open_max_calls" json:"half_open_max_calls"`
+}
+
+// DefaultCircuitBreakerConfig returns a sensible default circuit breaker configuration
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureThreshold: 5,
+		Cooldown:         30 * time.Second,
+		HalfOpenMaxCalls: 1,
+	}
+}
+
+// CircuitOpenError indicates that a call was rejected by an open circuit
+type CircuitOpenError struct {
+	Dependency string
+	Failures   int
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker open for dependency %q after %d consecutive failures (retry in %v)",
+		e.Dependency, e.Failures, e.RetryAfter.Round(time.Millisecond))
+}
+
+// Is allows errors.Is(err, ErrCircuitOpen)
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitBreaker tracks failures for a single dependency
+type CircuitBreaker struct {
+	dependency string
+	config     CircuitBreakerConfig
+	mu         sync.Mutex
+	state      CircuitState
+	failures   int
+	openedAt   time.Time
+	probes     int
+	now        func() time.Time
+	log        *logger.NamedLogger
+}
+
+// NewCircuitBreaker creates a circuit breaker for the given dependency
+func NewCircuitBreaker(dependency string, cfg CircuitBreakerConfig) *CircuitBreaker {
+	if cfg.HalfOpenMaxCalls <= 0 {
+		cfg.HalfOpenMaxCalls = 1
+	}
+	return &CircuitBreaker{
+		dependency: dependency,
+		config:     cfg,
+		state:      CircuitClosed,
+		now:        time.Now,
+		log:        logger.WithName("circuit-breaker"),
+	}
+}
+
+// Allow reports whether a call may proceed; it returns a CircuitOpenError otherwise
+func (cb *CircuitBreaker) Allow() error {
+	if cb.config.FailureThreshold <= 0 {
+		return nil
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case CircuitOpen:
+		elapsed := cb.now().Sub(cb.openedAt)
+		if elapsed < cb.config.Cooldown {
+			return &CircuitOpenError{
+				Dependency: cb.dependency,
+				Failures:   cb.failures,
+				RetryAfter: cb.config.Cooldown - elapsed,
+			}
+		}
+		cb.transition(CircuitHalfOpen)
+		cb.probes = 1
+		return nil
+	case CircuitHalfOpen:
+		if cb.probes >= cb.config.HalfOpenMaxCalls {
+			return &CircuitOpenError{
+				Dependency: cb.dependency,
+				Failures:   cb.failures,
+			}
+		}
+		cb.probes++
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Release gives back a probe taken by Allow for a call that was never made,
+// so a half-open circuit keeps probing
+func (cb *CircuitBreaker) Release() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitHalfOpen && cb.probes > 0 {
+		cb.probes--
+	}
+}
+
+// RecordSuccess records a successful call and closes the circuit
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures = 0
+	cb.probes = 0
+	if cb.state != CircuitClosed {
+		cb.transition(CircuitClosed)
+	}
+}
+
+// RecordFailure records a failed call, opening the circuit once the threshold is reached
+func (cb *CircuitBreaker) RecordFailure() {
+	if cb.config.FailureThreshold <= 0 {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+	switch cb.state {
+	case CircuitHalfOpen:
+		// A failed probe re-opens the circuit for another cooldown
+		cb.openedAt = cb.now()
+		cb.probes = 0
+		cb.transition(CircuitOpen)
+	case CircuitClosed:
+		if cb.failures >= cb.config.FailureThreshold {
+			cb.openedAt = cb.now()
+			cb.transition(CircuitOpen)
+		}
+	}
+}
+
+// State returns the current state of the circuit
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// transition changes state; callers must hold the lock
+func (cb *CircuitBreaker) transition(to CircuitState) {
+	cb.log.InfoS("Circuit breaker state changed",
+		"dependency", cb.dependency,
+		"from", cb.state.String(),
+		"to", to.String(),
+		"failures", cb.failures)
+	cb.state = to
+}
+
+// CircuitBreakerRegistry holds one circuit breaker per dependency key
+type CircuitBreakerRegistry struct {
+	config   CircuitBreakerConfig
+	mu       sync.Mutex
+	breakers map[string]*CircuitBreaker
+}
+
+// NewCircuitBreakerRegistry creates a registry that lazily creates breakers with cfg
+func NewCircuitBreakerRegistry(cfg CircuitBreakerConfig) *CircuitBreakerRegistry {
+	return &CircuitBreakerRegistry{
+		config:   cfg,
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Get returns the circuit breaker for a dependency, creating it if needed
+func (r *CircuitBreakerRegistry) Get(dependency string) *CircuitBreaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cb, exists := r.breakers[dependency]
+	if !exists {
+		cb = NewCircuitBreaker(dependency, r.config)
+		r.breakers[dependency] = cb
+	}
+	return cb
+}
+
+// States returns the current state of every known dependency circuit
+func (r *CircuitBreakerRegistry) States() map[string]CircuitState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	states := make(map[string]CircuitState, len(r.breakers))
+	for dependency, cb := range r.breakers {
+		states[dependency] = cb.State()
+	}
+	return states
+}
@@ -0,0 +1,162 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dependencyTask is a test task bound to a dependency
+type dependencyTask struct {
+	id         string
+	dependency string
+	calls      atomic.Int32
+	err        error
+}
+
+func (dt *dependencyTask) ID() string         { return dt.id }
+func (dt *dependencyTask) Priority() int      { return 0 }
+func (dt *dependencyTask) Dependency() string { return dt.dependency }
+
+func (dt *dependencyTask) Execute(ctx context.Context) error {
+	dt.calls.Add(1)
+	return dt.err
+}
+
+func TestCircuitBreaker_StateTransitions(t *testing.T) {
+	now := time.Now()
+	cb := NewCircuitBreaker("kube:dev01", CircuitBreakerConfig{
+		FailureThreshold: 2,
+		Cooldown:         time.Minute,
+	})
+	cb.now = func() time.Time { return now }
+
+	require.NoError(t, cb.Allow())
+	cb.RecordFailure()
+	assert.Equal(t, CircuitClosed, cb.State())
+
+	cb.RecordFailure()
+	assert.Equal(t, CircuitOpen, cb.State())
+
+	err := cb.Allow()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	var openErr *CircuitOpenError
+	require.True(t, errors.As(err, &openErr))
+	assert.Equal(t, "kube:dev01", openErr.Dependency)
+	assert.Equal(t, time.Minute, openErr.RetryAfter)
+
+	// After the cooldown a single probe is allowed
+	now = now.Add(time.Minute)
+	require.NoError(t, cb.Allow())
+	assert.Equal(t, CircuitHalfOpen, cb.State())
+	assert.ErrorIs(t, cb.Allow(), ErrCircuitOpen)
+
+	// A failed probe re-opens the circuit
+	cb.RecordFailure()
+	assert.Equal(t, CircuitOpen, cb.State())
+
+	// A successful probe closes it again
+	now = now.Add(time.Minute)
+	require.NoError(t, cb.Allow())
+	cb.RecordSuccess()
+	assert.Equal(t, CircuitClosed, cb.State())
+	assert.NoError(t, cb.Allow())
+}
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	cb := NewCircuitBreaker("aws:default", CircuitBreakerConfig{})
+	for i := 0; i < 10; i++ {
+		cb.RecordFailure()
+	}
+	assert.NoError(t, cb.Allow())
+	assert.Equal(t, CircuitClosed, cb.State())
+}
+
+func TestRetryableTask_CircuitBreakerShortCircuits(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = 1
+	policy.CircuitBreaker = CircuitBreakerConfig{FailureThreshold: 2, Cooldown: time.Hour}
+	breakers := NewCircuitBreakerRegistry(policy.CircuitBreaker)
+
+	failing := &dependencyTask{
+		id:         "svc-dev01",
+		dependency: KubeDependency("dev01"),
+		err:        fmt.Errorf("dial tcp: %w", syscall.ECONNREFUSED),
+	}
+
+	for i := 0; i < 2; i++ {
+		rt := NewRetryableTask(failing, policy)
+		rt.breakers = breakers
+		assert.Error(t, rt.Execute(context.Background()))
+	}
+	assert.Equal(t, int32(2), failing.calls.Load())
+
+	// The circuit is now open: the task must not be executed again
+	rt := NewRetryableTask(failing, policy)
+	rt.breakers = breakers
+	err := rt.Execute(context.Background())
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int32(2), failing.calls.Load())
+
+	// Other dependencies are unaffected
+	other := &dependencyTask{id: "svc-prod01", dependency: KubeDependency("prod01")}
+	rt = NewRetryableTask(other, policy)
+	rt.breakers = breakers
+	assert.NoError(t, rt.Execute(context.Background()))
+	assert.Equal(t, CircuitOpen, breakers.States()[KubeDependency("dev01")])
+	assert.Equal(t, CircuitClosed, breakers.States()[KubeDependency("prod01")])
+}
+
+func TestRetryableTask_NonRetryableErrorDoesNotTrip(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.CircuitBreaker = CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Hour}
+	breakers := NewCircuitBreakerRegistry(policy.CircuitBreaker)
+
+	task := &dependencyTask{
+		id:         "svc-dev01",
+		dependency: KubeDependency("dev01"),
+		err:        fmt.Errorf("invalid values file"),
+	}
+	rt := NewRetryableTask(task, policy)
+	rt.breakers = breakers
+	assert.Error(t, rt.Execute(context.Background()))
+	assert.Equal(t, CircuitClosed, breakers.Get(KubeDependency("dev01")).State())
+}
+
+func TestRetryableTask_CancelledWaitReleasesProbe(t *testing.T) {
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = 1
+	policy.CircuitBreaker = CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Minute}
+	breakers := NewCircuitBreakerRegistry(policy.CircuitBreaker)
+	limiters := NewRateLimiterRegistry(RateLimitConfig{RequestsPerSecond: 0.001, Burst: 1})
+
+	now := time.Now()
+	breaker := breakers.Get(KubeDependency("dev01"))
+	breaker.now = func() time.Time { return now }
+	breaker.RecordFailure()
+	require.Equal(t, CircuitOpen, breaker.State())
+	now = now.Add(time.Minute)
+
+	// The only token is gone, so the probe waits for the limiter until cancelled
+	require.True(t, limiters.Get(KubeDependency("dev01")).Allow())
+	task := &dependencyTask{id: "svc-dev01", dependency: KubeDependency("dev01")}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rt := NewRetryableTask(task, policy)
+	rt.breakers = breakers
+	rt.limiters = limiters
+	assert.ErrorIs(t, rt.Execute(ctx), context.Canceled)
+	assert.Equal(t, int32(0), task.calls.Load())
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+
+	// The probe is free again
+	assert.NoError(t, breaker.Allow())
+}
@@ -350,8 +350,9 @@ func (p *WorkerPool) processTask(workerID int, task Task) {
 		"taskID", taskID,
 		"priority", task.Priority())
 	
-	// Execute task with context
-	err := task.Execute(p.ctx)
+	// Execute task with a context that tags log lines with the task and worker
+	taskCtx := logger.NewContext(p.ctx, logger.FieldTask, taskID, logger.FieldWorker, workerID)
+	err := task.Execute(taskCtx)
 	duration := time.Since(start)
 	
 	result := Result{
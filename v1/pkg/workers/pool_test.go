@@ -2,13 +2,17 @@ package workers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"helm-charts-migrator/v1/pkg/errkind"
 )
 
 // SimpleTask for testing
@@ -438,4 +442,19 @@ func TestMetrics_Reset(t *testing.T) {
 	assert.Equal(t, int64(0), snapshot.CompletedTasks)
 	assert.Equal(t, int64(0), snapshot.FailedTasks)
 	assert.Equal(t, time.Duration(0), snapshot.TotalDuration)
-}
\ No newline at end of file
+}
+func TestRetryableTask_IsRetryableError(t *testing.T) {
+	rt := NewRetryableTask(&SimpleTask{id: "classify"}, DefaultRetryPolicy())
+
+	assert.True(t, rt.isRetryableError(fmt.Errorf("dial: %w", syscall.ECONNREFUSED)))
+	assert.True(t, rt.isRetryableError(errkind.New(errkind.KMSThrottling, "rate exceeded")))
+	assert.True(t, rt.isRetryableError(fmt.Errorf("call: %w", context.DeadlineExceeded)))
+	assert.False(t, rt.isRetryableError(errkind.New(errkind.Validation, "invalid values")))
+	assert.False(t, rt.isRetryableError(fmt.Errorf("stopped: %w", context.Canceled)))
+	assert.False(t, rt.isRetryableError(errors.New("some other failure")))
+
+	// Message patterns only apply to unclassified errors
+	rt.Policy.RetryableErrors = append(rt.Policy.RetryableErrors, "try again")
+	assert.True(t, rt.isRetryableError(errors.New("server busy, try again")))
+	assert.False(t, rt.isRetryableError(errkind.New(errkind.Validation, "try again")))
+}
@@ -0,0 +1,124 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitConfig defines a token bucket applied per dependency
+type RateLimitConfig struct {
+	// RequestsPerSecond is the sustained rate of calls (0 disables rate limiting)
+	RequestsPerSecond float64 `yaml:"requests_per_second" json:"requests_per_second"`
+	// Burst is the maximum number of calls allowed at once
+	Burst int `yaml:"burst" json:"burst"`
+}
+
+// TokenBucket is a simple token bucket rate limiter
+type TokenBucket struct {
+	rate   float64
+	burst  float64
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewTokenBucket creates a token bucket that starts full
+func NewTokenBucket(cfg RateLimitConfig) *TokenBucket {
+	burst := float64(cfg.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   cfg.RequestsPerSecond,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+		now:    time.Now,
+	}
+}
+
+// Allow takes a token if one is available without waiting
+func (tb *TokenBucket) Allow() bool {
+	if tb.rate <= 0 {
+		return true
+	}
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.refill()
+	if tb.tokens >= 1 {
+		tb.tokens--
+		return true
+	}
+	return false
+}
+
+// Wait blocks until a token is available or the context is done
+func (tb *TokenBucket) Wait(ctx context.Context) error {
+	if tb.rate <= 0 {
+		return nil
+	}
+
+	for {
+		tb.mu.Lock()
+		tb.refill()
+		if tb.tokens >= 1 {
+			tb.tokens--
+			tb.mu.Unlock()
+			return nil
+		}
+		missing := 1 - tb.tokens
+		delay := time.Duration(math.Ceil(missing / tb.rate * float64(time.Second)))
+		tb.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("rate limiter wait cancelled: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+// refill adds tokens for the time elapsed since the last refill; callers must hold the lock
+func (tb *TokenBucket) refill() {
+	now := tb.now()
+	elapsed := now.Sub(tb.last).Seconds()
+	tb.last = now
+	if elapsed <= 0 {
+		return
+	}
+	tb.tokens = math.Min(tb.burst, tb.tokens+elapsed*tb.rate)
+}
+
+// RateLimiterRegistry holds one token bucket per dependency key
+type RateLimiterRegistry struct {
+	config   RateLimitConfig
+	mu       sync.Mutex
+	limiters map[string]*TokenBucket
+}
+
+// NewRateLimiterRegistry creates a registry that lazily creates buckets with cfg
+func NewRateLimiterRegistry(cfg RateLimitConfig) *RateLimiterRegistry {
+	return &RateLimiterRegistry{
+		config:   cfg,
+		limiters: make(map[string]*TokenBucket),
+	}
+}
+
+// Get returns the token bucket for a dependency, creating it if needed
+func (r *RateLimiterRegistry) Get(dependency string) *TokenBucket {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tb, exists := r.limiters[dependency]
+	if !exists {
+		tb = NewTokenBucket(r.config)
+		r.limiters[dependency] = tb
+	}
+	return tb
+}
@@ -0,0 +1,57 @@
+package workers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket_AllowAndRefill(t *testing.T) {
+	now := time.Now()
+	tb := NewTokenBucket(RateLimitConfig{RequestsPerSecond: 2, Burst: 2})
+	tb.now = func() time.Time { return now }
+	tb.last = now
+
+	assert.True(t, tb.Allow())
+	assert.True(t, tb.Allow())
+	assert.False(t, tb.Allow())
+
+	now = now.Add(500 * time.Millisecond)
+	assert.True(t, tb.Allow())
+	assert.False(t, tb.Allow())
+
+	// Tokens never exceed the burst size
+	now = now.Add(10 * time.Second)
+	assert.True(t, tb.Allow())
+	assert.True(t, tb.Allow())
+	assert.False(t, tb.Allow())
+}
+
+func TestTokenBucket_Unlimited(t *testing.T) {
+	tb := NewTokenBucket(RateLimitConfig{})
+	for i := 0; i < 100; i++ {
+		assert.True(t, tb.Allow())
+	}
+	assert.NoError(t, tb.Wait(context.Background()))
+}
+
+func TestTokenBucket_WaitHonoursContext(t *testing.T) {
+	tb := NewTokenBucket(RateLimitConfig{RequestsPerSecond: 0.1, Burst: 1})
+	assert.True(t, tb.Allow())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := tb.Wait(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRateLimiterRegistry_PerDependency(t *testing.T) {
+	registry := NewRateLimiterRegistry(RateLimitConfig{RequestsPerSecond: 1, Burst: 1})
+
+	assert.Same(t, registry.Get(AWSDependency("prod")), registry.Get(AWSDependency("prod")))
+	assert.True(t, registry.Get(AWSDependency("prod")).Allow())
+	assert.False(t, registry.Get(AWSDependency("prod")).Allow())
+	assert.True(t, registry.Get(AWSDependency("dev")).Allow())
+}
@@ -2,11 +2,14 @@ package workers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
+	"strings"
 	"time"
 
+	"helm-charts-migrator/v1/pkg/errkind"
 	"helm-charts-migrator/v1/pkg/logger"
 )
 
@@ -17,7 +20,10 @@ type RetryPolicy struct {
 	MaxBackoff       time.Duration `yaml:"max_backoff" json:"max_backoff"`
 	BackoffMultiplier float64       `yaml:"backoff_multiplier" json:"backoff_multiplier"`
 	JitterPercent    float64       `yaml:"jitter_percent" json:"jitter_percent"`
+	RetryableKinds   []errkind.Kind `yaml:"retryable_kinds" json:"retryable_kinds"`
 	RetryableErrors  []string      `yaml:"retryable_errors" json:"retryable_errors"`
+	CircuitBreaker   CircuitBreakerConfig `yaml:"circuit_breaker" json:"circuit_breaker"`
+	RateLimit        RateLimitConfig      `yaml:"rate_limit" json:"rate_limit"`
 }
 
 // DefaultRetryPolicy returns a sensible default retry policy
@@ -28,16 +34,46 @@ func DefaultRetryPolicy() RetryPolicy {
 		MaxBackoff:        30 * time.Second,
 		BackoffMultiplier: 2.0,
 		JitterPercent:     0.1,
+		RetryableKinds: []errkind.Kind{
+			errkind.TransientNetwork,
+			errkind.KubernetesThrottling,
+			errkind.KMSThrottling,
+		},
 		RetryableErrors: []string{
 			"*net.OpError",
-			"*url.Error", 
-			"*errors.timeout",
-			"context deadline exceeded",
-			"connection refused",
-			"no such host",
-			"temporary failure",
+			"*net.DNSError",
+			"*url.Error",
 		},
+		CircuitBreaker: DefaultCircuitBreakerConfig(),
+	}
+}
+
+// DependencyTask is implemented by tasks that talk to an external dependency
+// (a kube context, an AWS profile or KMS key). Tasks sharing a dependency key
+// share a circuit breaker and a rate limiter in the RetryableWorkerPool.
+type DependencyTask interface {
+	Task
+
+	// Dependency returns the dependency key, or an empty string for none
+	Dependency() string
+}
+
+// KubeDependency returns the dependency key for a kube context
+func KubeDependency(kubeContext string) string {
+	return "kube:" + kubeContext
+}
+
+// AWSDependency returns the dependency key for an AWS profile or KMS key
+func AWSDependency(profileOrKey string) string {
+	return "aws:" + profileOrKey
+}
+
+// taskDependency returns the dependency key of a task, if it declares one
+func taskDependency(task Task) string {
+	if dt, ok := task.(DependencyTask); ok {
+		return dt.Dependency()
 	}
+	return ""
 }
 
 // RetryableTask wraps a Task with retry capabilities
@@ -47,6 +83,8 @@ type RetryableTask struct {
 	attempt      int
 	lastError    error
 	totalBackoff time.Duration
+	breakers     *CircuitBreakerRegistry
+	limiters     *RateLimiterRegistry
 	log          *logger.NamedLogger
 }
 
@@ -80,15 +118,42 @@ func (rt *RetryableTask) Priority() int {
 
 // Execute executes the task with retry logic
 func (rt *RetryableTask) Execute(ctx context.Context) error {
+	log := rt.log.WithContext(ctx)
+	
 	// Check if we've exceeded max attempts
 	if rt.attempt >= rt.Policy.MaxAttempts {
 		return fmt.Errorf("task %s failed after %d attempts: %w", 
 			rt.Task.ID(), rt.attempt, rt.lastError)
 	}
 	
+	// Short-circuit and throttle calls to the task's dependency
+	var breaker *CircuitBreaker
+	if dependency := taskDependency(rt.Task); dependency != "" {
+		if rt.breakers != nil {
+			breaker = rt.breakers.Get(dependency)
+			if err := breaker.Allow(); err != nil {
+				rt.lastError = err
+				log.V(2).InfoS("Task short-circuited",
+					"taskID", rt.Task.ID(),
+					"dependency", dependency,
+					"error", err)
+				return err
+			}
+		}
+		if rt.limiters != nil {
+			if err := rt.limiters.Get(dependency).Wait(ctx); err != nil {
+				// The call is not made, so its probe is free for the next one
+				if breaker != nil {
+					breaker.Release()
+				}
+				return err
+			}
+		}
+	}
+	
 	rt.attempt++
 	
-	rt.log.V(3).InfoS("Executing task", 
+	log.V(3).InfoS("Executing task", 
 		"taskID", rt.Task.ID(),
 		"attempt", rt.attempt,
 		"maxAttempts", rt.Policy.MaxAttempts)
@@ -98,8 +163,11 @@ func (rt *RetryableTask) Execute(ctx context.Context) error {
 	
 	// If successful or not retryable, return immediately
 	if err == nil {
+		if breaker != nil {
+			breaker.RecordSuccess()
+		}
 		if rt.attempt > 1 {
-			rt.log.InfoS("Task succeeded after retry",
+			log.InfoS("Task succeeded after retry",
 				"taskID", rt.Task.ID(),
 				"attempt", rt.attempt,
 				"totalBackoff", rt.totalBackoff)
@@ -111,16 +179,25 @@ func (rt *RetryableTask) Execute(ctx context.Context) error {
 	
 	// Check if error is retryable
 	if !rt.isRetryableError(err) {
-		rt.log.V(2).InfoS("Task failed with non-retryable error",
+		// The dependency answered, so it counts as healthy for the circuit
+		if breaker != nil {
+			breaker.RecordSuccess()
+		}
+		log.V(2).InfoS("Task failed with non-retryable error",
 			"taskID", rt.Task.ID(),
 			"attempt", rt.attempt,
 			"error", err)
 		return err
 	}
 	
+	// Only retryable (dependency) failures count against the circuit
+	if breaker != nil {
+		breaker.RecordFailure()
+	}
+	
 	// Check if we have more attempts
 	if rt.attempt >= rt.Policy.MaxAttempts {
-		rt.log.InfoS("Task failed after all retry attempts",
+		log.InfoS("Task failed after all retry attempts",
 			"taskID", rt.Task.ID(),
 			"attempts", rt.attempt,
 			"finalError", err)
@@ -131,7 +208,7 @@ func (rt *RetryableTask) Execute(ctx context.Context) error {
 	backoff := rt.calculateBackoff()
 	rt.totalBackoff += backoff
 	
-	rt.log.InfoS("Task failed, will retry",
+	log.InfoS("Task failed, will retry",
 		"taskID", rt.Task.ID(),
 		"attempt", rt.attempt,
 		"nextAttempt", rt.attempt+1,
@@ -154,34 +231,38 @@ func (rt *RetryableTask) Execute(ctx context.Context) error {
 	}
 }
 
-// isRetryableError checks if an error should trigger a retry
+// isRetryableError checks if an error should trigger a retry. The error is
+// classified with errkind; RetryableErrors lists extra error type names
+// (e.g. "*net.OpError") or message fragments for errors outside the taxonomy.
 func (rt *RetryableTask) isRetryableError(err error) bool {
-	if err == nil {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	
+	kind := errkind.Classify(err)
+	for _, retryable := range rt.Policy.RetryableKinds {
+		if kind == retryable {
+			return true
+		}
+	}
+	
+	// Explicitly classified errors are never overridden by patterns
+	if kind != errkind.Unknown {
 		return false
 	}
 	
 	errorStr := err.Error()
 	errorType := fmt.Sprintf("%T", err)
-	
-	// Check against configured retryable errors
 	for _, pattern := range rt.Policy.RetryableErrors {
 		if pattern == errorType {
 			return true
 		}
-		// Simple string contains check for error messages
-		if len(pattern) > 0 && pattern[0] != '*' {
-			if contains(errorStr, pattern) {
-				return true
-			}
+		if !strings.HasPrefix(pattern, "*") && strings.Contains(errorStr, pattern) {
+			return true
 		}
 	}
 	
-	// Default retryable conditions
-	return contains(errorStr, "timeout") ||
-		contains(errorStr, "connection refused") ||
-		contains(errorStr, "no such host") ||
-		contains(errorStr, "temporary failure") ||
-		contains(errorStr, "context deadline exceeded")
+	return false
 }
 
 // calculateBackoff calculates the next backoff duration
@@ -263,6 +344,8 @@ type RetryableWorkerPool struct {
 	retryPolicy RetryPolicy
 	metrics     *Metrics
 	retryQueue  chan *RetryableTask
+	breakers    *CircuitBreakerRegistry
+	limiters    *RateLimiterRegistry
 	log         *logger.NamedLogger
 }
 
@@ -276,6 +359,8 @@ func NewRetryableWorkerPool(workers int, policy RetryPolicy) *RetryableWorkerPoo
 		retryPolicy: policy,
 		metrics:     metrics,
 		retryQueue:  make(chan *RetryableTask, workers*2),
+		breakers:    NewCircuitBreakerRegistry(policy.CircuitBreaker),
+		limiters:    NewRateLimiterRegistry(policy.RateLimit),
 		log:         logger.WithName("retryable-worker-pool"),
 	}
 }
@@ -317,7 +402,7 @@ func (rwp *RetryableWorkerPool) Stop() error {
 
 // SubmitWithRetry submits a task with retry policy
 func (rwp *RetryableWorkerPool) SubmitWithRetry(task Task) error {
-	retryableTask := NewRetryableTask(task, rwp.retryPolicy)
+	retryableTask := rwp.newRetryableTask(task)
 	rwp.metrics.RecordTaskStart()
 	return rwp.Submit(retryableTask)
 }
@@ -326,12 +411,25 @@ func (rwp *RetryableWorkerPool) SubmitWithRetry(task Task) error {
 func (rwp *RetryableWorkerPool) SubmitBatchWithRetry(tasks []Task) error {
 	retryableTasks := make([]Task, len(tasks))
 	for i, task := range tasks {
-		retryableTasks[i] = NewRetryableTask(task, rwp.retryPolicy)
+		retryableTasks[i] = rwp.newRetryableTask(task)
 		rwp.metrics.RecordTaskStart()
 	}
 	return rwp.SubmitBatch(retryableTasks)
 }
 
+// newRetryableTask wraps a task with the pool's policy and shared dependency guards
+func (rwp *RetryableWorkerPool) newRetryableTask(task Task) *RetryableTask {
+	rt := NewRetryableTask(task, rwp.retryPolicy)
+	rt.breakers = rwp.breakers
+	rt.limiters = rwp.limiters
+	return rt
+}
+
+// CircuitStates returns the circuit state of every dependency seen so far
+func (rwp *RetryableWorkerPool) CircuitStates() map[string]CircuitState {
+	return rwp.breakers.States()
+}
+
 // GetMetrics returns the current metrics
 func (rwp *RetryableWorkerPool) GetMetrics() *Metrics {
 	return rwp.metrics
@@ -389,20 +487,3 @@ func (rwp *RetryableWorkerPool) handleResult(result Result) {
 	// Record as failed
 	rwp.metrics.RecordTaskFailed(result.Duration, result.Error)
 }
-
-// Helper function for string contains check
-func contains(s, substr string) bool {
-	return len(substr) > 0 && len(s) >= len(substr) && 
-		(s == substr || (len(s) > len(substr) && 
-			(s[:len(substr)] == substr || s[len(s)-len(substr):] == substr ||
-				containsInMiddle(s, substr))))
-}
-
-func containsInMiddle(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
-}
\ No newline at end of file
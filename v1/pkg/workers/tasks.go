@@ -51,6 +51,11 @@ func (t *ServiceMigrationTask) ID() string {
 	return fmt.Sprintf("%s-%s", t.ServiceName, t.ClusterName)
 }
 
+// Dependency keys the task by the kube context of its cluster
+func (t *ServiceMigrationTask) Dependency() string {
+	return KubeDependency(t.ClusterName)
+}
+
 func (t *ServiceMigrationTask) Priority() int {
 	// Lower priority number = higher priority
 	// Default cluster gets higher priority
@@ -62,7 +67,9 @@ func (t *ServiceMigrationTask) Priority() int {
 }
 
 func (t *ServiceMigrationTask) Execute(ctx context.Context) error {
-	t.log.InfoS("Starting service migration",
+	ctx = logger.NewContext(ctx, logger.FieldService, t.ServiceName, logger.FieldCluster, t.ClusterName)
+	log := t.log.WithContext(ctx)
+	log.InfoS("Starting service migration",
 		"service", t.ServiceName,
 		"cluster", t.ClusterName,
 		"dryRun", t.DryRun)
@@ -75,7 +82,7 @@ func (t *ServiceMigrationTask) Execute(ctx context.Context) error {
 	}
 	
 	if t.DryRun {
-		t.log.InfoS("DRY RUN: Would migrate service",
+		log.InfoS("DRY RUN: Would migrate service",
 			"service", t.ServiceName,
 			"cluster", t.ClusterName)
 		return nil
@@ -109,7 +116,7 @@ func (t *ServiceMigrationTask) Execute(ctx context.Context) error {
 		return fmt.Errorf("failed to transform service: %w", err)
 	}
 	
-	t.log.InfoS("Service migration completed",
+	log.InfoS("Service migration completed",
 		"service", t.ServiceName,
 		"cluster", t.ClusterName,
 		"targetDir", targetDir)
@@ -149,12 +156,19 @@ func (t *ValuesExtractionTask) ID() string {
 	return fmt.Sprintf("extract-%s-%s-%s", t.Cluster, t.Namespace, t.ReleaseName)
 }
 
+// Dependency keys the task by the kube context of its cluster
+func (t *ValuesExtractionTask) Dependency() string {
+	return KubeDependency(t.Cluster)
+}
+
 func (t *ValuesExtractionTask) Priority() int {
 	return 5 // Higher priority than migration
 }
 
 func (t *ValuesExtractionTask) Execute(ctx context.Context) error {
-	t.log.V(3).InfoS("Extracting values",
+	ctx = logger.NewContext(ctx, logger.FieldCluster, t.Cluster, logger.FieldNamespace, t.Namespace)
+	log := t.log.WithContext(ctx)
+	log.V(3).InfoS("Extracting values",
 		"release", t.ReleaseName,
 		"namespace", t.Namespace,
 		"cluster", t.Cluster)
@@ -169,7 +183,7 @@ func (t *ValuesExtractionTask) Execute(ctx context.Context) error {
 	// This would use the actual helm service to get the release
 	// and extract values - simplified for demonstration
 	
-	t.log.V(3).InfoS("Values extracted",
+	log.V(3).InfoS("Values extracted",
 		"release", t.ReleaseName,
 		"outputPath", t.OutputPath)
 	
@@ -209,7 +223,9 @@ func (t *TransformationTask) Priority() int {
 }
 
 func (t *TransformationTask) Execute(ctx context.Context) error {
-	t.log.V(3).InfoS("Transforming values",
+	ctx = logger.NewContext(ctx, logger.FieldService, t.ServiceName, logger.FieldStep, "transform")
+	log := t.log.WithContext(ctx)
+	log.V(3).InfoS("Transforming values",
 		"service", t.ServiceName,
 		"file", t.FilePath)
 	
@@ -233,7 +249,7 @@ func (t *TransformationTask) Execute(ctx context.Context) error {
 	// Write back transformed values
 	// This would write the actual file
 	
-	t.log.V(3).InfoS("Values transformed",
+	log.V(3).InfoS("Values transformed",
 		"service", t.ServiceName,
 		"file", t.FilePath,
 		"transformed", transformed != nil)
@@ -266,12 +282,22 @@ func (t *SOPSEncryptionTask) ID() string {
 	return fmt.Sprintf("sops-%s", filepath.Base(t.FilePath))
 }
 
+// Dependency keys the task by the AWS profile used for KMS
+func (t *SOPSEncryptionTask) Dependency() string {
+	if t.AwsProfile == "" {
+		return AWSDependency("default")
+	}
+	return AWSDependency(t.AwsProfile)
+}
+
 func (t *SOPSEncryptionTask) Priority() int {
 	return 30 // Runs after transformation
 }
 
 func (t *SOPSEncryptionTask) Execute(ctx context.Context) error {
-	t.log.V(3).InfoS("Encrypting file with SOPS",
+	ctx = logger.NewContext(ctx, logger.FieldStep, "sops-encrypt")
+	log := t.log.WithContext(ctx)
+	log.V(3).InfoS("Encrypting file with SOPS",
 		"file", t.FilePath,
 		"profile", t.AwsProfile)
 	
@@ -284,7 +310,7 @@ func (t *SOPSEncryptionTask) Execute(ctx context.Context) error {
 	
 	// Check if already encrypted
 	if t.SOPSService.IsEncrypted(t.FilePath) {
-		t.log.V(4).InfoS("File already encrypted, skipping", "file", t.FilePath)
+		log.V(4).InfoS("File already encrypted, skipping", "file", t.FilePath)
 		return nil
 	}
 	
@@ -293,7 +319,66 @@ func (t *SOPSEncryptionTask) Execute(ctx context.Context) error {
 		return fmt.Errorf("failed to encrypt %s: %w", t.FilePath, err)
 	}
 	
-	t.log.V(3).InfoS("File encrypted", "file", t.FilePath)
+	log.V(3).InfoS("File encrypted", "file", t.FilePath)
+	return nil
+}
+
+// SOPSRotationTask re-wraps the data key of an encrypted file for its current keys
+type SOPSRotationTask struct {
+	FilePath    string
+	AwsProfile  string
+	SOPSService services.SOPSService
+	// Rotated is set once the task ran and the file was re-keyed
+	Rotated bool
+	log     *logger.NamedLogger
+}
+
+// NewSOPSRotationTask creates a new SOPS key rotation task
+func NewSOPSRotationTask(
+	filePath, awsProfile string,
+	sopsService services.SOPSService,
+) *SOPSRotationTask {
+	return &SOPSRotationTask{
+		FilePath:    filePath,
+		AwsProfile:  awsProfile,
+		SOPSService: sopsService,
+		log:         logger.WithName("sops-rotation-task"),
+	}
+}
+
+func (t *SOPSRotationTask) ID() string {
+	return fmt.Sprintf("sops-rotate-%s", t.FilePath)
+}
+
+// Dependency keys the task by the AWS profile used for KMS
+func (t *SOPSRotationTask) Dependency() string {
+	if t.AwsProfile == "" {
+		return AWSDependency("default")
+	}
+	return AWSDependency(t.AwsProfile)
+}
+
+func (t *SOPSRotationTask) Priority() int {
+	return 30
+}
+
+func (t *SOPSRotationTask) Execute(ctx context.Context) error {
+	ctx = logger.NewContext(ctx, logger.FieldStep, "sops-rotate")
+	log := t.log.WithContext(ctx)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	rotated, err := t.SOPSService.Rotate(t.FilePath)
+	if err != nil {
+		return err
+	}
+	t.Rotated = rotated
+
+	log.V(3).InfoS("Rotation finished", "file", t.FilePath, "rotated", rotated)
 	return nil
 }
 
@@ -322,7 +407,7 @@ func (t *BatchTask) Priority() int {
 }
 
 func (t *BatchTask) Execute(ctx context.Context) error {
-	log := logger.WithName("batch-task")
+	log := logger.FromContext(ctx, "batch-task")
 	log.InfoS("Starting batch task", "name", t.Name, "taskCount", len(t.SubTasks))
 	
 	// Start the pool